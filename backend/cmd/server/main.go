@@ -2,21 +2,38 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	nethttp "net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/email-sorting-app/internal/adapters/ai"
+	"github.com/email-sorting-app/internal/adapters/artifacts"
+	"github.com/email-sorting-app/internal/adapters/database/migrations"
 	"github.com/email-sorting-app/internal/adapters/database/postgres"
 	"github.com/email-sorting-app/internal/adapters/gmail"
 	"github.com/email-sorting-app/internal/adapters/http"
 	"github.com/email-sorting-app/internal/adapters/http/handlers"
+	"github.com/email-sorting-app/internal/adapters/imageproxy"
+	"github.com/email-sorting-app/internal/adapters/risk"
 	"github.com/email-sorting-app/internal/adapters/unsubscribe"
 	"github.com/email-sorting-app/internal/config"
+	"github.com/email-sorting-app/internal/domain/repositories"
 	"github.com/email-sorting-app/internal/usecases"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func main() {
+	// Database migrations can be managed without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -30,10 +47,34 @@ func main() {
 	}
 	defer db.Close()
 
+	// Bring the schema up to date
+	if cfg.AutoMigrate {
+		migrator, err := migrations.NewMigrator(db)
+		if err != nil {
+			log.Fatal("Failed to load migrations:", err)
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		if applied > 0 {
+			fmt.Printf("Applied %d database migrations\n", applied)
+		}
+	}
+
 	// Initialize repositories
 	accountRepo := postgres.NewAccountRepository(db)
 	emailRepo := postgres.NewEmailRepository(db)
 	categoryRepo := postgres.NewCategoryRepository(db)
+	aiUsageRepo := postgres.NewAIUsageRepository(db)
+	extractionRepo := postgres.NewExtractionRepository(db)
+	threadRepo := postgres.NewThreadRepository(db)
+	subscriptionRepo := postgres.NewSubscriptionRepository(db)
+	jobRepo := postgres.NewJobRepository(db)
+	unsubscribePlanRepo := postgres.NewUnsubscribePlanRepository(db)
+	unsubscribeRecipeRepo := postgres.NewUnsubscribeRecipeRepository(db)
+	senderRepo := postgres.NewSenderRepository(db)
+	attachmentRepo := postgres.NewAttachmentRepository(db)
 
 	// Initialize OAuth config
 	oauthConfig := cfg.OAuthConfig()
@@ -49,31 +90,104 @@ func main() {
 	gmailService := gmail.NewGmailService(oauthConfig, aiService)
 
 	// Initialize unsubscribe service
-	unsubscribeService := unsubscribe.NewWebAutomationService(aiService)
-	defer func() {
-		if err := unsubscribeService.Close(); err != nil {
-			log.Printf("Failed to close unsubscribe service: %v", err)
+	var blockedDomains []string
+	if cfg.UnsubscribeBlocklistFile != "" {
+		blockedDomains, err = unsubscribe.LoadBlocklist(cfg.UnsubscribeBlocklistFile)
+		if err != nil {
+			log.Fatal("Failed to load unsubscribe blocklist:", err)
 		}
-	}()
+	}
+	urlPolicy := unsubscribe.NewURLPolicy(cfg.UnsubscribeMaxRedirects, blockedDomains)
+	artifactStore, err := artifacts.NewLocalStore(cfg.ArtifactDir)
+	if err != nil {
+		log.Fatal("Failed to initialize artifact store:", err)
+	}
+	// Remote images of emails are fetched under the same rules as unsubscribe links
+	imageProxy := imageproxy.NewProxy([]byte(cfg.ImageProxySecret), urlPolicy.HTTPClient(10*time.Second))
+	unsubscribeService := unsubscribe.NewWebAutomationService(aiService, gmailService, accountRepo, urlPolicy, artifactStore, unsubscribeRecipeRepo, cfg.UnsubscribeBrowserContexts)
+
+	// Initialize risk scoring; the AI second opinion is opt-in and shares the daily AI budget
+	var riskAI repositories.AIService
+	if cfg.RiskAIEnabled {
+		riskAI = aiService
+	}
+	riskService := risk.NewRiskService(riskAI)
+
+	// Background workers stop when the server is asked to shut down; unsubscribe and
+	// archive jobs stop starting items, and the running ones are drained below
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
 
 	// Initialize use cases
 	authUsecase := usecases.NewAuthUsecase(accountRepo, oauthConfig)
 	accountUsecase := usecases.NewAccountUsecase(accountRepo)
 	categoryUsecase := usecases.NewCategoryUsecase(categoryRepo, accountRepo, gmailService)
-	emailUsecase := usecases.NewEmailUsecase(emailRepo, accountRepo, categoryRepo, gmailService, aiService, unsubscribeService)
+	aiBudget := usecases.NewAIBudget(aiUsageRepo, cfg.AIDailyBudget)
+	summaryUsecase := usecases.NewSummaryUsecase(emailRepo, accountRepo, categoryRepo, aiService, aiBudget, cfg.SummaryBacklogInterval)
+	extractionUsecase := usecases.NewExtractionUsecase(emailRepo, extractionRepo, aiService, aiBudget, cfg.ExtractionBacklogInterval)
+	threadUsecase := usecases.NewThreadUsecase(threadRepo, emailRepo, aiService, aiBudget)
+	riskUsecase := usecases.NewRiskUsecase(emailRepo, accountRepo, categoryRepo, gmailService, riskService, aiBudget, cfg.RiskThreshold, cfg.RiskBacklogInterval)
+	subscriptionUsecase := usecases.NewSubscriptionUsecase(subscriptionRepo, unsubscribePlanRepo, emailRepo, accountRepo, gmailService, unsubscribeService, artifactStore, cfg.UnsubscribeGracePeriod, cfg.UnsubscribeViolationAction, cfg.UnsubscribeRetryEnabled)
+	jobUsecase := usecases.NewJobUsecase(jobRepo, emailRepo, accountRepo, gmailService, subscriptionUsecase, cfg.UnsubscribeConcurrency, cfg.UnsubscribeHostDelay)
+	senderUsecase := usecases.NewSenderUsecase(senderRepo, emailRepo, accountRepo, categoryRepo, jobUsecase)
+	attachmentUsecase := usecases.NewAttachmentUsecase(attachmentRepo, emailRepo, accountRepo, gmailService, cfg.AttachmentTextExtraction, int64(cfg.AttachmentMaxExtractSize), cfg.AttachmentBacklogInterval)
+	emailUsecase := usecases.NewEmailUsecase(emailRepo, accountRepo, categoryRepo, gmailService, aiService, summaryUsecase, extractionUsecase, threadUsecase, riskUsecase, subscriptionUsecase, senderUsecase, attachmentUsecase, imageProxy)
+
+	// Start background workers
+	unsubscribeService.Start(ctx)
+	summaryUsecase.Start(ctx)
+	extractionUsecase.Start(ctx)
+	threadUsecase.Start(ctx)
+	riskUsecase.Start(ctx)
+	subscriptionUsecase.Start(ctx)
+	jobUsecase.Start(ctx)
+	attachmentUsecase.Start(ctx)
 
 	// Initialize HTTP handlers
 	authHandler := handlers.NewAuthHandler(authUsecase)
 	accountHandler := handlers.NewAccountHandler(accountUsecase)
 	categoryHandler := handlers.NewCategoryHandler(categoryUsecase)
-	emailHandler := handlers.NewEmailHandler(emailUsecase)
+	emailHandler := handlers.NewEmailHandler(emailUsecase, cfg.PublicBaseURL)
+	summaryHandler := handlers.NewSummaryHandler(summaryUsecase)
+	extractionHandler := handlers.NewExtractionHandler(extractionUsecase)
+	threadHandler := handlers.NewThreadHandler(threadUsecase)
+	riskHandler := handlers.NewRiskHandler(riskUsecase)
+	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionUsecase)
+	jobHandler := handlers.NewJobHandler(jobUsecase)
+	senderHandler := handlers.NewSenderHandler(senderUsecase)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentUsecase)
 
 	// Setup routes
-	r := http.SetupRoutes(authHandler, accountHandler, categoryHandler, emailHandler)
+	r := http.SetupRoutes(authHandler, accountHandler, categoryHandler, emailHandler, summaryHandler, extractionHandler, threadHandler, riskHandler, subscriptionHandler, jobHandler, senderHandler, attachmentHandler)
 
 	// Start server
-	fmt.Printf("Server starting on port %s\n", cfg.Port)
-	log.Fatal(r.Run(":" + cfg.Port))
+	server := &nethttp.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: r,
+	}
+	go func() {
+		fmt.Printf("Server starting on port %s\n", cfg.Port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, nethttp.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	// Stop accepting requests, then let running job items finish before closing the browser
+	<-ctx.Done()
+	fmt.Println("Shutting down server...")
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelShutdown()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down server: %v", err)
+	}
+	if err := jobUsecase.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down jobs: %v", err)
+	}
+	if err := unsubscribeService.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down unsubscribe service: %v", err)
+	}
 }
 
 func initDB(databaseURL string) (*pgxpool.Pool, error) {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/email-sorting-app/internal/domain/entities"
 	"github.com/email-sorting-app/internal/domain/repositories"
@@ -12,6 +13,10 @@ import (
 	"google.golang.org/api/option"
 )
 
+// maxThreadMessageLength caps each message body sent for thread summaries so long
+// conversations stay within the prompt size
+const maxThreadMessageLength = 4000
+
 type GeminiService struct {
 	client *genai.Client
 }
@@ -41,7 +46,7 @@ Subject: %s
 From: %s
 Body: %s
 
-Summary:`, email.Subject, email.Sender, email.Body)
+Summary:`, email.Subject, email.Sender, email.PlainBody())
 
 	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
 	if err != nil {
@@ -56,6 +61,51 @@ Summary:`, email.Subject, email.Sender, email.Body)
 	return strings.TrimSpace(summary), nil
 }
 
+func (g *GeminiService) SummarizeThread(ctx context.Context, thread *entities.Thread, newMessages []entities.Email) (string, error) {
+	model := g.client.GenerativeModel("models/gemini-2.0-flash")
+
+	var messagesStr strings.Builder
+	for _, msg := range newMessages {
+		messagesStr.WriteString(fmt.Sprintf("---\nFrom: %s\nDate: %s\nBody: %s\n", msg.Sender, msg.ReceivedAt.Format(time.RFC1123), truncate(msg.PlainBody(), maxThreadMessageLength)))
+	}
+
+	var prompt string
+	if thread.AISummary != nil && *thread.AISummary != "" {
+		prompt = fmt.Sprintf(`Update the summary of this email conversation with the new replies. Keep it to 2-4 sentences,
+mention who said what when it matters, and highlight any decisions or open questions.
+
+Subject: %s
+
+Current summary:
+%s
+
+New replies:
+%s
+Updated summary:`, thread.Subject, *thread.AISummary, messagesStr.String())
+	} else {
+		prompt = fmt.Sprintf(`Summarize this email conversation in 2-4 sentences. Mention who said what when it matters,
+and highlight any decisions or open questions.
+
+Subject: %s
+
+Messages (oldest first):
+%s
+Summary:`, thread.Subject, messagesStr.String())
+	}
+
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate thread summary: %w", err)
+	}
+
+	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("no content generated")
+	}
+
+	summary := fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
+	return strings.TrimSpace(summary), nil
+}
+
 func (g *GeminiService) CategorizeEmail(ctx context.Context, email *entities.Email, categories []entities.Category) ([]int64, error) {
 	if len(categories) == 0 {
 		return []int64{}, nil
@@ -73,12 +123,19 @@ func (g *GeminiService) CategorizeEmail(ctx context.Context, email *entities.Ema
 		categoriesStr.WriteString(fmt.Sprintf("- %s: %s\n", cat.Name, description))
 	}
 
+	// Documents such as invoices often say more about the email than its body
+	attachmentText := "None"
+	if email.AttachmentText != "" {
+		attachmentText = truncate(email.AttachmentText, maxThreadMessageLength)
+	}
+
 	prompt := fmt.Sprintf(`Given the following email and categories, determine which categories this email belongs to. An email can belong to multiple categories or none at all.
 
 Email:
 Subject: %s
 From: %s
 Body: %s
+Attachment text: %s
 
 Available Categories:
 %s
@@ -89,7 +146,7 @@ Instructions:
 - Be strict - only categorize if there's a clear match with the category description
 - An email can belong to multiple categories
 
-Categories:`, email.Subject, email.Sender, email.Body, categoriesStr.String())
+Categories:`, email.Subject, email.Sender, email.PlainBody(), attachmentText, categoriesStr.String())
 
 	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
 	if err != nil {
@@ -244,3 +301,228 @@ URL:`, headers, body)
 
 	return result, nil
 }
+
+func (g *GeminiService) DraftReply(ctx context.Context, email *entities.Email, instruction, tone string) (string, error) {
+	model := g.client.GenerativeModel("models/gemini-2.0-flash")
+
+	if instruction == "" {
+		instruction = "Write an appropriate reply."
+	}
+	if tone == "" {
+		tone = "professional and friendly"
+	}
+
+	prompt := fmt.Sprintf(`Draft a reply to this email on behalf of its recipient.
+
+Subject: %s
+From: %s
+Body: %s
+
+What the reply should say: %s
+Tone: %s
+
+Instructions:
+- Return only the body of the reply as plain text
+- Do not include a subject line, headers or the quoted original message
+- Do not invent facts, dates or commitments that are not in the instructions or the email
+- Leave placeholders like [your name] where information is missing
+
+Reply:`, email.Subject, email.Sender, truncate(email.PlainBody(), maxThreadMessageLength), instruction, tone)
+
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return "", fmt.Errorf("failed to draft reply: %w", err)
+	}
+
+	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("no content generated")
+	}
+
+	reply := fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
+	return strings.TrimSpace(reply), nil
+}
+
+func (g *GeminiService) AssessPhishingRisk(ctx context.Context, email *entities.Email, signals []string) (*entities.RiskJudgement, error) {
+	model := g.client.GenerativeModel("models/gemini-2.0-flash")
+
+	prompt := fmt.Sprintf(`You are an email security analyst. Judge how likely this email is phishing or a scam.
+
+Subject: %s
+From: %s
+Body: %s
+
+Automated checks flagged:
+- %s
+
+Instructions:
+- Consider impersonation, credential or payment requests, pressure tactics and mismatched links
+- Automated checks can be wrong; legitimate newsletters often trip them
+- Return a score from 0.0 (clearly legitimate) to 1.0 (clearly malicious) and a one-sentence reason
+
+Return only JSON: {"score": 0.0, "reason": "..."}
+
+JSON:`, email.Subject, email.Sender, truncate(email.PlainBody(), maxThreadMessageLength), strings.Join(signals, "\n- "))
+
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to assess phishing risk: %w", err)
+	}
+
+	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
+		return nil, fmt.Errorf("no content generated for risk assessment")
+	}
+
+	responseText := fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
+
+	jsonStart := strings.Index(responseText, "{")
+	jsonEnd := strings.LastIndex(responseText, "}")
+	if jsonStart == -1 || jsonEnd == -1 {
+		return nil, fmt.Errorf("failed to parse AI response as JSON")
+	}
+
+	var judgement entities.RiskJudgement
+	err = json.Unmarshal([]byte(responseText[jsonStart:jsonEnd+1]), &judgement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	}
+
+	return &judgement, nil
+}
+
+type extractionResponse struct {
+	ActionItems []struct {
+		Description string `json:"description"`
+		DueDate     string `json:"due_date"`
+	} `json:"action_items"`
+	Entities []struct {
+		Type     string `json:"type"`
+		Value    string `json:"value"`
+		DateTime string `json:"datetime"`
+	} `json:"entities"`
+}
+
+func (g *GeminiService) ExtractEmailData(ctx context.Context, email *entities.Email) (*entities.EmailExtraction, error) {
+	model := g.client.GenerativeModel("models/gemini-2.0-flash")
+
+	prompt := fmt.Sprintf(`Extract structured data from this email.
+
+Received: %s
+Subject: %s
+From: %s
+Body: %s
+
+Instructions:
+- List the action items the recipient is asked to do, with a due date when one is stated or implied
+- List entities of these types: "amount" (money amounts with currency), "person" (people mentioned by name),
+  "tracking_number" (shipment tracking numbers), "meeting_time" (scheduled meetings or calls), "date" (other important dates)
+- Resolve relative dates like "next Friday" using the received date
+- Format dates as RFC3339 (e.g. 2024-05-01T15:00:00Z) or YYYY-MM-DD when there is no time
+- Use an empty string when a date is unknown
+- Return empty arrays if nothing is found
+
+Return only JSON with this structure:
+{
+  "action_items": [{"description": "what to do", "due_date": "date or empty"}],
+  "entities": [{"type": "amount|person|tracking_number|meeting_time|date", "value": "text as written", "datetime": "date or empty"}]
+}
+
+JSON:`, email.ReceivedAt.Format(time.RFC3339), email.Subject, email.Sender, email.PlainBody())
+
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract email data: %w", err)
+	}
+
+	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
+		return nil, fmt.Errorf("no content generated for extraction")
+	}
+
+	responseText := fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
+
+	extraction, err := parseExtractionResponse(responseText)
+	if err != nil {
+		return nil, err
+	}
+	extraction.EmailID = email.ID
+
+	return extraction, nil
+}
+
+// parseExtractionResponse converts the JSON returned by the model into domain types,
+// dropping entities of unknown types and empty values
+func parseExtractionResponse(responseText string) (*entities.EmailExtraction, error) {
+	jsonStart := strings.Index(responseText, "{")
+	jsonEnd := strings.LastIndex(responseText, "}")
+	if jsonStart == -1 || jsonEnd == -1 {
+		return nil, fmt.Errorf("failed to parse AI response as JSON")
+	}
+
+	var parsed extractionResponse
+	if err := json.Unmarshal([]byte(responseText[jsonStart:jsonEnd+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	}
+
+	extraction := &entities.EmailExtraction{
+		ActionItems: []entities.ActionItem{},
+		Entities:    []entities.ExtractedEntity{},
+	}
+
+	for _, item := range parsed.ActionItems {
+		description := strings.TrimSpace(item.Description)
+		if description == "" {
+			continue
+		}
+		extraction.ActionItems = append(extraction.ActionItems, entities.ActionItem{
+			Description: description,
+			DueAt:       parseExtractedTime(item.DueDate),
+			Status:      entities.ActionItemStatusOpen,
+		})
+	}
+
+	for _, entity := range parsed.Entities {
+		value := strings.TrimSpace(entity.Value)
+		if value == "" {
+			continue
+		}
+
+		entityType := strings.ToLower(strings.TrimSpace(entity.Type))
+		switch entityType {
+		case entities.ExtractedEntityAmount, entities.ExtractedEntityPerson, entities.ExtractedEntityTrackingNumber,
+			entities.ExtractedEntityMeetingTime, entities.ExtractedEntityDate:
+		default:
+			continue
+		}
+
+		extraction.Entities = append(extraction.Entities, entities.ExtractedEntity{
+			Type:     entityType,
+			Value:    value,
+			OccursAt: parseExtractedTime(entity.DateTime),
+		})
+	}
+
+	return extraction, nil
+}
+
+func parseExtractedTime(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	layouts := []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+
+	return nil
+}
+
+func truncate(text string, maxLength int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLength {
+		return text
+	}
+	return string(runes[:maxLength]) + "..."
+}
@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExtractionResponse(t *testing.T) {
+	response := "```json\n" + `{
+  "action_items": [
+    {"description": "Pay the invoice", "due_date": "2024-05-01"},
+    {"description": "  ", "due_date": ""},
+    {"description": "Reply to Anna", "due_date": ""}
+  ],
+  "entities": [
+    {"type": "amount", "value": "$120.50", "datetime": ""},
+    {"type": "Meeting_Time", "value": "Tuesday 3pm", "datetime": "2024-04-30T15:00:00Z"},
+    {"type": "color", "value": "blue", "datetime": ""}
+  ]
+}` + "\n```"
+
+	extraction, err := parseExtractionResponse(response)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(extraction.ActionItems) != 2 {
+		t.Fatalf("Expected 2 action items, got %d", len(extraction.ActionItems))
+	}
+
+	due := extraction.ActionItems[0].DueAt
+	if due == nil || !due.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected first due date to be 2024-05-01, got %v", due)
+	}
+
+	if extraction.ActionItems[1].DueAt != nil {
+		t.Errorf("Expected second due date to be nil, got %v", extraction.ActionItems[1].DueAt)
+	}
+
+	if extraction.ActionItems[0].Status != "open" {
+		t.Errorf("Expected status to be 'open', got '%s'", extraction.ActionItems[0].Status)
+	}
+
+	if len(extraction.Entities) != 2 {
+		t.Fatalf("Expected 2 entities, got %d", len(extraction.Entities))
+	}
+
+	if extraction.Entities[1].Type != "meeting_time" {
+		t.Errorf("Expected entity type to be 'meeting_time', got '%s'", extraction.Entities[1].Type)
+	}
+
+	if extraction.Entities[1].OccursAt == nil {
+		t.Error("Expected meeting time to be parsed")
+	}
+}
+
+func TestParseExtractionResponse_InvalidJSON(t *testing.T) {
+	_, err := parseExtractionResponse("no json here")
+	if err == nil {
+		t.Error("Expected an error for a response without JSON")
+	}
+}
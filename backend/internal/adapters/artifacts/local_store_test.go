@@ -0,0 +1,60 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	apperrors "github.com/email-sorting-app/pkg/errors"
+)
+
+func TestLocalStore_SaveAndOpen(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ctx := context.Background()
+
+	if err := store.Save(ctx, "unsubscribe/email-1/before.png", []byte("png")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	reader, err := store.Open(ctx, "unsubscribe/email-1/before.png")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil || string(data) != "png" {
+		t.Errorf("Expected saved contents, got %q, %v", data, err)
+	}
+}
+
+func TestLocalStore_OpenMissingArtifact(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := store.Open(context.Background(), "unsubscribe/email-1/missing.png"); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestLocalStore_RejectsKeysOutsideStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, key := range []string{"", "../secret", "a/../../secret", "/etc/passwd", `a\..\..\secret`} {
+		if err := store.Save(context.Background(), key, []byte("x")); err == nil {
+			t.Errorf("Expected key %q to be rejected", key)
+		}
+		if _, err := store.Open(context.Background(), key); err == nil {
+			t.Errorf("Expected key %q to be rejected", key)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+drop table if exists email_categories;
+drop table if exists emails;
+drop table if exists categories;
+drop table if exists accounts;
@@ -0,0 +1,7 @@
+drop index if exists idx_emails_pending_summary;
+drop table if exists ai_daily_usage;
+alter table emails
+    drop column summary_failed_at,
+    drop column summary_attempts;
+alter table categories drop column auto_summarize;
+alter table accounts drop column auto_summarize;
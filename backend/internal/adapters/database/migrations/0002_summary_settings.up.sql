@@ -0,0 +1,19 @@
+alter table accounts add column auto_summarize boolean not null default true;
+alter table categories add column auto_summarize boolean not null default true;
+
+-- Failed summaries are retried with a backoff and given up after a few attempts
+alter table emails
+    add column summary_attempts integer not null default 0,
+    add column summary_failed_at timestamp with time zone;
+
+-- Daily counters used to keep background AI work under a budget
+create table ai_daily_usage (
+    id bigserial primary key,
+    usage_date date not null,
+    feature varchar(64) not null,
+    count integer not null default 0,
+    updated_at timestamp with time zone not null default now(),
+    unique(usage_date, feature)
+);
+
+create index idx_emails_pending_summary on emails(received_at desc) where ai_summary is null;
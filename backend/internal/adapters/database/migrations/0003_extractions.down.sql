@@ -0,0 +1,8 @@
+drop index if exists idx_emails_pending_extraction;
+alter table emails
+    drop column extraction_failed_at,
+    drop column extraction_attempts,
+    drop column extraction_pending;
+
+drop table if exists extracted_entities;
+drop table if exists action_items;
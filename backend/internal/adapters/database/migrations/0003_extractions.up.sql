@@ -0,0 +1,36 @@
+-- Structured data extracted from emails by AI
+create table action_items (
+    id bigserial primary key,
+    email_id bigint not null references emails(id) on delete cascade,
+    account_id bigint not null references accounts(id) on delete cascade,
+    description text not null,
+    -- Normalized description; re-extraction updates items by it, keeping their status
+    item_key text not null,
+    due_at timestamp with time zone,
+    status varchar(32) not null default 'open',
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+create table extracted_entities (
+    id bigserial primary key,
+    email_id bigint not null references emails(id) on delete cascade,
+    entity_type varchar(64) not null,
+    value text not null,
+    occurs_at timestamp with time zone,
+    created_at timestamp with time zone not null default now()
+);
+
+create unique index idx_action_items_email_key on action_items(email_id, item_key);
+create index idx_action_items_open_due on action_items(due_at nulls last) where status = 'open';
+create index idx_extracted_entities_email_id on extracted_entities(email_id);
+
+-- New emails are extracted in the background, retrying failures with a backoff and
+-- giving up after a few attempts. Emails synced before are extracted on demand.
+alter table emails
+    add column extraction_pending boolean not null default false,
+    add column extraction_attempts integer not null default 0,
+    add column extraction_failed_at timestamp with time zone;
+alter table emails alter column extraction_pending set default true;
+
+create index idx_emails_pending_extraction on emails(received_at desc) where extraction_pending;
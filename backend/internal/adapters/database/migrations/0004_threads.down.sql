@@ -0,0 +1,6 @@
+drop index if exists idx_emails_thread_id;
+alter table emails
+    drop column thread_summarized,
+    drop column thread_id,
+    drop column gmail_thread_id;
+drop table if exists threads;
@@ -0,0 +1,22 @@
+-- Gmail conversations; emails reference their thread
+create table threads (
+    id bigserial primary key,
+    account_id bigint not null references accounts(id) on delete cascade,
+    gmail_thread_id varchar(256) not null,
+    subject text,
+    message_count integer not null default 0,
+    last_message_at timestamp with time zone,
+    ai_summary text,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now(),
+    unique(account_id, gmail_thread_id)
+);
+
+alter table emails
+    add column gmail_thread_id varchar(256),
+    add column thread_id bigint references threads(id) on delete set null,
+    -- Set once the thread summary covers the message
+    add column thread_summarized boolean not null default false;
+
+create index idx_emails_thread_id on emails(thread_id);
+create index idx_threads_account_last_message on threads(account_id, last_message_at desc);
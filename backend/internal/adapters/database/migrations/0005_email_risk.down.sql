@@ -0,0 +1,7 @@
+drop index if exists idx_emails_pending_risk;
+drop index if exists idx_emails_risk_score;
+alter table emails
+    drop column risk_failed_at,
+    drop column risk_attempts,
+    drop column risk_reasons,
+    drop column risk_score;
@@ -0,0 +1,10 @@
+alter table emails
+    add column risk_score real,
+    add column risk_reasons text[],
+    -- Emails that couldn't be scored during sync are scored by a backlog processor,
+    -- which retries failures with a backoff and gives up after a few attempts
+    add column risk_attempts integer not null default 0,
+    add column risk_failed_at timestamp with time zone;
+
+create index idx_emails_risk_score on emails(account_id, risk_score desc) where risk_score is not null;
+create index idx_emails_pending_risk on emails(received_at desc) where risk_score is null;
@@ -0,0 +1,3 @@
+alter table emails
+    drop column list_unsubscribe_post,
+    drop column list_unsubscribe;
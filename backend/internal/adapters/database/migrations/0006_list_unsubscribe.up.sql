@@ -0,0 +1,4 @@
+-- Raw List-Unsubscribe and List-Unsubscribe-Post headers (RFC 2369 / RFC 8058)
+alter table emails
+    add column list_unsubscribe text,
+    add column list_unsubscribe_post text;
@@ -0,0 +1,2 @@
+drop view if exists subscriptions;
+drop table if exists unsubscribe_attempts;
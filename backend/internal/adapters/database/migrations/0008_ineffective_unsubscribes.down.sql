@@ -0,0 +1,38 @@
+drop view if exists subscriptions;
+alter table unsubscribe_attempts drop column ineffective_at;
+alter table emails drop column mailed_after_unsubscribe;
+
+-- One row per mailing-list sender; a successful attempt wins over later failures
+create view subscriptions as
+select s.account_id,
+       s.sender_address,
+       split_part(s.sender_address, '@', 2) as sender_domain,
+       trim(both ' "' from split_part(s.latest_sender, '<', 1)) as sender_name,
+       s.email_count,
+       s.last_received_at,
+       case
+           when a.id is null then 'subscribed'
+           when a.success then 'unsubscribed'
+           when a.requires_auth then 'requires_auth'
+           else 'failed'
+       end as status,
+       a.created_at as last_attempt_at,
+       a.strategy as last_strategy,
+       a.error_type as last_error_type
+from (
+    select account_id,
+           lower(trim(coalesce(substring(sender from '<([^>]+)>'), sender))) as sender_address,
+           (array_agg(sender order by received_at desc))[1] as latest_sender,
+           count(*) as email_count,
+           max(received_at) as last_received_at
+    from emails
+    where unsubscribe_link is not null or list_unsubscribe is not null
+    group by 1, 2
+) s
+left join lateral (
+    select ua.id, ua.success, ua.requires_auth, ua.created_at, ua.strategy, ua.error_type
+    from unsubscribe_attempts ua
+    where ua.account_id = s.account_id and ua.sender_address = s.sender_address
+    order by ua.success desc, ua.created_at desc
+    limit 1
+) a on true;
@@ -0,0 +1,42 @@
+alter table emails add column mailed_after_unsubscribe boolean not null default false;
+
+-- Set when the sender kept mailing after the grace period of a successful attempt
+alter table unsubscribe_attempts add column ineffective_at timestamp with time zone;
+
+-- One row per mailing-list sender; an effective successful attempt wins over later failures
+drop view subscriptions;
+create view subscriptions as
+select s.account_id,
+       s.sender_address,
+       split_part(s.sender_address, '@', 2) as sender_domain,
+       trim(both ' "' from split_part(s.latest_sender, '<', 1)) as sender_name,
+       s.email_count,
+       s.last_received_at,
+       case
+           when a.id is null then 'subscribed'
+           when a.success and a.ineffective_at is null then 'unsubscribed'
+           when a.success then 'ineffective'
+           when a.requires_auth then 'requires_auth'
+           when a.error_type = 'unverified' then 'unverified'
+           else 'failed'
+       end as status,
+       a.created_at as last_attempt_at,
+       a.strategy as last_strategy,
+       a.error_type as last_error_type
+from (
+    select account_id,
+           lower(trim(coalesce(substring(sender from '<([^>]+)>'), sender))) as sender_address,
+           (array_agg(sender order by received_at desc))[1] as latest_sender,
+           count(*) as email_count,
+           max(received_at) as last_received_at
+    from emails
+    where unsubscribe_link is not null or list_unsubscribe is not null
+    group by 1, 2
+) s
+left join lateral (
+    select ua.id, ua.success, ua.requires_auth, ua.ineffective_at, ua.created_at, ua.strategy, ua.error_type
+    from unsubscribe_attempts ua
+    where ua.account_id = s.account_id and ua.sender_address = s.sender_address
+    order by (ua.success and ua.ineffective_at is null) desc, ua.created_at desc
+    limit 1
+) a on true;
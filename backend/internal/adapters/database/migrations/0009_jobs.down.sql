@@ -0,0 +1,2 @@
+drop table if exists job_items;
+drop table if exists jobs;
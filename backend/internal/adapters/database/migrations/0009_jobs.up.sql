@@ -0,0 +1,23 @@
+-- Bulk operations on emails, such as unsubscribing or archiving, processed in the background
+create table jobs (
+    id bigserial primary key,
+    kind varchar(32) not null default 'unsubscribe',
+    status varchar(32) not null default 'queued',
+    created_at timestamp with time zone not null default now(),
+    started_at timestamp with time zone,
+    finished_at timestamp with time zone
+);
+
+create table job_items (
+    id bigserial primary key,
+    job_id bigint not null references jobs(id) on delete cascade,
+    email_id bigint not null,
+    status varchar(32) not null default 'pending',
+    strategy varchar(32),
+    message text not null default '',
+    error_type varchar(64),
+    updated_at timestamp with time zone not null default now()
+);
+
+create index idx_job_items_job_id on job_items(job_id);
+create index idx_jobs_unfinished on jobs(id) where status in ('queued', 'running');
@@ -0,0 +1,21 @@
+-- Dry-run unsubscribe plans awaiting the user's approval
+create table unsubscribe_plans (
+    id bigserial primary key,
+    account_id bigint not null references accounts(id) on delete cascade,
+    email_id bigint not null references emails(id) on delete cascade,
+    strategy varchar(32) not null,
+    message text not null default '',
+    page_url text,
+    actions jsonb not null default '[]',
+    screenshots text[] not null default '{}',
+    created_at timestamp with time zone not null default now(),
+    expires_at timestamp with time zone not null,
+    -- A plan is claimed while it runs and only marked executed once the unsubscribe
+    -- succeeded; a failed run releases it so it can be approved again
+    started_at timestamp with time zone,
+    failed_at timestamp with time zone,
+    executed_at timestamp with time zone
+);
+
+-- Expired plans are purged
+create index idx_unsubscribe_plans_expires_at on unsubscribe_plans(expires_at);
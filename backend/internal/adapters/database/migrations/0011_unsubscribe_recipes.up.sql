@@ -0,0 +1,14 @@
+-- Action sequences that unsubscribed successfully, replayed on pages with the same host and path
+create table unsubscribe_recipes (
+    id bigserial primary key,
+    host varchar(255) not null,
+    path_pattern text not null,
+    actions jsonb not null,
+    success_count integer not null default 1,
+    -- Replays in a row that the page didn't confirm; the recipe is dropped after a few
+    miss_count integer not null default 0,
+    last_used_at timestamp with time zone not null default now(),
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now(),
+    unique(host, path_pattern)
+);
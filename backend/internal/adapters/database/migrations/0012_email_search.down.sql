@@ -0,0 +1,2 @@
+drop index if exists idx_emails_search;
+alter table emails drop column search_vector;
@@ -0,0 +1,9 @@
+-- Full-text search document, weighted so subject matches rank above body matches
+alter table emails add column search_vector tsvector generated always as (
+    setweight(to_tsvector('english', coalesce(subject, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(sender, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(ai_summary, '')), 'C') ||
+    setweight(to_tsvector('english', coalesce(body, '')), 'D')
+) stored;
+
+create index idx_emails_search on emails using gin(search_vector);
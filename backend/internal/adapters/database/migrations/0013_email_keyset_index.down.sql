@@ -0,0 +1 @@
+drop index if exists idx_emails_account_received;
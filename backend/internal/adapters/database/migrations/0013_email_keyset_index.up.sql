@@ -0,0 +1,2 @@
+-- Keyset pagination walks emails newest first with the ID as tie-breaker
+create index idx_emails_account_received on emails(account_id, received_at desc, id desc);
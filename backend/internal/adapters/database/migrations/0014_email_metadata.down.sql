@@ -0,0 +1,22 @@
+-- Fails if a message has since been stored for more than one account
+alter table emails add constraint emails_gmail_message_id_key unique (gmail_message_id);
+
+drop index if exists idx_emails_starred;
+drop index if exists idx_emails_unread;
+drop index if exists idx_emails_recipients;
+drop index if exists idx_emails_list_id;
+drop index if exists idx_emails_in_reply_to;
+drop index if exists idx_emails_message_id;
+
+alter table emails
+    drop column is_starred,
+    drop column is_read,
+    drop column size_estimate,
+    drop column snippet,
+    drop column list_id,
+    drop column message_references,
+    drop column in_reply_to,
+    drop column message_id,
+    drop column reply_to,
+    drop column cc_addresses,
+    drop column to_addresses;
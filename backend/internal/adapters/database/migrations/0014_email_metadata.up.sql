@@ -0,0 +1,25 @@
+-- Message metadata kept from the Gmail headers and labels at sync. Read state isn't
+-- known for emails synced before this, so they count as read until their labels change.
+alter table emails
+    add column to_addresses text[] not null default '{}',
+    add column cc_addresses text[] not null default '{}',
+    add column reply_to text,
+    add column message_id text,
+    add column in_reply_to text,
+    add column message_references text[] not null default '{}',
+    add column list_id text,
+    add column snippet text,
+    add column size_estimate bigint not null default 0,
+    add column is_read boolean not null default true,
+    add column is_starred boolean not null default false;
+
+create index idx_emails_message_id on emails(account_id, message_id) where message_id is not null;
+create index idx_emails_in_reply_to on emails(account_id, in_reply_to) where in_reply_to is not null;
+create index idx_emails_list_id on emails(account_id, list_id) where list_id is not null;
+create index idx_emails_recipients on emails using gin(to_addresses, cc_addresses);
+create index idx_emails_unread on emails(account_id, received_at desc) where not is_read;
+create index idx_emails_starred on emails(account_id, received_at desc) where is_starred;
+
+-- Gmail message IDs are only unique within a mailbox; the same message can be stored
+-- once per account, which unique(account_id, gmail_message_id) already enforces
+alter table emails drop constraint emails_gmail_message_id_key;
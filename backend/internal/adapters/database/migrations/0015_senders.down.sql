@@ -0,0 +1,3 @@
+drop index if exists idx_emails_sender_id;
+alter table emails drop column sender_id;
+drop table if exists senders;
@@ -0,0 +1,48 @@
+-- One row per address that has mailed an account; emails link to their sender
+create table senders (
+    id bigserial primary key,
+    account_id bigint not null references accounts(id) on delete cascade,
+    address text not null,
+    display_name text not null default '',
+    domain text not null default '',
+    first_seen_at timestamp with time zone,
+    last_seen_at timestamp with time zone,
+    message_count integer not null default 0,
+    has_unsubscribe boolean not null default false,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now(),
+    unique(account_id, address)
+);
+
+alter table emails add column sender_id bigint references senders(id) on delete set null;
+
+create index idx_emails_sender_id on emails(sender_id, received_at desc);
+create index idx_senders_account_volume on senders(account_id, message_count desc);
+
+-- Backfill from the emails already stored, using the address expression of the subscriptions view
+insert into senders (account_id, address, display_name, domain, first_seen_at, last_seen_at, message_count, has_unsubscribe)
+select account_id,
+       address,
+       case
+           when (array_agg(sender order by received_at desc))[1] like '%<%'
+           then trim(both ' "' from split_part((array_agg(sender order by received_at desc))[1], '<', 1))
+           else ''
+       end,
+       split_part(address, '@', 2),
+       min(received_at),
+       max(received_at),
+       count(*),
+       bool_or(unsubscribe_link is not null or list_unsubscribe is not null)
+from (
+    select account_id, sender, received_at, unsubscribe_link, list_unsubscribe,
+           lower(trim(coalesce(substring(sender from '<([^>]+)>'), sender))) as address
+    from emails
+    where sender is not null
+) e
+group by account_id, address;
+
+update emails e
+set sender_id = s.id
+from senders s
+where s.account_id = e.account_id
+  and s.address = lower(trim(coalesce(substring(e.sender from '<([^>]+)>'), e.sender)));
@@ -0,0 +1,12 @@
+-- Plain text version of the body; search indexes it instead of the HTML markup
+alter table emails add column body_text text;
+
+alter table emails drop column search_vector;
+alter table emails add column search_vector tsvector generated always as (
+    setweight(to_tsvector('english', coalesce(subject, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(sender, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(ai_summary, '')), 'C') ||
+    setweight(to_tsvector('english', coalesce(nullif(body_text, ''), body, '')), 'D')
+) stored;
+
+create index idx_emails_search on emails using gin(search_vector);
@@ -0,0 +1,37 @@
+-- Files attached to emails; the content stays in Gmail and is fetched on demand
+create table attachments (
+    id bigserial primary key,
+    email_id bigint not null references emails(id) on delete cascade,
+    filename text not null default '',
+    mime_type text not null default '',
+    size bigint not null default 0,
+    -- Gmail keeps large parts behind an attachment ID and small ones inline in the message part
+    gmail_attachment_id text not null default '',
+    gmail_part_id text not null default '',
+    content_id text not null default '',
+    inline boolean not null default false,
+    extracted_text text,
+    -- Text is extracted from documents in the background after sync. Failed downloads
+    -- are retried with a backoff and given up after a few attempts.
+    text_extracted boolean not null default false,
+    extraction_attempts integer not null default 0,
+    extraction_failed_at timestamp with time zone,
+    created_at timestamp with time zone not null default now()
+);
+
+create index idx_attachments_email_id on attachments(email_id);
+create index idx_attachments_pending_extraction on attachments(id) where not text_extracted;
+
+-- Text extracted from the email's documents, so search finds what they say
+alter table emails add column attachment_text text;
+
+alter table emails drop column search_vector;
+alter table emails add column search_vector tsvector generated always as (
+    setweight(to_tsvector('english', coalesce(subject, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(sender, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(ai_summary, '')), 'C') ||
+    setweight(to_tsvector('english', coalesce(nullif(body_text, ''), body, '')), 'D') ||
+    setweight(to_tsvector('english', coalesce(attachment_text, '')), 'D')
+) stored;
+
+create index idx_emails_search on emails using gin(search_vector);
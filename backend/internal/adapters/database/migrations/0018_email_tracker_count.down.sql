@@ -0,0 +1 @@
+alter table emails drop column tracker_count;
@@ -0,0 +1,2 @@
+-- Number of tracking pixels in the HTML body; null for emails not counted yet
+alter table emails add column tracker_count integer;
@@ -0,0 +1,87 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_notes.up.sql":        {Data: []byte("alter table emails add column notes text;")},
+		"0002_add_notes.down.sql":      {Data: []byte("alter table emails drop column notes;")},
+		"0001_initial_schema.up.sql":   {Data: []byte("create table accounts (id bigserial primary key);")},
+		"0001_initial_schema.down.sql": {Data: []byte("drop table accounts;")},
+	}
+
+	migrations, err := load(fsys)
+	if err != nil {
+		t.Fatalf("Expected migrations to load, got %v", err)
+	}
+
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "initial_schema" || migrations[1].Version != 2 {
+		t.Errorf("Expected migrations sorted by version, got %d_%s then %d_%s",
+			migrations[0].Version, migrations[0].Name, migrations[1].Version, migrations[1].Name)
+	}
+	if migrations[1].Down != "alter table emails drop column notes;" {
+		t.Errorf("Expected the down script to be read, got '%s'", migrations[1].Down)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"bad name": {
+			"initial.sql": {Data: []byte("select 1;")},
+		},
+		"missing up": {
+			"0001_initial_schema.down.sql": {Data: []byte("select 1;")},
+		},
+		"mismatched names": {
+			"0001_initial_schema.up.sql": {Data: []byte("select 1;")},
+			"0001_initial.down.sql":      {Data: []byte("select 1;")},
+		},
+		"zero version": {
+			"0000_initial_schema.up.sql": {Data: []byte("select 1;")},
+		},
+	}
+
+	for name, fsys := range tests {
+		if _, err := load(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatalf("Expected the embedded migrations to load, got %v", err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("Expected migration versions to be consecutive from 1, got %d at position %d", migration.Version, i)
+		}
+		if migration.Down == "" {
+			t.Errorf("Expected migration %d_%s to have a down file", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestEmbeddedMigrations_InitialSchemaIsBaseline(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatalf("Expected the embedded migrations to load, got %v", err)
+	}
+
+	// Databases created from schema.sql are recorded at the initial schema, so it must
+	// not gain anything a later migration adds
+	for _, later := range []string{"ai_daily_usage", "action_items", "threads", "unsubscribe_attempts", "search_vector"} {
+		if strings.Contains(migrations[0].Up, later) {
+			t.Errorf("Expected the initial schema to be the baseline schema.sql, found %s", later)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/email-sorting-app/internal/domain/entities"
+	apperrors "github.com/email-sorting-app/pkg/errors"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/oauth2"
@@ -20,7 +21,7 @@ func NewAccountRepository(db *pgxpool.Pool) *AccountRepository {
 
 func (r *AccountRepository) GetAll(ctx context.Context) ([]entities.Account, error) {
 	rows, err := r.db.Query(ctx, `
-		SELECT id, email, name, created_at, updated_at 
+		SELECT id, email, name, auto_summarize, created_at, updated_at 
 		FROM accounts 
 		ORDER BY created_at DESC
 	`)
@@ -32,7 +33,7 @@ func (r *AccountRepository) GetAll(ctx context.Context) ([]entities.Account, err
 	var accounts []entities.Account
 	for rows.Next() {
 		var account entities.Account
-		err := rows.Scan(&account.ID, &account.Email, &account.Name, &account.CreatedAt, &account.UpdatedAt)
+		err := rows.Scan(&account.ID, &account.Email, &account.Name, &account.AutoSummarize, &account.CreatedAt, &account.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan account: %w", err)
 		}
@@ -45,15 +46,15 @@ func (r *AccountRepository) GetAll(ctx context.Context) ([]entities.Account, err
 func (r *AccountRepository) GetByID(ctx context.Context, id int64) (*entities.Account, error) {
 	var account entities.Account
 	err := r.db.QueryRow(ctx, `
-		SELECT id, email, name, access_token, refresh_token, token_expiry, last_sync_history_id, created_at, updated_at 
+		SELECT id, email, name, access_token, refresh_token, token_expiry, last_sync_history_id, auto_summarize, created_at, updated_at 
 		FROM accounts WHERE id = $1
 	`, id).Scan(
 		&account.ID, &account.Email, &account.Name, &account.AccessToken,
-		&account.RefreshToken, &account.TokenExpiry, &account.LastSyncHistoryID, &account.CreatedAt, &account.UpdatedAt,
+		&account.RefreshToken, &account.TokenExpiry, &account.LastSyncHistoryID, &account.AutoSummarize, &account.CreatedAt, &account.UpdatedAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("account not found")
+			return nil, apperrors.NewNotFoundError("account not found")
 		}
 		return nil, fmt.Errorf("failed to get account: %w", err)
 	}
@@ -64,15 +65,15 @@ func (r *AccountRepository) GetByID(ctx context.Context, id int64) (*entities.Ac
 func (r *AccountRepository) GetByEmail(ctx context.Context, email string) (*entities.Account, error) {
 	var account entities.Account
 	err := r.db.QueryRow(ctx, `
-		SELECT id, email, name, access_token, refresh_token, token_expiry, last_sync_history_id, created_at, updated_at 
+		SELECT id, email, name, access_token, refresh_token, token_expiry, last_sync_history_id, auto_summarize, created_at, updated_at 
 		FROM accounts WHERE email = $1
 	`, email).Scan(
 		&account.ID, &account.Email, &account.Name, &account.AccessToken,
-		&account.RefreshToken, &account.TokenExpiry, &account.LastSyncHistoryID, &account.CreatedAt, &account.UpdatedAt,
+		&account.RefreshToken, &account.TokenExpiry, &account.LastSyncHistoryID, &account.AutoSummarize, &account.CreatedAt, &account.UpdatedAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("account not found")
+			return nil, apperrors.NewNotFoundError("account not found")
 		}
 		return nil, fmt.Errorf("failed to get account by email: %w", err)
 	}
@@ -85,9 +86,9 @@ func (r *AccountRepository) Create(ctx context.Context, email, name string, toke
 	err := r.db.QueryRow(ctx, `
 		INSERT INTO accounts (email, name, access_token, refresh_token, token_expiry, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
-		RETURNING id, email, name, created_at, updated_at
+		RETURNING id, email, name, auto_summarize, created_at, updated_at
 	`, email, name, token.AccessToken, token.RefreshToken, token.Expiry).Scan(
-		&account.ID, &account.Email, &account.Name, &account.CreatedAt, &account.UpdatedAt,
+		&account.ID, &account.Email, &account.Name, &account.AutoSummarize, &account.CreatedAt, &account.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create account: %w", err)
@@ -130,3 +131,16 @@ func (r *AccountRepository) UpdateLastSyncHistoryID(ctx context.Context, account
 
 	return nil
 }
+
+func (r *AccountRepository) UpdateAutoSummarize(ctx context.Context, accountID int64, enabled bool) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE accounts 
+		SET auto_summarize = $1, updated_at = NOW()
+		WHERE id = $2
+	`, enabled, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to update auto summarize setting: %w", err)
+	}
+
+	return nil
+}
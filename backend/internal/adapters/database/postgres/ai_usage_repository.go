@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AIUsageRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAIUsageRepository(db *pgxpool.Pool) *AIUsageRepository {
+	return &AIUsageRepository{db: db}
+}
+
+func (r *AIUsageRepository) GetDailyUsage(ctx context.Context, day time.Time, feature string) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `
+		SELECT count FROM ai_daily_usage 
+		WHERE usage_date = $1 AND feature = $2
+	`, day.Format("2006-01-02"), feature).Scan(&count)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get daily AI usage: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *AIUsageRepository) IncrementDailyUsage(ctx context.Context, day time.Time, feature string, amount int) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO ai_daily_usage (usage_date, feature, count, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (usage_date, feature) 
+		DO UPDATE SET count = ai_daily_usage.count + EXCLUDED.count, updated_at = NOW()
+	`, day.Format("2006-01-02"), feature, amount)
+	if err != nil {
+		return fmt.Errorf("failed to increment daily AI usage: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AIUsageRepository) ReserveDailyUsage(ctx context.Context, day time.Time, feature string, limit int) (bool, error) {
+	if limit <= 0 {
+		return false, nil
+	}
+
+	// The limit is checked in the same statement as the increment, so concurrent
+	// workers can't reserve past it
+	var count int
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO ai_daily_usage (usage_date, feature, count, updated_at)
+		VALUES ($1, $2, 1, NOW())
+		ON CONFLICT (usage_date, feature)
+		DO UPDATE SET count = ai_daily_usage.count + 1, updated_at = NOW()
+		WHERE ai_daily_usage.count < $3
+		RETURNING count
+	`, day.Format("2006-01-02"), feature, limit).Scan(&count)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to reserve daily AI usage: %w", err)
+	}
+
+	return true, nil
+}
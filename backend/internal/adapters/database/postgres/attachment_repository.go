@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AttachmentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAttachmentRepository(db *pgxpool.Pool) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+const attachmentColumns = `id, email_id, filename, mime_type, size, gmail_attachment_id, gmail_part_id,
+		       content_id, inline, extracted_text, COALESCE(extracted_text, '') <> '', text_extracted, created_at`
+
+func scanAttachment(row pgx.Row, attachment *entities.Attachment) error {
+	return row.Scan(
+		&attachment.ID, &attachment.EmailID, &attachment.Filename, &attachment.MimeType, &attachment.Size,
+		&attachment.GmailAttachmentID, &attachment.GmailPartID, &attachment.ContentID, &attachment.Inline,
+		&attachment.ExtractedText, &attachment.HasText, &attachment.TextExtracted, &attachment.CreatedAt,
+	)
+}
+
+func (r *AttachmentRepository) Create(ctx context.Context, attachments []entities.Attachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for i := range attachments {
+		attachment := &attachments[i]
+		err = tx.QueryRow(ctx, `
+			INSERT INTO attachments (email_id, filename, mime_type, size, gmail_attachment_id, gmail_part_id,
+			                         content_id, inline, extracted_text, text_extracted, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+			RETURNING id, created_at
+		`, attachment.EmailID, attachment.Filename, attachment.MimeType, attachment.Size,
+			attachment.GmailAttachmentID, attachment.GmailPartID, attachment.ContentID, attachment.Inline,
+			attachment.ExtractedText, attachment.TextExtracted,
+		).Scan(&attachment.ID, &attachment.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert attachment: %w", err)
+		}
+		attachment.HasText = attachment.ExtractedText != nil && *attachment.ExtractedText != ""
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AttachmentRepository) GetByID(ctx context.Context, id int64) (*entities.Attachment, error) {
+	var attachment entities.Attachment
+	err := scanAttachment(r.db.QueryRow(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM attachments
+		WHERE id = $1
+	`, id), &attachment)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, apperrors.NewNotFoundError("attachment not found")
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return &attachment, nil
+}
+
+func (r *AttachmentRepository) GetByEmailID(ctx context.Context, emailID int64) ([]entities.Attachment, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM attachments
+		WHERE email_id = $1
+		ORDER BY id
+	`, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []entities.Attachment{}
+	for rows.Next() {
+		var attachment entities.Attachment
+		if err := scanAttachment(rows, &attachment); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+// GetPendingExtractions returns attachments still to be read, oldest first. After a
+// failure an attachment waits 2^attempts hours before it is tried again.
+func (r *AttachmentRepository) GetPendingExtractions(ctx context.Context, limit, maxAttempts int) ([]entities.Attachment, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM attachments
+		WHERE NOT text_extracted
+		  AND extraction_attempts < $2
+		  AND (extraction_failed_at IS NULL
+		       OR extraction_failed_at < NOW() - make_interval(hours => power(2, extraction_attempts)::int))
+		ORDER BY id
+		LIMIT $1
+	`, limit, maxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments pending extraction: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []entities.Attachment
+	for rows.Next() {
+		var attachment entities.Attachment
+		if err := scanAttachment(rows, &attachment); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+func (r *AttachmentRepository) UpdateExtractedText(ctx context.Context, id int64, text *string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE attachments
+		SET extracted_text = $2, text_extracted = true
+		WHERE id = $1
+	`, id, text)
+	if err != nil {
+		return fmt.Errorf("failed to update extracted text: %w", err)
+	}
+
+	return nil
+}
+
+// RecordExtractionFailure counts a failed attempt to download an attachment for its text
+func (r *AttachmentRepository) RecordExtractionFailure(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE attachments
+		SET extraction_attempts = extraction_attempts + 1, extraction_failed_at = NOW()
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to record extraction failure: %w", err)
+	}
+
+	return nil
+}
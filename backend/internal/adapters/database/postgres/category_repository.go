@@ -19,7 +19,7 @@ func NewCategoryRepository(db *pgxpool.Pool) *CategoryRepository {
 
 func (r *CategoryRepository) GetByAccountID(ctx context.Context, accountID int64) ([]entities.Category, error) {
 	rows, err := r.db.Query(ctx, `
-		SELECT id, account_id, name, description, created_at, updated_at 
+		SELECT id, account_id, name, description, auto_summarize, created_at, updated_at 
 		FROM categories 
 		WHERE account_id = $1 
 		ORDER BY name
@@ -32,7 +32,7 @@ func (r *CategoryRepository) GetByAccountID(ctx context.Context, accountID int64
 	var categories []entities.Category
 	for rows.Next() {
 		var category entities.Category
-		err := rows.Scan(&category.ID, &category.AccountID, &category.Name, &category.Description, &category.CreatedAt, &category.UpdatedAt)
+		err := rows.Scan(&category.ID, &category.AccountID, &category.Name, &category.Description, &category.AutoSummarize, &category.CreatedAt, &category.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan category: %w", err)
 		}
@@ -45,10 +45,10 @@ func (r *CategoryRepository) GetByAccountID(ctx context.Context, accountID int64
 func (r *CategoryRepository) GetByName(ctx context.Context, accountID int64, name string) (*entities.Category, error) {
 	var category entities.Category
 	err := r.db.QueryRow(ctx, `
-		SELECT id, account_id, name, description, created_at, updated_at 
+		SELECT id, account_id, name, description, auto_summarize, created_at, updated_at 
 		FROM categories WHERE account_id = $1 AND name = $2
 	`, accountID, name).Scan(
-		&category.ID, &category.AccountID, &category.Name, &category.Description, &category.CreatedAt, &category.UpdatedAt,
+		&category.ID, &category.AccountID, &category.Name, &category.Description, &category.AutoSummarize, &category.CreatedAt, &category.UpdatedAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -64,9 +64,9 @@ func (r *CategoryRepository) Create(ctx context.Context, category *entities.Cate
 	err := r.db.QueryRow(ctx, `
 		INSERT INTO categories (account_id, name, description, created_at, updated_at)
 		VALUES ($1, $2, $3, NOW(), NOW())
-		RETURNING id, created_at, updated_at
+		RETURNING id, auto_summarize, created_at, updated_at
 	`, category.AccountID, category.Name, category.Description).Scan(
-		&category.ID, &category.CreatedAt, &category.UpdatedAt,
+		&category.ID, &category.AutoSummarize, &category.CreatedAt, &category.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create category: %w", err)
@@ -103,3 +103,16 @@ func (r *CategoryRepository) Delete(ctx context.Context, categoryID int64) error
 
 	return nil
 }
+
+func (r *CategoryRepository) UpdateAutoSummarize(ctx context.Context, categoryID int64, enabled bool) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE categories 
+		SET auto_summarize = $1, updated_at = NOW()
+		WHERE id = $2
+	`, enabled, categoryID)
+	if err != nil {
+		return fmt.Errorf("failed to update auto summarize setting: %w", err)
+	}
+
+	return nil
+}
@@ -3,11 +3,13 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"html"
 	"math"
 	"strings"
 
 	"github.com/email-sorting-app/internal/domain/entities"
 	"github.com/email-sorting-app/internal/domain/repositories"
+	apperrors "github.com/email-sorting-app/pkg/errors"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -16,17 +18,41 @@ type EmailRepository struct {
 	db *pgxpool.Pool
 }
 
+// emailColumns lists the columns read by scanEmail; queries alias the emails table as e
+const emailColumns = `e.id, e.account_id, e.gmail_message_id, COALESCE(e.gmail_thread_id, ''), e.thread_id,
+		       e.sender_id, e.sender, e.subject, e.body, COALESCE(e.body_text, ''), COALESCE(e.attachment_text, ''), e.ai_summary, e.received_at, e.is_archived_in_gmail,
+		       e.unsubscribe_link, COALESCE(e.list_unsubscribe, ''), COALESCE(e.list_unsubscribe_post, ''), e.risk_score, COALESCE(e.risk_reasons, '{}'),
+		       e.mailed_after_unsubscribe, e.thread_summarized, e.to_addresses, e.cc_addresses, COALESCE(e.reply_to, ''),
+		       COALESCE(e.message_id, ''), COALESCE(e.in_reply_to, ''), e.message_references, COALESCE(e.list_id, ''),
+		       COALESCE(e.snippet, ''), e.size_estimate, e.is_read, e.is_starred, e.tracker_count, e.created_at, e.updated_at`
+
+func scanEmail(row pgx.Row, email *entities.Email) error {
+	return row.Scan(emailFields(email)...)
+}
+
+// emailFields returns scan destinations for emailColumns, for queries selecting more columns
+func emailFields(email *entities.Email) []interface{} {
+	return []interface{}{
+		&email.ID, &email.AccountID, &email.GmailMessageID, &email.GmailThreadID, &email.ThreadID,
+		&email.SenderID, &email.Sender, &email.Subject, &email.Body, &email.BodyText, &email.AttachmentText, &email.AISummary,
+		&email.ReceivedAt, &email.IsArchivedInGmail, &email.UnsubscribeLink,
+		&email.ListUnsubscribe, &email.ListUnsubscribePost, &email.RiskScore, &email.RiskReasons,
+		&email.MailedAfterUnsubscribe, &email.ThreadSummarized, &email.To, &email.Cc, &email.ReplyTo,
+		&email.MessageID, &email.InReplyTo, &email.References, &email.ListID,
+		&email.Snippet, &email.SizeEstimate, &email.IsRead, &email.IsStarred, &email.TrackerCount, &email.CreatedAt, &email.UpdatedAt,
+	}
+}
+
 func NewEmailRepository(db *pgxpool.Pool) *EmailRepository {
 	return &EmailRepository{db: db}
 }
 
 func (r *EmailRepository) GetByAccountID(ctx context.Context, accountID int64) ([]entities.Email, error) {
 	rows, err := r.db.Query(ctx, `
-		SELECT id, account_id, gmail_message_id, sender, subject, body, 
-		       ai_summary, received_at, is_archived_in_gmail, unsubscribe_link, created_at, updated_at
-		FROM emails 
-		WHERE account_id = $1 
-		ORDER BY received_at DESC
+		SELECT `+emailColumns+`
+		FROM emails e
+		WHERE e.account_id = $1 
+		ORDER BY e.received_at DESC
 	`, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query emails: %w", err)
@@ -36,54 +62,37 @@ func (r *EmailRepository) GetByAccountID(ctx context.Context, accountID int64) (
 	var emails []entities.Email
 	for rows.Next() {
 		var email entities.Email
-		err := rows.Scan(
-			&email.ID, &email.AccountID, &email.GmailMessageID,
-			&email.Sender, &email.Subject, &email.Body, &email.AISummary,
-			&email.ReceivedAt, &email.IsArchivedInGmail, &email.UnsubscribeLink,
-			&email.CreatedAt, &email.UpdatedAt,
-		)
+		err := scanEmail(rows, &email)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan email: %w", err)
 		}
 
-		// Load categories for this email
-		categoryIDs, err := r.GetEmailCategories(ctx, email.ID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get email categories: %w", err)
-		}
-		email.CategoryIDs = categoryIDs
-
 		emails = append(emails, email)
 	}
 
+	if err := r.loadCategories(ctx, emailPointers(emails)...); err != nil {
+		return nil, err
+	}
+
 	return emails, nil
 }
 
 func (r *EmailRepository) GetByID(ctx context.Context, id int64) (*entities.Email, error) {
 	var email entities.Email
-	err := r.db.QueryRow(ctx, `
-		SELECT id, account_id, gmail_message_id, sender, subject, body, 
-		       ai_summary, received_at, is_archived_in_gmail, unsubscribe_link, created_at, updated_at
-		FROM emails WHERE id = $1
-	`, id).Scan(
-		&email.ID, &email.AccountID, &email.GmailMessageID,
-		&email.Sender, &email.Subject, &email.Body, &email.AISummary,
-		&email.ReceivedAt, &email.IsArchivedInGmail, &email.UnsubscribeLink,
-		&email.CreatedAt, &email.UpdatedAt,
-	)
+	err := scanEmail(r.db.QueryRow(ctx, `
+		SELECT `+emailColumns+`
+		FROM emails e WHERE e.id = $1
+	`, id), &email)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("email not found")
+			return nil, apperrors.NewNotFoundError("email not found")
 		}
 		return nil, fmt.Errorf("failed to get email: %w", err)
 	}
 
-	// Load categories for this email
-	categoryIDs, err := r.GetEmailCategories(ctx, email.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get email categories: %w", err)
+	if err := r.loadCategories(ctx, &email); err != nil {
+		return nil, err
 	}
-	email.CategoryIDs = categoryIDs
 
 	return &email, nil
 }
@@ -96,10 +105,10 @@ func (r *EmailRepository) Create(ctx context.Context, email *entities.Email) (*e
 	defer tx.Rollback(ctx)
 
 	err = tx.QueryRow(ctx, `
-		INSERT INTO emails (account_id, gmail_message_id, sender, subject, body, received_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		INSERT INTO emails (account_id, gmail_message_id, gmail_thread_id, sender, subject, body, body_text, received_at, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, NULLIF($7, ''), $8, NOW(), NOW())
 		RETURNING id, created_at, updated_at
-	`, email.AccountID, email.GmailMessageID, email.Sender, email.Subject, email.Body, email.ReceivedAt).Scan(
+	`, email.AccountID, email.GmailMessageID, email.GmailThreadID, email.Sender, email.Subject, email.Body, email.BodyText, email.ReceivedAt).Scan(
 		&email.ID, &email.CreatedAt, &email.UpdatedAt,
 	)
 	if err != nil {
@@ -136,10 +145,10 @@ func (r *EmailRepository) Update(ctx context.Context, email *entities.Email) err
 
 	_, err = tx.Exec(ctx, `
 		UPDATE emails 
-		SET sender = $1, subject = $2, body = $3, ai_summary = $4, 
-		    is_archived_in_gmail = $5, unsubscribe_link = $6, updated_at = NOW()
-		WHERE id = $7
-	`, email.Sender, email.Subject, email.Body, email.AISummary,
+		SET sender = $1, subject = $2, body = $3, body_text = NULLIF($4, ''), ai_summary = $5,
+		    is_archived_in_gmail = $6, unsubscribe_link = $7, updated_at = NOW()
+		WHERE id = $8
+	`, email.Sender, email.Subject, email.Body, email.BodyText, email.AISummary,
 		email.IsArchivedInGmail, email.UnsubscribeLink, email.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update email: %w", err)
@@ -190,46 +199,169 @@ func (r *EmailRepository) ExistsByGmailMessageID(ctx context.Context, accountID
 	return exists, nil
 }
 
-func (r *EmailRepository) BulkCreate(ctx context.Context, emails []entities.Email) error {
+// emailImportColumns are the columns BulkCreate copies into its staging table
+var emailImportColumns = []string{
+	"position", "account_id", "gmail_message_id", "gmail_thread_id", "sender", "subject", "body", "body_text",
+	"ai_summary", "unsubscribe_link", "list_unsubscribe", "list_unsubscribe_post", "to_addresses", "cc_addresses", "reply_to",
+	"message_id", "in_reply_to", "message_references", "list_id", "snippet", "size_estimate", "is_read", "is_starred",
+	"tracker_count", "received_at",
+}
+
+// BulkCreate copies the emails into a staging table and inserts them from there in one
+// statement, skipping emails that are already stored so re-imports are harmless. New
+// emails get their IDs set and are added to their categories; the new IDs are returned.
+func (r *EmailRepository) BulkCreate(ctx context.Context, emails []entities.Email) ([]int64, error) {
 	if len(emails) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	for i, email := range emails {
-		var emailID int64
-		err := tx.QueryRow(ctx, `
-			INSERT INTO emails (account_id, gmail_message_id, sender, subject, body, ai_summary, unsubscribe_link, received_at, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
-			RETURNING id
-		`, email.AccountID, email.GmailMessageID, email.Sender, email.Subject, email.Body, email.AISummary, email.UnsubscribeLink, email.ReceivedAt).Scan(&emailID)
-		if err != nil {
-			return fmt.Errorf("failed to insert email at index %d: %w", i, err)
+	_, err = tx.Exec(ctx, `
+		CREATE TEMP TABLE email_import (
+			position integer not null,
+			account_id bigint not null,
+			gmail_message_id varchar(256) not null,
+			gmail_thread_id varchar(256),
+			sender text,
+			subject text,
+			body text,
+			body_text text,
+			ai_summary text,
+			unsubscribe_link text,
+			list_unsubscribe text,
+			list_unsubscribe_post text,
+			to_addresses text[],
+			cc_addresses text[],
+			reply_to text,
+			message_id text,
+			in_reply_to text,
+			message_references text[],
+			list_id text,
+			snippet text,
+			size_estimate bigint,
+			is_read boolean,
+			is_starred boolean,
+			tracker_count integer,
+			received_at timestamp with time zone
+		) ON COMMIT DROP
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create email staging table: %w", err)
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"email_import"}, emailImportColumns,
+		pgx.CopyFromSlice(len(emails), func(i int) ([]interface{}, error) {
+			email := emails[i]
+			return []interface{}{
+				i, email.AccountID, email.GmailMessageID, nullIfEmpty(email.GmailThreadID), email.Sender, email.Subject, email.Body,
+				nullIfEmpty(email.BodyText), email.AISummary, email.UnsubscribeLink, nullIfEmpty(email.ListUnsubscribe), nullIfEmpty(email.ListUnsubscribePost),
+				emptyIfNil(email.To), emptyIfNil(email.Cc), nullIfEmpty(email.ReplyTo),
+				nullIfEmpty(email.MessageID), nullIfEmpty(email.InReplyTo), emptyIfNil(email.References), nullIfEmpty(email.ListID),
+				nullIfEmpty(email.Snippet), email.SizeEstimate, email.IsRead, email.IsStarred,
+				email.TrackerCount, email.ReceivedAt,
+			}, nil
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy emails: %w", err)
+	}
+
+	// Only the account's own copy of a message is a duplicate; other accounts that
+	// received the same message store theirs
+	rows, err := tx.Query(ctx, `
+		INSERT INTO emails (account_id, gmail_message_id, gmail_thread_id, sender, subject, body, body_text, ai_summary, unsubscribe_link,
+		                    list_unsubscribe, list_unsubscribe_post, to_addresses, cc_addresses, reply_to,
+		                    message_id, in_reply_to, message_references, list_id, snippet, size_estimate, is_read, is_starred,
+		                    tracker_count, received_at, created_at, updated_at)
+		SELECT account_id, gmail_message_id, gmail_thread_id, sender, subject, body, body_text, ai_summary, unsubscribe_link,
+		       list_unsubscribe, list_unsubscribe_post, to_addresses, cc_addresses, reply_to,
+		       message_id, in_reply_to, message_references, list_id, snippet, size_estimate, is_read, is_starred,
+		       tracker_count, received_at, NOW(), NOW()
+		FROM email_import
+		ORDER BY position
+		ON CONFLICT (account_id, gmail_message_id) DO NOTHING
+		RETURNING id, account_id, gmail_message_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert emails: %w", err)
+	}
+
+	type importKey struct {
+		accountID      int64
+		gmailMessageID string
+	}
+	insertedIDs := make(map[importKey]int64)
+	for rows.Next() {
+		var id int64
+		var key importKey
+		if err := rows.Scan(&id, &key.accountID, &key.gmailMessageID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan inserted email: %w", err)
 		}
+		insertedIDs[key] = id
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to insert emails: %w", err)
+	}
 
-		// Add email to categories
-		for _, categoryID := range email.CategoryIDs {
-			_, err = tx.Exec(ctx, `
-				INSERT INTO email_categories (email_id, category_id, created_at)
-				VALUES ($1, $2, NOW())
-			`, emailID, categoryID)
-			if err != nil {
-				return fmt.Errorf("failed to add email to category: %w", err)
-			}
+	// Hand out IDs in input order; a message listed twice is only new the first time
+	var newIDs []int64
+	var categoryEmailIDs, categoryIDs []int64
+	for i := range emails {
+		key := importKey{accountID: emails[i].AccountID, gmailMessageID: emails[i].GmailMessageID}
+		id, inserted := insertedIDs[key]
+		if !inserted {
+			continue
+		}
+		delete(insertedIDs, key)
+
+		emails[i].ID = id
+		newIDs = append(newIDs, id)
+		for _, categoryID := range emails[i].CategoryIDs {
+			categoryEmailIDs = append(categoryEmailIDs, id)
+			categoryIDs = append(categoryIDs, categoryID)
+		}
+	}
+
+	// Add the new emails to their categories in one statement
+	if len(categoryIDs) > 0 {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO email_categories (email_id, category_id, created_at)
+			SELECT email_id, category_id, NOW()
+			FROM unnest($1::bigint[], $2::bigint[]) AS c(email_id, category_id)
+			ON CONFLICT (email_id, category_id) DO NOTHING
+		`, categoryEmailIDs, categoryIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add emails to categories: %w", err)
 		}
 	}
 
 	err = tx.Commit(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return newIDs, nil
+}
+
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// emptyIfNil keeps nil slices out of not null array columns
+func emptyIfNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
 }
 
 func (r *EmailRepository) GetByAccountIDPaginated(ctx context.Context, accountID int64, params repositories.PaginationParams) (*repositories.PaginatedEmails, error) {
@@ -247,11 +379,10 @@ func (r *EmailRepository) GetByAccountIDPaginated(ctx context.Context, accountID
 
 	// Get paginated emails
 	rows, err := r.db.Query(ctx, `
-		SELECT id, account_id, gmail_message_id, sender, subject, body, 
-		       ai_summary, received_at, is_archived_in_gmail, unsubscribe_link, created_at, updated_at
-		FROM emails 
-		WHERE account_id = $1 
-		ORDER BY received_at DESC
+		SELECT `+emailColumns+`
+		FROM emails e
+		WHERE e.account_id = $1 
+		ORDER BY e.received_at DESC, e.id DESC
 		LIMIT $2 OFFSET $3
 	`, accountID, params.PageSize, offset)
 	if err != nil {
@@ -262,26 +393,18 @@ func (r *EmailRepository) GetByAccountIDPaginated(ctx context.Context, accountID
 	var emails []entities.Email
 	for rows.Next() {
 		var email entities.Email
-		err := rows.Scan(
-			&email.ID, &email.AccountID, &email.GmailMessageID,
-			&email.Sender, &email.Subject, &email.Body, &email.AISummary,
-			&email.ReceivedAt, &email.IsArchivedInGmail, &email.UnsubscribeLink,
-			&email.CreatedAt, &email.UpdatedAt,
-		)
+		err := scanEmail(rows, &email)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan email: %w", err)
 		}
 
-		// Load categories for this email
-		categoryIDs, err := r.GetEmailCategories(ctx, email.ID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get email categories: %w", err)
-		}
-		email.CategoryIDs = categoryIDs
-
 		emails = append(emails, email)
 	}
 
+	if err := r.loadCategories(ctx, emailPointers(emails)...); err != nil {
+		return nil, err
+	}
+
 	// Calculate total pages
 	totalPages := int(math.Ceil(float64(totalCount) / float64(params.PageSize)))
 
@@ -294,12 +417,219 @@ func (r *EmailRepository) GetByAccountIDPaginated(ctx context.Context, accountID
 	}, nil
 }
 
+// ts_headline marks matches with control characters rather than tags, so the body
+// can be HTML-escaped before the marks are turned into <mark> elements
+const (
+	headlineStart   = "\x02"
+	headlineStop    = "\x03"
+	headlineOptions = "StartSel=" + headlineStart + ", StopSel=" + headlineStop + ", MaxWords=35, MinWords=15, MaxFragments=2"
+)
+
+// Without search text there is nothing to highlight, so the snippet is the start of the body
+const plainSnippetLength = 200
+
+// Snippets come from the plain text body, so they don't show markup
+const snippetSource = "COALESCE(NULLIF(e.body_text, ''), e.body, '')"
+
+func (r *EmailRepository) Search(ctx context.Context, accountID int64, query *repositories.EmailSearchQuery, params repositories.PaginationParams) (*repositories.EmailSearchResults, error) {
+	args := []interface{}{accountID}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"e.account_id = $1"}
+	tsQuery := ""
+	if query.Text != "" {
+		tsQuery = "websearch_to_tsquery('english', " + arg(query.Text) + ")"
+		conditions = append(conditions, "e.search_vector @@ "+tsQuery)
+	}
+	for _, from := range query.From {
+		conditions = append(conditions, "e.sender ILIKE "+arg(containsPattern(from)))
+	}
+	for _, subject := range query.Subject {
+		conditions = append(conditions, "e.subject ILIKE "+arg(containsPattern(subject)))
+	}
+	for _, category := range query.Categories {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM email_categories ec
+			INNER JOIN categories c ON c.id = ec.category_id
+			WHERE ec.email_id = e.id AND LOWER(c.name) = LOWER(`+arg(category)+`)
+		)`)
+	}
+	if query.Before != nil {
+		conditions = append(conditions, "e.received_at < "+arg(*query.Before))
+	}
+	if query.After != nil {
+		conditions = append(conditions, "e.received_at >= "+arg(*query.After))
+	}
+	if query.HasUnsubscribe {
+		conditions = append(conditions, "(e.unsubscribe_link IS NOT NULL OR COALESCE(e.list_unsubscribe, '') <> '')")
+	}
+	where := strings.Join(conditions, " AND ")
+
+	// Get total count
+	var totalCount int64
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM emails e WHERE `+where, args...).Scan(&totalCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get search result count: %w", err)
+	}
+
+	// Rank and highlight text matches; filter-only searches list newest first
+	rank := "0::real"
+	snippet := "LEFT(" + snippetSource + ", " + arg(plainSnippetLength) + ")"
+	orderBy := "e.received_at DESC, e.id DESC"
+	if tsQuery != "" {
+		rank = "ts_rank_cd(e.search_vector, " + tsQuery + ")"
+		snippet = "ts_headline('english', " + snippetSource + ", " + tsQuery + ", " + arg(headlineOptions) + ")"
+		orderBy = "rank DESC, " + orderBy
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	rows, err := r.db.Query(ctx, `
+		SELECT `+emailColumns+`, `+rank+` AS rank, `+snippet+`
+		FROM emails e
+		WHERE `+where+`
+		ORDER BY `+orderBy+`
+		LIMIT `+arg(params.PageSize)+` OFFSET `+arg(offset), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search emails: %w", err)
+	}
+	defer rows.Close()
+
+	results := []repositories.EmailSearchResult{}
+	for rows.Next() {
+		var result repositories.EmailSearchResult
+		var rank float32
+		email := &result.Email
+		err := rows.Scan(append(emailFields(email), &rank, &result.Snippet)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Rank = float64(rank)
+		result.Snippet = highlightSnippet(result.Snippet)
+
+		results = append(results, result)
+	}
+
+	resultEmails := make([]*entities.Email, len(results))
+	for i := range results {
+		resultEmails[i] = &results[i].Email
+	}
+	if err := r.loadCategories(ctx, resultEmails...); err != nil {
+		return nil, err
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(params.PageSize)))
+
+	return &repositories.EmailSearchResults{
+		Results:    results,
+		TotalCount: totalCount,
+		Page:       params.Page,
+		PageSize:   params.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// containsPattern builds an ILIKE pattern matching value anywhere, with its own
+// wildcard characters taken literally
+func containsPattern(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+	return "%" + escaped + "%"
+}
+
+// highlightSnippet escapes a ts_headline result and turns its match marks into <mark> elements
+func highlightSnippet(headline string) string {
+	escaped := html.EscapeString(headline)
+	return strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>").Replace(escaped)
+}
+
+// GetByAccountIDCursor pages through an account's emails by keyset rather than offset,
+// so deep pages cost the same as the first and concurrent inserts don't shift pages
+func (r *EmailRepository) GetByAccountIDCursor(ctx context.Context, accountID int64, params repositories.CursorParams) (*repositories.EmailCursorPage, error) {
+	return r.queryCursorPage(ctx, `
+		SELECT `+emailColumns+`
+		FROM emails e
+		WHERE e.account_id = $1`, []interface{}{accountID}, params)
+}
+
+func (r *EmailRepository) GetByCategoryIDCursor(ctx context.Context, accountID, categoryID int64, params repositories.CursorParams) (*repositories.EmailCursorPage, error) {
+	return r.queryCursorPage(ctx, `
+		SELECT `+emailColumns+`
+		FROM emails e
+		INNER JOIN email_categories ec ON e.id = ec.email_id
+		WHERE e.account_id = $1 AND ec.category_id = $2`, []interface{}{accountID, categoryID}, params)
+}
+
+// queryCursorPage runs base, a query selecting emailColumns ending in its WHERE clause,
+// for the page of emails after the cursor
+func (r *EmailRepository) queryCursorPage(ctx context.Context, base string, args []interface{}, params repositories.CursorParams) (*repositories.EmailCursorPage, error) {
+	query := base
+	if params.After != nil {
+		args = append(args, params.After.ReceivedAt, params.After.ID)
+		query += fmt.Sprintf(" AND (e.received_at, e.id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	// Fetch one extra email to learn whether there is another page
+	args = append(args, params.Limit+1)
+	query += fmt.Sprintf(" ORDER BY e.received_at DESC, e.id DESC LIMIT $%d", len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query email page: %w", err)
+	}
+	defer rows.Close()
+
+	emails := []entities.Email{}
+	for rows.Next() {
+		var email entities.Email
+		err := scanEmail(rows, &email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+
+		emails = append(emails, email)
+	}
+
+	page := &repositories.EmailCursorPage{Emails: emails}
+	if len(emails) > params.Limit {
+		page.Emails = emails[:params.Limit]
+		last := page.Emails[len(page.Emails)-1]
+		page.Next = &repositories.EmailCursor{ReceivedAt: last.ReceivedAt, ID: last.ID}
+	}
+
+	if err := r.loadCategories(ctx, emailPointers(page.Emails)...); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// DeleteByAccountID deletes the account's emails and zeroes its senders' counts, which
+// are only recounted for the senders of emails stored again
 func (r *EmailRepository) DeleteByAccountID(ctx context.Context, accountID int64) error {
-	_, err := r.db.Exec(ctx, "DELETE FROM emails WHERE account_id = $1", accountID)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "DELETE FROM emails WHERE account_id = $1", accountID)
 	if err != nil {
 		return fmt.Errorf("failed to delete emails by account ID: %w", err)
 	}
 
+	_, err = tx.Exec(ctx, "UPDATE senders SET message_count = 0, updated_at = NOW() WHERE account_id = $1", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to reset sender statistics: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
@@ -368,12 +698,11 @@ func (r *EmailRepository) GetByCategoryIDPaginated(ctx context.Context, accountI
 
 	// Get paginated emails
 	rows, err := r.db.Query(ctx, `
-		SELECT e.id, e.account_id, e.gmail_message_id, e.sender, e.subject, e.body, 
-		       e.ai_summary, e.received_at, e.is_archived_in_gmail, e.unsubscribe_link, e.created_at, e.updated_at
+		SELECT `+emailColumns+`
 		FROM emails e
 		INNER JOIN email_categories ec ON e.id = ec.email_id
 		WHERE e.account_id = $1 AND ec.category_id = $2
-		ORDER BY e.received_at DESC
+		ORDER BY e.received_at DESC, e.id DESC
 		LIMIT $3 OFFSET $4
 	`, accountID, categoryID, params.PageSize, offset)
 	if err != nil {
@@ -384,26 +713,101 @@ func (r *EmailRepository) GetByCategoryIDPaginated(ctx context.Context, accountI
 	var emails []entities.Email
 	for rows.Next() {
 		var email entities.Email
-		err := rows.Scan(
-			&email.ID, &email.AccountID, &email.GmailMessageID,
-			&email.Sender, &email.Subject, &email.Body, &email.AISummary,
-			&email.ReceivedAt, &email.IsArchivedInGmail, &email.UnsubscribeLink,
-			&email.CreatedAt, &email.UpdatedAt,
-		)
+		err := scanEmail(rows, &email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+
+		emails = append(emails, email)
+	}
+
+	if err := r.loadCategories(ctx, emailPointers(emails)...); err != nil {
+		return nil, err
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(params.PageSize)))
+
+	return &repositories.PaginatedEmails{
+		Emails:     emails,
+		TotalCount: totalCount,
+		Page:       params.Page,
+		PageSize:   params.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (r *EmailRepository) GetBySenderID(ctx context.Context, senderID int64) ([]entities.Email, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+emailColumns+`
+		FROM emails e
+		WHERE e.sender_id = $1
+		ORDER BY e.received_at DESC, e.id DESC
+	`, senderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query emails by sender: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []entities.Email
+	for rows.Next() {
+		var email entities.Email
+		err := scanEmail(rows, &email)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan email: %w", err)
 		}
 
-		// Load categories for this email
-		categoryIDs, err := r.GetEmailCategories(ctx, email.ID)
+		emails = append(emails, email)
+	}
+
+	if err := r.loadCategories(ctx, emailPointers(emails)...); err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+func (r *EmailRepository) GetBySenderIDPaginated(ctx context.Context, senderID int64, params repositories.PaginationParams) (*repositories.PaginatedEmails, error) {
+	// Get total count
+	var totalCount int64
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM emails WHERE sender_id = $1
+	`, senderID).Scan(&totalCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	// Calculate offset
+	offset := (params.Page - 1) * params.PageSize
+
+	// Get paginated emails
+	rows, err := r.db.Query(ctx, `
+		SELECT `+emailColumns+`
+		FROM emails e
+		WHERE e.sender_id = $1
+		ORDER BY e.received_at DESC, e.id DESC
+		LIMIT $2 OFFSET $3
+	`, senderID, params.PageSize, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query paginated emails by sender: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []entities.Email
+	for rows.Next() {
+		var email entities.Email
+		err := scanEmail(rows, &email)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get email categories: %w", err)
+			return nil, fmt.Errorf("failed to scan email: %w", err)
 		}
-		email.CategoryIDs = categoryIDs
 
 		emails = append(emails, email)
 	}
 
+	if err := r.loadCategories(ctx, emailPointers(emails)...); err != nil {
+		return nil, err
+	}
+
 	// Calculate total pages
 	totalPages := int(math.Ceil(float64(totalCount) / float64(params.PageSize)))
 
@@ -416,6 +820,62 @@ func (r *EmailRepository) GetByCategoryIDPaginated(ctx context.Context, accountI
 	}, nil
 }
 
+// loadCategories fills in the category IDs and categories of emails with a single
+// query, however many emails there are
+func (r *EmailRepository) loadCategories(ctx context.Context, emails ...*entities.Email) error {
+	if len(emails) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*entities.Email, len(emails))
+	emailIDs := make([]int64, len(emails))
+	for i, email := range emails {
+		byID[email.ID] = email
+		emailIDs[i] = email.ID
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT ec.email_id, c.id, c.account_id, c.name, c.description, c.auto_summarize, c.created_at, c.updated_at
+		FROM email_categories ec
+		INNER JOIN categories c ON c.id = ec.category_id
+		WHERE ec.email_id = ANY($1)
+		ORDER BY ec.id
+	`, emailIDs)
+	if err != nil {
+		return fmt.Errorf("failed to query email categories: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var emailID int64
+		var category entities.Category
+		err := rows.Scan(
+			&emailID, &category.ID, &category.AccountID, &category.Name, &category.Description,
+			&category.AutoSummarize, &category.CreatedAt, &category.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan email category: %w", err)
+		}
+
+		email := byID[emailID]
+		email.CategoryIDs = append(email.CategoryIDs, category.ID)
+		email.Categories = append(email.Categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read email categories: %w", err)
+	}
+
+	return nil
+}
+
+func emailPointers(emails []entities.Email) []*entities.Email {
+	pointers := make([]*entities.Email, len(emails))
+	for i := range emails {
+		pointers[i] = &emails[i]
+	}
+	return pointers
+}
+
 func (r *EmailRepository) GetEmailCategories(ctx context.Context, emailID int64) ([]int64, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT category_id FROM email_categories WHERE email_id = $1
@@ -464,6 +924,27 @@ func (r *EmailRepository) AddEmailToCategories(ctx context.Context, emailID int6
 	return nil
 }
 
+// AddSenderEmailsToCategory adds every email of the sender to the category in one
+// statement, returning how many emails the sender has and how many were added
+func (r *EmailRepository) AddSenderEmailsToCategory(ctx context.Context, senderID, categoryID int64) (total, added int, err error) {
+	err = r.db.QueryRow(ctx, `
+		WITH sender_emails AS (
+			SELECT id FROM emails WHERE sender_id = $1
+		), inserted AS (
+			INSERT INTO email_categories (email_id, category_id, created_at)
+			SELECT id, $2, NOW() FROM sender_emails
+			ON CONFLICT (email_id, category_id) DO NOTHING
+			RETURNING email_id
+		)
+		SELECT (SELECT COUNT(*) FROM sender_emails), (SELECT COUNT(*) FROM inserted)
+	`, senderID, categoryID).Scan(&total, &added)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to add sender emails to category: %w", err)
+	}
+
+	return total, added, nil
+}
+
 func (r *EmailRepository) RemoveEmailFromCategories(ctx context.Context, emailID int64, categoryIDs []int64) error {
 	if len(categoryIDs) == 0 {
 		return nil
@@ -503,3 +984,210 @@ func (r *EmailRepository) UpdateAISummary(ctx context.Context, emailID int64, su
 
 	return nil
 }
+
+// UpdateAttachmentText stores the text extracted from an email's attachments, which search indexes
+func (r *EmailRepository) UpdateAttachmentText(ctx context.Context, emailID int64, text string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE emails
+		SET attachment_text = NULLIF($1, ''), updated_at = NOW()
+		WHERE id = $2
+	`, text, emailID)
+	if err != nil {
+		return fmt.Errorf("failed to update attachment text: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTrackerCount stores the number of tracking pixels found in an email's HTML body
+func (r *EmailRepository) UpdateTrackerCount(ctx context.Context, emailID int64, count int) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE emails
+		SET tracker_count = $1, updated_at = NOW()
+		WHERE id = $2
+	`, count, emailID)
+	if err != nil {
+		return fmt.Errorf("failed to update tracker count: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingSummaries returns emails without an AI summary whose account and
+// categories have auto summarization enabled, newest first. After a failure an email
+// waits 2^attempts hours before it is tried again.
+func (r *EmailRepository) GetPendingSummaries(ctx context.Context, limit, maxAttempts int) ([]entities.Email, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+emailColumns+`
+		FROM emails e
+		INNER JOIN accounts a ON a.id = e.account_id
+		WHERE e.ai_summary IS NULL AND a.auto_summarize
+		  AND e.summary_attempts < $2
+		  AND (e.summary_failed_at IS NULL
+		       OR e.summary_failed_at < NOW() - make_interval(hours => power(2, e.summary_attempts)::int))
+		  AND NOT EXISTS (
+		      SELECT 1 FROM email_categories ec
+		      INNER JOIN categories c ON c.id = ec.category_id
+		      WHERE ec.email_id = e.id AND NOT c.auto_summarize
+		  )
+		ORDER BY e.received_at DESC
+		LIMIT $1
+	`, limit, maxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query emails pending summary: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []entities.Email
+	for rows.Next() {
+		var email entities.Email
+		err := scanEmail(rows, &email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		emails = append(emails, email)
+	}
+
+	return emails, nil
+}
+
+// RecordSummaryFailure counts a failed attempt to summarize an email
+func (r *EmailRepository) RecordSummaryFailure(ctx context.Context, emailID int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE emails
+		SET summary_attempts = summary_attempts + 1, summary_failed_at = NOW()
+		WHERE id = $1
+	`, emailID)
+	if err != nil {
+		return fmt.Errorf("failed to record summary failure: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingRiskAssessments returns emails that have not been scored for risk, newest
+// first. After a failure an email waits 2^attempts hours before it is tried again.
+func (r *EmailRepository) GetPendingRiskAssessments(ctx context.Context, limit, maxAttempts int) ([]entities.Email, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+emailColumns+`
+		FROM emails e
+		WHERE e.risk_score IS NULL
+		  AND e.risk_attempts < $2
+		  AND (e.risk_failed_at IS NULL
+		       OR e.risk_failed_at < NOW() - make_interval(hours => power(2, e.risk_attempts)::int))
+		ORDER BY e.received_at DESC
+		LIMIT $1
+	`, limit, maxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query emails pending risk assessment: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []entities.Email
+	for rows.Next() {
+		var email entities.Email
+		err := scanEmail(rows, &email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		emails = append(emails, email)
+	}
+
+	return emails, nil
+}
+
+// RecordRiskFailure counts a failed attempt to score an email
+func (r *EmailRepository) RecordRiskFailure(ctx context.Context, emailID int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE emails
+		SET risk_attempts = risk_attempts + 1, risk_failed_at = NOW()
+		WHERE id = $1
+	`, emailID)
+	if err != nil {
+		return fmt.Errorf("failed to record risk assessment failure: %w", err)
+	}
+
+	return nil
+}
+
+func (r *EmailRepository) GetByThreadID(ctx context.Context, threadID int64) ([]entities.Email, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+emailColumns+`
+		FROM emails e
+		WHERE e.thread_id = $1 
+		ORDER BY e.received_at ASC
+	`, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query thread emails: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []entities.Email
+	for rows.Next() {
+		var email entities.Email
+		err := scanEmail(rows, &email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+
+		emails = append(emails, email)
+	}
+
+	if err := r.loadCategories(ctx, emailPointers(emails)...); err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+func (r *EmailRepository) UpdateRiskAssessment(ctx context.Context, emailID int64, score float64, reasons []string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE emails 
+		SET risk_score = $1, risk_reasons = $2, updated_at = NOW()
+		WHERE id = $3
+	`, score, reasons, emailID)
+	if err != nil {
+		return fmt.Errorf("failed to update risk assessment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *EmailRepository) MarkMailedAfterUnsubscribe(ctx context.Context, emailIDs []int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE emails 
+		SET mailed_after_unsubscribe = TRUE, updated_at = NOW()
+		WHERE id = ANY($1)
+	`, emailIDs)
+	if err != nil {
+		return fmt.Errorf("failed to flag emails mailed after unsubscribe: %w", err)
+	}
+
+	return nil
+}
+
+func (r *EmailRepository) UpdateFlagsByGmailMessageID(ctx context.Context, accountID int64, gmailMessageID string, isRead, isStarred bool) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE emails 
+		SET is_read = $1, is_starred = $2, updated_at = NOW()
+		WHERE account_id = $3 AND gmail_message_id = $4 AND (is_read <> $1 OR is_starred <> $2)
+	`, isRead, isStarred, accountID, gmailMessageID)
+	if err != nil {
+		return fmt.Errorf("failed to update email flags: %w", err)
+	}
+
+	return nil
+}
+
+func (r *EmailRepository) UpdateArchivedInGmail(ctx context.Context, emailID int64, archived bool) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE emails 
+		SET is_archived_in_gmail = $1, updated_at = NOW()
+		WHERE id = $2
+	`, archived, emailID)
+	if err != nil {
+		return fmt.Errorf("failed to update archived state: %w", err)
+	}
+
+	return nil
+}
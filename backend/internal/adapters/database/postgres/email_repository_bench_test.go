@@ -0,0 +1,232 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/email-sorting-app/internal/adapters/database/migrations"
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// The benchmarks need a Postgres they can create a scratch schema in, e.g.
+// TEST_DATABASE_URL=postgres://localhost/email_sorting_test go test -bench . ./internal/adapters/database/postgres
+const testDatabaseURLEnv = "TEST_DATABASE_URL"
+
+const (
+	benchEmailCount    = 500
+	benchCategoryCount = 5
+)
+
+// queryCounter counts the queries run on a pool
+type queryCounter struct {
+	count atomic.Int64
+}
+
+func (q *queryCounter) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	q.count.Add(1)
+	return ctx
+}
+
+func (q *queryCounter) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+}
+
+type benchFixture struct {
+	repo       *EmailRepository
+	queries    *queryCounter
+	accountID  int64
+	categoryID int64
+}
+
+// newTestDB migrates a scratch Postgres schema, dropped when tb finishes, and returns
+// a pool using it along with the counter of its queries
+func newTestDB(tb testing.TB) (*pgxpool.Pool, *queryCounter) {
+	tb.Helper()
+
+	databaseURL := os.Getenv(testDatabaseURLEnv)
+	if databaseURL == "" {
+		tb.Skipf("%s not set", testDatabaseURLEnv)
+	}
+	ctx := context.Background()
+
+	admin, err := pgxpool.New(ctx, databaseURL)
+	if err != nil {
+		tb.Fatalf("Failed to connect: %v", err)
+	}
+	tb.Cleanup(admin.Close)
+
+	schemaName := fmt.Sprintf("bench_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schemaName); err != nil {
+		tb.Fatalf("Failed to create schema: %v", err)
+	}
+	tb.Cleanup(func() {
+		admin.Exec(context.Background(), "DROP SCHEMA "+schemaName+" CASCADE")
+	})
+
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		tb.Fatalf("Failed to parse %s: %v", testDatabaseURLEnv, err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schemaName
+	queries := &queryCounter{}
+	config.ConnConfig.Tracer = queries
+
+	db, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		tb.Fatalf("Failed to connect: %v", err)
+	}
+	tb.Cleanup(db.Close)
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		tb.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		tb.Fatalf("Failed to create tables: %v", err)
+	}
+
+	return db, queries
+}
+
+// createTestAccount inserts an account with the given address and returns its ID
+func createTestAccount(tb testing.TB, db *pgxpool.Pool, email string) int64 {
+	tb.Helper()
+
+	var accountID int64
+	err := db.QueryRow(context.Background(), `
+		INSERT INTO accounts (email, name, access_token) VALUES ($1, 'Test', 'token')
+		RETURNING id
+	`, email).Scan(&accountID)
+	if err != nil {
+		tb.Fatalf("Failed to create account: %v", err)
+	}
+	return accountID
+}
+
+// newBenchFixture creates the schema in a scratch Postgres schema and fills it with an
+// account of emails spread over a few categories
+func newBenchFixture(tb testing.TB) *benchFixture {
+	tb.Helper()
+	ctx := context.Background()
+
+	db, queries := newTestDB(tb)
+	fixture := &benchFixture{repo: NewEmailRepository(db), queries: queries}
+	fixture.accountID = createTestAccount(tb, db, "bench@example.com")
+
+	categoryIDs := make([]int64, benchCategoryCount)
+	for i := range categoryIDs {
+		err := db.QueryRow(ctx, `
+			INSERT INTO categories (account_id, name) VALUES ($1, $2) RETURNING id
+		`, fixture.accountID, fmt.Sprintf("Category %d", i)).Scan(&categoryIDs[i])
+		if err != nil {
+			tb.Fatalf("Failed to create category: %v", err)
+		}
+	}
+	fixture.categoryID = categoryIDs[0]
+
+	// Every email is in two categories, so each of them holds a good share of the emails
+	emails := make([]entities.Email, benchEmailCount)
+	start := time.Now().Add(-benchEmailCount * time.Minute)
+	for i := range emails {
+		emails[i] = entities.Email{
+			AccountID:      fixture.accountID,
+			GmailMessageID: fmt.Sprintf("bench-%d", i),
+			Sender:         fmt.Sprintf("Sender %d <sender%d@example.com>", i%20, i%20),
+			Subject:        fmt.Sprintf("Weekly invoice %d", i),
+			Body:           "Your invoice is attached. Reply to this email with any questions about the charges.",
+			ReceivedAt:     start.Add(time.Duration(i) * time.Minute),
+			CategoryIDs:    []int64{categoryIDs[0], categoryIDs[1+i%(benchCategoryCount-1)]},
+		}
+	}
+	if _, err := fixture.repo.BulkCreate(ctx, emails); err != nil {
+		tb.Fatalf("Failed to create emails: %v", err)
+	}
+
+	return fixture
+}
+
+// countQueries returns how many queries fn ran
+func (f *benchFixture) countQueries(tb testing.TB, fn func() error) int64 {
+	tb.Helper()
+	before := f.queries.count.Load()
+	if err := fn(); err != nil {
+		tb.Fatalf("Query failed: %v", err)
+	}
+	return f.queries.count.Load() - before
+}
+
+// listings runs each email listing method for a page of the given size
+func (f *benchFixture) listings(pageSize int) map[string]func() error {
+	ctx := context.Background()
+	page := repositories.PaginationParams{Page: 2, PageSize: pageSize}
+	cursor := repositories.CursorParams{Limit: pageSize}
+	search := &repositories.EmailSearchQuery{Text: "invoice"}
+
+	return map[string]func() error{
+		"GetByAccountID": func() error {
+			_, err := f.repo.GetByAccountID(ctx, f.accountID)
+			return err
+		},
+		"GetByAccountIDPaginated": func() error {
+			_, err := f.repo.GetByAccountIDPaginated(ctx, f.accountID, page)
+			return err
+		},
+		"GetByCategoryIDPaginated": func() error {
+			_, err := f.repo.GetByCategoryIDPaginated(ctx, f.accountID, f.categoryID, page)
+			return err
+		},
+		"GetByAccountIDCursor": func() error {
+			_, err := f.repo.GetByAccountIDCursor(ctx, f.accountID, cursor)
+			return err
+		},
+		"GetByCategoryIDCursor": func() error {
+			_, err := f.repo.GetByCategoryIDCursor(ctx, f.accountID, f.categoryID, cursor)
+			return err
+		},
+		"Search": func() error {
+			_, err := f.repo.Search(ctx, f.accountID, search, page)
+			return err
+		},
+	}
+}
+
+// Loading categories must not cost a query per email: a page of 100 runs the same
+// queries as a page of 10
+func TestEmailRepository_QueriesPerPageAreConstant(t *testing.T) {
+	fixture := newBenchFixture(t)
+
+	small := fixture.listings(10)
+	large := fixture.listings(100)
+	for name, list := range small {
+		smallCount := fixture.countQueries(t, list)
+		largeCount := fixture.countQueries(t, large[name])
+		if smallCount != largeCount {
+			t.Errorf("%s: expected the same number of queries for 10 and 100 emails, got %d and %d", name, smallCount, largeCount)
+		}
+		if largeCount > 3 {
+			t.Errorf("%s: expected at most 3 queries, got %d", name, largeCount)
+		}
+	}
+}
+
+func BenchmarkEmailRepository(b *testing.B) {
+	fixture := newBenchFixture(b)
+
+	for _, pageSize := range []int{20, 100} {
+		for name, list := range fixture.listings(pageSize) {
+			b.Run(fmt.Sprintf("%s/page=%d", name, pageSize), func(b *testing.B) {
+				var queries int64
+				for i := 0; i < b.N; i++ {
+					queries += fixture.countQueries(b, list)
+				}
+				b.ReportMetric(float64(queries)/float64(b.N), "queries/op")
+			})
+		}
+	}
+}
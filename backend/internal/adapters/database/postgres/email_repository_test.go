@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+func TestEmailRepository_SearchRanksMatches(t *testing.T) {
+	db, _ := newTestDB(t)
+	repo := NewEmailRepository(db)
+	ctx := context.Background()
+	accountID := createTestAccount(t, db, "search@example.com")
+	otherAccountID := createTestAccount(t, db, "other@example.com")
+
+	now := time.Now()
+	emails := []entities.Email{
+		{AccountID: accountID, GmailMessageID: "body", Sender: "Shop <news@shop.example>", Subject: "Weekly deals",
+			Body: "Your invoice is below", ReceivedAt: now},
+		{AccountID: accountID, GmailMessageID: "subject", Sender: "Billing <billing@shop.example>", Subject: "Invoice for March",
+			Body: "Thanks for your order", ReceivedAt: now.Add(-time.Hour)},
+		{AccountID: accountID, GmailMessageID: "unrelated", Sender: "Friend <friend@example.com>", Subject: "Lunch",
+			Body: "See you at noon", ReceivedAt: now},
+		{AccountID: otherAccountID, GmailMessageID: "other", Sender: "Billing <billing@shop.example>", Subject: "Invoice for April",
+			Body: "Thanks for your order", ReceivedAt: now},
+	}
+	if _, err := repo.BulkCreate(ctx, emails); err != nil {
+		t.Fatalf("Failed to create emails: %v", err)
+	}
+
+	page := repositories.PaginationParams{Page: 1, PageSize: 10}
+	results, err := repo.Search(ctx, accountID, &repositories.EmailSearchQuery{Text: "invoice"}, page)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Subject matches outrank body matches, and other accounts' emails aren't searched
+	if results.TotalCount != 2 || len(results.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", results.TotalCount)
+	}
+	if results.Results[0].Email.GmailMessageID != "subject" || results.Results[1].Email.GmailMessageID != "body" {
+		t.Errorf("Expected the subject match first, got %s then %s", results.Results[0].Email.GmailMessageID, results.Results[1].Email.GmailMessageID)
+	}
+	if results.Results[0].Rank <= results.Results[1].Rank {
+		t.Errorf("Expected ranks to decrease, got %v and %v", results.Results[0].Rank, results.Results[1].Rank)
+	}
+	if !strings.Contains(results.Results[1].Snippet, "<mark>invoice</mark>") {
+		t.Errorf("Expected the match to be highlighted, got %q", results.Results[1].Snippet)
+	}
+
+	// Filters narrow a text search
+	results, err = repo.Search(ctx, accountID, &repositories.EmailSearchQuery{Text: "invoice", From: []string{"news@"}}, page)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if results.TotalCount != 1 || results.Results[0].Email.GmailMessageID != "body" {
+		t.Errorf("Expected only the email from news@, got %d results", results.TotalCount)
+	}
+}
+
+func TestEmailRepository_BulkCreateSkipsStoredEmails(t *testing.T) {
+	db, _ := newTestDB(t)
+	repo := NewEmailRepository(db)
+	ctx := context.Background()
+	accountID := createTestAccount(t, db, "import@example.com")
+	otherAccountID := createTestAccount(t, db, "other@example.com")
+
+	newEmail := func(accountID int64, gmailMessageID string) entities.Email {
+		return entities.Email{AccountID: accountID, GmailMessageID: gmailMessageID, Subject: gmailMessageID, ReceivedAt: time.Now()}
+	}
+
+	ids, err := repo.BulkCreate(ctx, []entities.Email{newEmail(accountID, "a"), newEmail(accountID, "b")})
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("Expected 2 emails to be created, got %v, %v", ids, err)
+	}
+
+	// A re-import only inserts what is new, including a message listed twice once
+	emails := []entities.Email{newEmail(accountID, "a"), newEmail(accountID, "c"), newEmail(accountID, "c")}
+	ids, err = repo.BulkCreate(ctx, emails)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(ids) != 1 || emails[0].ID != 0 || emails[1].ID != ids[0] || emails[2].ID != 0 {
+		t.Errorf("Expected only the first c to be inserted, got %v and IDs %d, %d, %d", ids, emails[0].ID, emails[1].ID, emails[2].ID)
+	}
+
+	// Another account receiving the same message stores its own copy
+	ids, err = repo.BulkCreate(ctx, []entities.Email{newEmail(otherAccountID, "a")})
+	if err != nil || len(ids) != 1 {
+		t.Errorf("Expected the other account's copy to be inserted, got %v, %v", ids, err)
+	}
+
+	var count int
+	if err := db.QueryRow(ctx, "SELECT COUNT(*) FROM emails").Scan(&count); err != nil || count != 4 {
+		t.Errorf("Expected 4 stored emails, got %d, %v", count, err)
+	}
+}
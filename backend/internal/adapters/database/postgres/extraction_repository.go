@@ -0,0 +1,252 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ExtractionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewExtractionRepository(db *pgxpool.Pool) *ExtractionRepository {
+	return &ExtractionRepository{db: db}
+}
+
+// SaveExtraction stores the result of extracting an email. Action items that were
+// extracted before are matched by their normalized description and keep their status;
+// open items the extraction no longer finds are removed, finished ones are kept.
+func (r *ExtractionRepository) SaveExtraction(ctx context.Context, accountID int64, extraction *entities.EmailExtraction) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	keys := make([]string, 0, len(extraction.ActionItems))
+	for i := range extraction.ActionItems {
+		item := &extraction.ActionItems[i]
+		item.EmailID = extraction.EmailID
+		item.AccountID = accountID
+
+		// The key is normalized in SQL only, so every item is keyed the same way
+		var key string
+		err = tx.QueryRow(ctx, `
+			INSERT INTO action_items (email_id, account_id, description, item_key, due_at, status, created_at, updated_at)
+			VALUES ($1, $2, $3, lower(regexp_replace(btrim($3), '\s+', ' ', 'g')), $4, $5, NOW(), NOW())
+			ON CONFLICT (email_id, item_key)
+			DO UPDATE SET description = EXCLUDED.description, due_at = EXCLUDED.due_at, updated_at = NOW()
+			RETURNING id, item_key, status, created_at, updated_at
+		`, item.EmailID, item.AccountID, item.Description, item.DueAt, entities.ActionItemStatusOpen).Scan(
+			&item.ID, &key, &item.Status, &item.CreatedAt, &item.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save action item: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM action_items
+		WHERE email_id = $1 AND status = $2 AND NOT (item_key = ANY($3))
+	`, extraction.EmailID, entities.ActionItemStatusOpen, keys)
+	if err != nil {
+		return fmt.Errorf("failed to remove stale action items: %w", err)
+	}
+
+	// Entities carry no state of their own, so they are simply replaced
+	_, err = tx.Exec(ctx, "DELETE FROM extracted_entities WHERE email_id = $1", extraction.EmailID)
+	if err != nil {
+		return fmt.Errorf("failed to remove existing entities: %w", err)
+	}
+
+	for i := range extraction.Entities {
+		entity := &extraction.Entities[i]
+		entity.EmailID = extraction.EmailID
+
+		err = tx.QueryRow(ctx, `
+			INSERT INTO extracted_entities (email_id, entity_type, value, occurs_at, created_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			RETURNING id, created_at
+		`, entity.EmailID, entity.Type, entity.Value, entity.OccursAt).Scan(&entity.ID, &entity.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert extracted entity: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE emails SET extraction_pending = false WHERE id = $1", extraction.EmailID)
+	if err != nil {
+		return fmt.Errorf("failed to mark email as extracted: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingExtractions returns the IDs of new emails not extracted yet, newest first.
+// After a failure an email waits 2^attempts hours before it is tried again.
+func (r *ExtractionRepository) GetPendingExtractions(ctx context.Context, limit, maxAttempts int) ([]int64, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id FROM emails
+		WHERE extraction_pending
+		  AND extraction_attempts < $2
+		  AND (extraction_failed_at IS NULL
+		       OR extraction_failed_at < NOW() - make_interval(hours => power(2, extraction_attempts)::int))
+		ORDER BY received_at DESC
+		LIMIT $1
+	`, limit, maxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query emails pending extraction: %w", err)
+	}
+	defer rows.Close()
+
+	var emailIDs []int64
+	for rows.Next() {
+		var emailID int64
+		if err := rows.Scan(&emailID); err != nil {
+			return nil, fmt.Errorf("failed to scan email ID: %w", err)
+		}
+		emailIDs = append(emailIDs, emailID)
+	}
+
+	return emailIDs, rows.Err()
+}
+
+// RecordExtractionFailure counts a failed attempt to extract an email
+func (r *ExtractionRepository) RecordExtractionFailure(ctx context.Context, emailID int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE emails
+		SET extraction_attempts = extraction_attempts + 1, extraction_failed_at = NOW()
+		WHERE id = $1
+	`, emailID)
+	if err != nil {
+		return fmt.Errorf("failed to record extraction failure: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ExtractionRepository) GetByEmailID(ctx context.Context, emailID int64) (*entities.EmailExtraction, error) {
+	extraction := &entities.EmailExtraction{
+		EmailID:     emailID,
+		ActionItems: []entities.ActionItem{},
+		Entities:    []entities.ExtractedEntity{},
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, email_id, account_id, description, due_at, status, created_at, updated_at
+		FROM action_items 
+		WHERE email_id = $1 
+		ORDER BY id
+	`, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query action items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entities.ActionItem
+		err := rows.Scan(
+			&item.ID, &item.EmailID, &item.AccountID, &item.Description,
+			&item.DueAt, &item.Status, &item.CreatedAt, &item.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan action item: %w", err)
+		}
+		extraction.ActionItems = append(extraction.ActionItems, item)
+	}
+	rows.Close()
+
+	rows, err = r.db.Query(ctx, `
+		SELECT id, email_id, entity_type, value, occurs_at, created_at
+		FROM extracted_entities 
+		WHERE email_id = $1 
+		ORDER BY id
+	`, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query extracted entities: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entity entities.ExtractedEntity
+		err := rows.Scan(&entity.ID, &entity.EmailID, &entity.Type, &entity.Value, &entity.OccursAt, &entity.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan extracted entity: %w", err)
+		}
+		extraction.Entities = append(extraction.Entities, entity)
+	}
+
+	return extraction, nil
+}
+
+func (r *ExtractionRepository) GetOpenActionItems(ctx context.Context) ([]entities.ActionItem, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT a.id, a.email_id, a.account_id, a.description, a.due_at, a.status, 
+		       COALESCE(e.subject, ''), COALESCE(e.sender, ''), a.created_at, a.updated_at
+		FROM action_items a
+		INNER JOIN emails e ON e.id = a.email_id
+		WHERE a.status = $1
+		ORDER BY a.due_at ASC NULLS LAST, a.created_at DESC
+	`, entities.ActionItemStatusOpen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query open action items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []entities.ActionItem{}
+	for rows.Next() {
+		var item entities.ActionItem
+		err := rows.Scan(
+			&item.ID, &item.EmailID, &item.AccountID, &item.Description, &item.DueAt, &item.Status,
+			&item.EmailSubject, &item.EmailSender, &item.CreatedAt, &item.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan action item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (r *ExtractionRepository) GetActionItemByID(ctx context.Context, id int64) (*entities.ActionItem, error) {
+	var item entities.ActionItem
+	err := r.db.QueryRow(ctx, `
+		SELECT id, email_id, account_id, description, due_at, status, created_at, updated_at
+		FROM action_items WHERE id = $1
+	`, id).Scan(
+		&item.ID, &item.EmailID, &item.AccountID, &item.Description,
+		&item.DueAt, &item.Status, &item.CreatedAt, &item.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, apperrors.NewNotFoundError("action item not found")
+		}
+		return nil, fmt.Errorf("failed to get action item: %w", err)
+	}
+
+	return &item, nil
+}
+
+func (r *ExtractionRepository) UpdateActionItemStatus(ctx context.Context, id int64, status string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE action_items 
+		SET status = $1, updated_at = NOW()
+		WHERE id = $2
+	`, status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update action item status: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type JobRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewJobRepository(db *pgxpool.Pool) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+func (r *JobRepository) Create(ctx context.Context, job *entities.Job) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if job.Kind == "" {
+		job.Kind = entities.JobKindUnsubscribe
+	}
+	if job.Status == "" {
+		job.Status = entities.JobStatusQueued
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO jobs (kind, status, created_at)
+		VALUES ($1, $2, NOW())
+		RETURNING id, created_at
+	`, job.Kind, job.Status).Scan(&job.ID, &job.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create unsubscribe job: %w", err)
+	}
+
+	for i := range job.Items {
+		item := &job.Items[i]
+		item.JobID = job.ID
+		if item.Status == "" {
+			item.Status = entities.JobItemStatusPending
+		}
+
+		err = tx.QueryRow(ctx, `
+			INSERT INTO job_items (job_id, email_id, status, message, updated_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			RETURNING id, updated_at
+		`, item.JobID, item.EmailID, item.Status, item.Message).Scan(&item.ID, &item.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create unsubscribe job item: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	job.Tally()
+	return nil
+}
+
+func (r *JobRepository) GetByID(ctx context.Context, id int64) (*entities.Job, error) {
+	var job entities.Job
+	err := r.db.QueryRow(ctx, `
+		SELECT id, kind, status, created_at, started_at, finished_at
+		FROM jobs WHERE id = $1
+	`, id).Scan(&job.ID, &job.Kind, &job.Status, &job.CreatedAt, &job.StartedAt, &job.FinishedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, apperrors.NewNotFoundError("job not found")
+		}
+		return nil, fmt.Errorf("failed to get unsubscribe job: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, job_id, email_id, status, COALESCE(strategy, ''), message, COALESCE(error_type, ''), updated_at
+		FROM job_items
+		WHERE job_id = $1
+		ORDER BY id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unsubscribe job items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entities.JobItem
+		err := rows.Scan(&item.ID, &item.JobID, &item.EmailID, &item.Status, &item.Strategy, &item.Message, &item.ErrorType, &item.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan unsubscribe job item: %w", err)
+		}
+		job.Items = append(job.Items, item)
+	}
+
+	job.Tally()
+	return &job, nil
+}
+
+func (r *JobRepository) GetUnfinishedIDs(ctx context.Context) ([]int64, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id FROM jobs
+		WHERE status IN ('queued', 'running')
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unfinished unsubscribe jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan unsubscribe job id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (r *JobRepository) UpdateStatus(ctx context.Context, id int64, status string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE jobs 
+		SET status = $1,
+		    started_at = CASE WHEN $1 = 'running' THEN COALESCE(started_at, NOW()) ELSE started_at END,
+		    finished_at = CASE WHEN $1 IN ('completed', 'cancelled') THEN NOW() ELSE finished_at END
+		WHERE id = $2
+	`, status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update unsubscribe job status: %w", err)
+	}
+
+	return nil
+}
+
+func (r *JobRepository) UpdateItem(ctx context.Context, item *entities.JobItem) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE job_items 
+		SET status = $1, strategy = NULLIF($2, ''), message = $3, error_type = NULLIF($4, ''), updated_at = NOW()
+		WHERE id = $5
+		RETURNING updated_at
+	`, item.Status, item.Strategy, item.Message, item.ErrorType, item.ID).Scan(&item.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update unsubscribe job item: %w", err)
+	}
+
+	return nil
+}
+
+func (r *JobRepository) CancelPendingItems(ctx context.Context, jobID int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE job_items 
+		SET status = 'cancelled', updated_at = NOW()
+		WHERE job_id = $1 AND status = 'pending'
+	`, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel pending unsubscribe job items: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SenderRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSenderRepository(db *pgxpool.Pool) *SenderRepository {
+	return &SenderRepository{db: db}
+}
+
+// senderColumns lists the columns read by scanSender; queries alias the senders table as s
+// and join the sender's most relevant unsubscribe attempt as a, like the subscriptions view
+const senderColumns = `s.id, s.account_id, s.address, s.display_name, s.domain,
+		       COALESCE(s.first_seen_at, s.created_at), COALESCE(s.last_seen_at, s.created_at), s.message_count, s.has_unsubscribe,
+		       CASE
+		           WHEN a.id IS NOT NULL AND a.success AND a.ineffective_at IS NULL THEN 'unsubscribed'
+		           WHEN a.id IS NOT NULL AND a.success THEN 'ineffective'
+		           WHEN a.id IS NOT NULL AND a.requires_auth THEN 'requires_auth'
+		           WHEN a.id IS NOT NULL AND a.error_type = 'unverified' THEN 'unverified'
+		           WHEN a.id IS NOT NULL THEN 'failed'
+		           WHEN s.has_unsubscribe THEN 'subscribed'
+		           ELSE 'none'
+		       END,
+		       s.created_at, s.updated_at`
+
+const senderAttemptJoin = `
+		LEFT JOIN LATERAL (
+		    SELECT ua.id, ua.success, ua.requires_auth, ua.error_type, ua.ineffective_at
+		    FROM unsubscribe_attempts ua
+		    WHERE ua.account_id = s.account_id AND ua.sender_address = s.address
+		    ORDER BY (ua.success AND ua.ineffective_at IS NULL) DESC, ua.created_at DESC
+		    LIMIT 1
+		) a ON TRUE`
+
+func scanSender(row pgx.Row, sender *entities.Sender) error {
+	return row.Scan(
+		&sender.ID, &sender.AccountID, &sender.Address, &sender.DisplayName, &sender.Domain,
+		&sender.FirstSeenAt, &sender.LastSeenAt, &sender.MessageCount, &sender.HasUnsubscribe,
+		&sender.UnsubscribeStatus, &sender.CreatedAt, &sender.UpdatedAt,
+	)
+}
+
+func (r *SenderRepository) RecordEmails(ctx context.Context, accountID int64, emails []entities.Email) error {
+	if len(emails) == 0 {
+		return nil
+	}
+
+	// One row per sender, named as in their latest email
+	type senderRow struct {
+		address, displayName string
+		latest               time.Time
+		hasUnsubscribe       bool
+	}
+	bySender := make(map[string]*senderRow)
+	var order []string
+	var emailIDs []int64
+	var emailAddresses []string
+	for _, email := range emails {
+		address := entities.SenderAddress(email.Sender)
+		if address == "" {
+			continue
+		}
+		emailIDs = append(emailIDs, email.ID)
+		emailAddresses = append(emailAddresses, address)
+
+		row, exists := bySender[address]
+		if !exists {
+			row = &senderRow{address: address}
+			bySender[address] = row
+			order = append(order, address)
+		}
+		if !exists || email.ReceivedAt.After(row.latest) {
+			row.displayName = entities.SenderName(email.Sender)
+			row.latest = email.ReceivedAt
+		}
+		if email.UnsubscribeLink != nil || email.ListUnsubscribe != "" {
+			row.hasUnsubscribe = true
+		}
+	}
+
+	addresses := make([]string, len(order))
+	displayNames := make([]string, len(order))
+	domains := make([]string, len(order))
+	latest := make([]time.Time, len(order))
+	hasUnsubscribe := make([]bool, len(order))
+	for i, address := range order {
+		row := bySender[address]
+		addresses[i] = address
+		displayNames[i] = row.displayName
+		domains[i] = entities.SenderDomain(address)
+		latest[i] = row.latest
+		hasUnsubscribe[i] = row.hasUnsubscribe
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// A newer email's display name wins; an older one's never replaces it
+	_, err = tx.Exec(ctx, `
+		INSERT INTO senders (account_id, address, display_name, domain, last_seen_at, has_unsubscribe, created_at, updated_at)
+		SELECT $1, address, display_name, domain, latest, has_unsubscribe, NOW(), NOW()
+		FROM unnest($2::text[], $3::text[], $4::text[], $5::timestamptz[], $6::boolean[])
+		     AS n(address, display_name, domain, latest, has_unsubscribe)
+		ON CONFLICT (account_id, address) DO UPDATE
+		SET display_name = CASE
+		        WHEN senders.last_seen_at IS NULL OR EXCLUDED.last_seen_at >= senders.last_seen_at THEN EXCLUDED.display_name
+		        ELSE senders.display_name
+		    END,
+		    has_unsubscribe = senders.has_unsubscribe OR EXCLUDED.has_unsubscribe,
+		    updated_at = NOW()
+	`, accountID, addresses, displayNames, domains, latest, hasUnsubscribe)
+	if err != nil {
+		return fmt.Errorf("failed to upsert senders: %w", err)
+	}
+
+	// Link the emails by the addresses computed above, so linking and the senders
+	// themselves share entities.SenderAddress as the one normaliser
+	_, err = tx.Exec(ctx, `
+		UPDATE emails e
+		SET sender_id = s.id
+		FROM unnest($2::bigint[], $3::text[]) AS l(email_id, address)
+		INNER JOIN senders s ON s.account_id = $1 AND s.address = l.address
+		WHERE e.id = l.email_id
+	`, accountID, emailIDs, emailAddresses)
+	if err != nil {
+		return fmt.Errorf("failed to link emails to senders: %w", err)
+	}
+
+	// Recount the senders of the batch; a full resync resets the counts of the
+	// account's senders when it deletes their emails
+	_, err = tx.Exec(ctx, `
+		UPDATE senders s
+		SET message_count = stats.message_count,
+		    first_seen_at = COALESCE(stats.first_seen_at, s.first_seen_at),
+		    last_seen_at = COALESCE(stats.last_seen_at, s.last_seen_at)
+		FROM (
+		    SELECT s2.id, COUNT(e.id) AS message_count, MIN(e.received_at) AS first_seen_at, MAX(e.received_at) AS last_seen_at
+		    FROM senders s2
+		    LEFT JOIN emails e ON e.sender_id = s2.id
+		    WHERE s2.account_id = $1 AND s2.address = ANY($2)
+		    GROUP BY s2.id
+		) stats
+		WHERE s.id = stats.id
+	`, accountID, addresses)
+	if err != nil {
+		return fmt.Errorf("failed to refresh sender statistics: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SenderRepository) GetByID(ctx context.Context, id int64) (*entities.Sender, error) {
+	var sender entities.Sender
+	err := scanSender(r.db.QueryRow(ctx, `
+		SELECT `+senderColumns+`
+		FROM senders s`+senderAttemptJoin+`
+		WHERE s.id = $1
+	`, id), &sender)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, apperrors.NewNotFoundError("sender not found")
+		}
+		return nil, fmt.Errorf("failed to get sender: %w", err)
+	}
+
+	return &sender, nil
+}
+
+func (r *SenderRepository) GetByAccountIDPaginated(ctx context.Context, accountID int64, params repositories.PaginationParams) (*repositories.PaginatedSenders, error) {
+	// Get total count
+	var totalCount int64
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM senders WHERE account_id = $1 AND message_count > 0
+	`, accountID).Scan(&totalCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	// Calculate offset
+	offset := (params.Page - 1) * params.PageSize
+
+	rows, err := r.db.Query(ctx, `
+		SELECT `+senderColumns+`
+		FROM senders s`+senderAttemptJoin+`
+		WHERE s.account_id = $1 AND s.message_count > 0
+		ORDER BY s.message_count DESC, s.last_seen_at DESC, s.id
+		LIMIT $2 OFFSET $3
+	`, accountID, params.PageSize, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query senders: %w", err)
+	}
+	defer rows.Close()
+
+	senders := []entities.Sender{}
+	for rows.Next() {
+		var sender entities.Sender
+		if err := scanSender(rows, &sender); err != nil {
+			return nil, fmt.Errorf("failed to scan sender: %w", err)
+		}
+		senders = append(senders, sender)
+	}
+
+	// Calculate total pages
+	totalPages := int(math.Ceil(float64(totalCount) / float64(params.PageSize)))
+
+	return &repositories.PaginatedSenders{
+		Senders:    senders,
+		TotalCount: totalCount,
+		Page:       params.Page,
+		PageSize:   params.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
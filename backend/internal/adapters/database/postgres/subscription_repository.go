@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SubscriptionRepository struct {
+	db *pgxpool.Pool
+}
+
+const subscriptionColumns = `account_id, sender_address, sender_domain, sender_name, email_count, last_received_at,
+		       status, last_attempt_at, last_strategy, last_error_type`
+
+func scanSubscription(row pgx.Row, subscription *entities.Subscription) error {
+	return row.Scan(
+		&subscription.AccountID, &subscription.SenderAddress, &subscription.SenderDomain, &subscription.SenderName,
+		&subscription.EmailCount, &subscription.LastReceivedAt, &subscription.Status,
+		&subscription.LastAttemptAt, &subscription.LastStrategy, &subscription.LastErrorType,
+	)
+}
+
+const attemptColumns = `id, account_id, COALESCE(email_id, 0), sender_address, sender_domain, COALESCE(strategy, ''), success, message,
+		       COALESCE(error_type, ''), requires_auth, COALESCE(sent_message_id, ''), artifacts, started_at, completed_at,
+		       ineffective_at, created_at`
+
+func scanAttempt(row pgx.Row, attempt *entities.UnsubscribeAttempt) error {
+	return row.Scan(
+		&attempt.ID, &attempt.AccountID, &attempt.EmailID, &attempt.SenderAddress, &attempt.SenderDomain,
+		&attempt.Strategy, &attempt.Success, &attempt.Message, &attempt.ErrorType, &attempt.RequiresAuth,
+		&attempt.SentMessageID, &attempt.Artifacts, &attempt.StartedAt, &attempt.CompletedAt,
+		&attempt.IneffectiveAt, &attempt.CreatedAt,
+	)
+}
+
+func NewSubscriptionRepository(db *pgxpool.Pool) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db}
+}
+
+func (r *SubscriptionRepository) CreateAttempt(ctx context.Context, attempt *entities.UnsubscribeAttempt) error {
+	if attempt.Artifacts == nil {
+		attempt.Artifacts = []string{}
+	}
+
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO unsubscribe_attempts (account_id, email_id, sender_address, sender_domain, strategy, success, message,
+		                                  error_type, requires_auth, sent_message_id, artifacts, started_at, completed_at, created_at)
+		VALUES ($1, NULLIF($2, 0), $3, $4, NULLIF($5, ''), $6, $7, NULLIF($8, ''), $9, NULLIF($10, ''), $11, $12, $13, NOW())
+		RETURNING id, created_at
+	`, attempt.AccountID, attempt.EmailID, attempt.SenderAddress, attempt.SenderDomain, attempt.Strategy, attempt.Success,
+		attempt.Message, attempt.ErrorType, attempt.RequiresAuth, attempt.SentMessageID, attempt.Artifacts,
+		attempt.StartedAt, attempt.CompletedAt).Scan(&attempt.ID, &attempt.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create unsubscribe attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepository) GetAttemptByID(ctx context.Context, id int64) (*entities.UnsubscribeAttempt, error) {
+	var attempt entities.UnsubscribeAttempt
+	err := scanAttempt(r.db.QueryRow(ctx, `
+		SELECT `+attemptColumns+`
+		FROM unsubscribe_attempts
+		WHERE id = $1
+	`, id), &attempt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, apperrors.NewNotFoundError("unsubscribe attempt not found")
+		}
+		return nil, fmt.Errorf("failed to get unsubscribe attempt: %w", err)
+	}
+
+	return &attempt, nil
+}
+
+func (r *SubscriptionRepository) GetAttemptsBySender(ctx context.Context, accountID int64, senderAddress string) ([]entities.UnsubscribeAttempt, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+attemptColumns+`
+		FROM unsubscribe_attempts
+		WHERE account_id = $1 AND sender_address = $2
+		ORDER BY created_at DESC, id DESC
+	`, accountID, senderAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unsubscribe attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []entities.UnsubscribeAttempt{}
+	for rows.Next() {
+		var attempt entities.UnsubscribeAttempt
+		err := scanAttempt(rows, &attempt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan unsubscribe attempt: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, nil
+}
+
+func (r *SubscriptionRepository) GetEffectiveAttempt(ctx context.Context, accountID int64, senderAddress string) (*entities.UnsubscribeAttempt, error) {
+	var attempt entities.UnsubscribeAttempt
+	err := scanAttempt(r.db.QueryRow(ctx, `
+		SELECT `+attemptColumns+`
+		FROM unsubscribe_attempts
+		WHERE account_id = $1 AND sender_address = $2 AND success AND ineffective_at IS NULL
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`, accountID, senderAddress), &attempt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get effective unsubscribe attempt: %w", err)
+	}
+
+	return &attempt, nil
+}
+
+func (r *SubscriptionRepository) MarkAttemptIneffective(ctx context.Context, attemptID int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE unsubscribe_attempts 
+		SET ineffective_at = NOW()
+		WHERE id = $1 AND ineffective_at IS NULL
+	`, attemptID)
+	if err != nil {
+		return fmt.Errorf("failed to mark unsubscribe attempt ineffective: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepository) GetByAccountID(ctx context.Context, accountID int64, status string) ([]entities.Subscription, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM subscriptions
+		WHERE account_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY email_count DESC, last_received_at DESC
+	`, accountID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []entities.Subscription{}
+	for rows.Next() {
+		var subscription entities.Subscription
+		err := scanSubscription(rows, &subscription)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, nil
+}
+
+func (r *SubscriptionRepository) GetBySender(ctx context.Context, accountID int64, senderAddress string) (*entities.Subscription, error) {
+	var subscription entities.Subscription
+	err := scanSubscription(r.db.QueryRow(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM subscriptions
+		WHERE account_id = $1 AND sender_address = $2
+	`, accountID, senderAddress), &subscription)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	return &subscription, nil
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ThreadRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewThreadRepository(db *pgxpool.Pool) *ThreadRepository {
+	return &ThreadRepository{db: db}
+}
+
+func (r *ThreadRepository) GetByID(ctx context.Context, id int64) (*entities.Thread, error) {
+	var thread entities.Thread
+	err := r.db.QueryRow(ctx, `
+		SELECT id, account_id, gmail_thread_id, COALESCE(subject, ''), message_count, last_message_at,
+		       ai_summary, created_at, updated_at
+		FROM threads WHERE id = $1
+	`, id).Scan(
+		&thread.ID, &thread.AccountID, &thread.GmailThreadID, &thread.Subject, &thread.MessageCount,
+		&thread.LastMessageAt, &thread.AISummary, &thread.CreatedAt, &thread.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, apperrors.NewNotFoundError("thread not found")
+		}
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+
+	return &thread, nil
+}
+
+func (r *ThreadRepository) GetOrCreate(ctx context.Context, accountID int64, gmailThreadID, subject string) (*entities.Thread, error) {
+	var thread entities.Thread
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO threads (account_id, gmail_thread_id, subject, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (account_id, gmail_thread_id) DO UPDATE SET updated_at = NOW()
+		RETURNING id, account_id, gmail_thread_id, COALESCE(subject, ''), message_count, last_message_at,
+		          ai_summary, created_at, updated_at
+	`, accountID, gmailThreadID, subject).Scan(
+		&thread.ID, &thread.AccountID, &thread.GmailThreadID, &thread.Subject, &thread.MessageCount,
+		&thread.LastMessageAt, &thread.AISummary, &thread.CreatedAt, &thread.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get or create thread: %w", err)
+	}
+
+	return &thread, nil
+}
+
+func (r *ThreadRepository) AttachEmails(ctx context.Context, threadID int64, emailIDs []int64) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE emails 
+		SET thread_id = $1, thread_summarized = thread_summarized AND thread_id IS NOT DISTINCT FROM $1, updated_at = NOW() 
+		WHERE id = ANY($2)
+	`, threadID, emailIDs)
+	if err != nil {
+		return fmt.Errorf("failed to attach emails to thread: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE threads 
+		SET message_count = stats.message_count, last_message_at = stats.last_message_at, updated_at = NOW()
+		FROM (
+		    SELECT COUNT(*) AS message_count, MAX(received_at) AS last_message_at 
+		    FROM emails WHERE thread_id = $1
+		) stats
+		WHERE threads.id = $1
+	`, threadID)
+	if err != nil {
+		return fmt.Errorf("failed to update thread stats: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ThreadRepository) UpdateSummary(ctx context.Context, threadID int64, summary string, emailIDs []int64) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE threads 
+		SET ai_summary = $1, updated_at = NOW()
+		WHERE id = $2
+	`, summary, threadID)
+	if err != nil {
+		return fmt.Errorf("failed to update thread summary: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE emails SET thread_summarized = TRUE 
+		WHERE thread_id = $1 AND id = ANY($2)
+	`, threadID, emailIDs)
+	if err != nil {
+		return fmt.Errorf("failed to mark summarized emails: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ThreadRepository) DeleteByAccountID(ctx context.Context, accountID int64) error {
+	_, err := r.db.Exec(ctx, "DELETE FROM threads WHERE account_id = $1", accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete threads by account ID: %w", err)
+	}
+
+	return nil
+}
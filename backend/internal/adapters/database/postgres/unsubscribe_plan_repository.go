@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/repositories"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UnsubscribePlanRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUnsubscribePlanRepository(db *pgxpool.Pool) *UnsubscribePlanRepository {
+	return &UnsubscribePlanRepository{db: db}
+}
+
+func (r *UnsubscribePlanRepository) Create(ctx context.Context, plan *repositories.UnsubscribePlan) error {
+	if plan.Actions == nil {
+		plan.Actions = []repositories.UnsubscribeAction{}
+	}
+	if plan.Screenshots == nil {
+		plan.Screenshots = []string{}
+	}
+
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO unsubscribe_plans (account_id, email_id, strategy, message, page_url, actions, screenshots, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, NOW())
+		RETURNING id, created_at
+	`, plan.AccountID, plan.EmailID, plan.Strategy, plan.Message, plan.PageURL, plan.Actions, plan.Screenshots,
+		plan.ExpiresAt).Scan(&plan.ID, &plan.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create unsubscribe plan: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UnsubscribePlanRepository) GetByID(ctx context.Context, id int64) (*repositories.UnsubscribePlan, error) {
+	var plan repositories.UnsubscribePlan
+	err := r.db.QueryRow(ctx, `
+		SELECT id, account_id, email_id, strategy, message, COALESCE(page_url, ''), actions, screenshots,
+		       created_at, expires_at, started_at, executed_at, failed_at
+		FROM unsubscribe_plans
+		WHERE id = $1
+	`, id).Scan(
+		&plan.ID, &plan.AccountID, &plan.EmailID, &plan.Strategy, &plan.Message, &plan.PageURL, &plan.Actions,
+		&plan.Screenshots, &plan.CreatedAt, &plan.ExpiresAt, &plan.StartedAt, &plan.ExecutedAt, &plan.FailedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, apperrors.NewNotFoundError("unsubscribe plan not found")
+		}
+		return nil, fmt.Errorf("failed to get unsubscribe plan: %w", err)
+	}
+
+	return &plan, nil
+}
+
+func (r *UnsubscribePlanRepository) Claim(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE unsubscribe_plans
+		SET started_at = NOW()
+		WHERE id = $1 AND started_at IS NULL AND executed_at IS NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to claim unsubscribe plan: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return apperrors.NewInvalidInputError(fmt.Sprintf("unsubscribe plan %d is running or was already executed", id))
+	}
+
+	return nil
+}
+
+func (r *UnsubscribePlanRepository) Finish(ctx context.Context, id int64, success bool) error {
+	query := `UPDATE unsubscribe_plans SET started_at = NULL, failed_at = NOW() WHERE id = $1`
+	if success {
+		query = `UPDATE unsubscribe_plans SET executed_at = NOW(), failed_at = NULL WHERE id = $1`
+	}
+
+	_, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to finish unsubscribe plan: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UnsubscribePlanRepository) DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, "DELETE FROM unsubscribe_plans WHERE expires_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired unsubscribe plans: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/email-sorting-app/internal/domain/repositories"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UnsubscribeRecipeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUnsubscribeRecipeRepository(db *pgxpool.Pool) *UnsubscribeRecipeRepository {
+	return &UnsubscribeRecipeRepository{db: db}
+}
+
+func (r *UnsubscribeRecipeRepository) GetByKey(ctx context.Context, host, pathPattern string) (*repositories.UnsubscribeRecipe, error) {
+	var recipe repositories.UnsubscribeRecipe
+	err := r.db.QueryRow(ctx, `
+		SELECT id, host, path_pattern, actions, success_count, miss_count, last_used_at, created_at, updated_at
+		FROM unsubscribe_recipes
+		WHERE host = $1 AND path_pattern = $2
+	`, host, pathPattern).Scan(
+		&recipe.ID, &recipe.Host, &recipe.PathPattern, &recipe.Actions, &recipe.SuccessCount, &recipe.MissCount,
+		&recipe.LastUsedAt, &recipe.CreatedAt, &recipe.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get unsubscribe recipe: %w", err)
+	}
+
+	return &recipe, nil
+}
+
+func (r *UnsubscribeRecipeRepository) Save(ctx context.Context, recipe *repositories.UnsubscribeRecipe) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO unsubscribe_recipes (host, path_pattern, actions, success_count, last_used_at, created_at, updated_at)
+		VALUES ($1, $2, $3, 1, NOW(), NOW(), NOW())
+		ON CONFLICT (host, path_pattern) DO UPDATE
+		SET actions = EXCLUDED.actions, success_count = unsubscribe_recipes.success_count + 1, miss_count = 0,
+		    last_used_at = NOW(), updated_at = NOW()
+		RETURNING id, success_count, miss_count, last_used_at, created_at, updated_at
+	`, recipe.Host, recipe.PathPattern, recipe.Actions).Scan(
+		&recipe.ID, &recipe.SuccessCount, &recipe.MissCount, &recipe.LastUsedAt, &recipe.CreatedAt, &recipe.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save unsubscribe recipe: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UnsubscribeRecipeRepository) RecordSuccess(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE unsubscribe_recipes
+		SET success_count = success_count + 1, miss_count = 0, last_used_at = NOW()
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to record unsubscribe recipe success: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UnsubscribeRecipeRepository) RecordMiss(ctx context.Context, id int64, maxMisses int) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM unsubscribe_recipes
+		WHERE id = $1 AND miss_count + 1 >= $2
+	`, id, maxMisses)
+	if err != nil {
+		return false, fmt.Errorf("failed to drop unsubscribe recipe: %w", err)
+	}
+	if result.RowsAffected() > 0 {
+		return true, nil
+	}
+
+	_, err = r.db.Exec(ctx, `
+		UPDATE unsubscribe_recipes
+		SET miss_count = miss_count + 1, last_used_at = NOW()
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to record unsubscribe recipe miss: %w", err)
+	}
+
+	return false, nil
+}
+
+func (r *UnsubscribeRecipeRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM unsubscribe_recipes WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete unsubscribe recipe: %w", err)
+	}
+
+	return nil
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"io"
+	"mime"
+	"net/mail"
+	"net/textproto"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/email-sorting-app/internal/domain/entities"
 	"github.com/email-sorting-app/internal/domain/repositories"
+	"github.com/email-sorting-app/pkg/mimeparse"
 	"golang.org/x/oauth2"
 	"google.golang.org/api/gmail/v1"
 	"google.golang.org/api/option"
@@ -18,6 +23,7 @@ import (
 type GmailService struct {
 	oauthConfig *oauth2.Config
 	aiService   repositories.AIService
+	endpoint    string // overrides the Gmail API base URL, used by tests
 }
 
 func NewGmailService(oauthConfig *oauth2.Config, aiService repositories.AIService) *GmailService {
@@ -27,9 +33,16 @@ func NewGmailService(oauthConfig *oauth2.Config, aiService repositories.AIServic
 	}
 }
 
+func (s *GmailService) newService(ctx context.Context, token *oauth2.Token) (*gmail.Service, error) {
+	opts := []option.ClientOption{option.WithHTTPClient(s.oauthConfig.Client(ctx, token))}
+	if s.endpoint != "" {
+		opts = append(opts, option.WithEndpoint(s.endpoint))
+	}
+	return gmail.NewService(ctx, opts...)
+}
+
 func (s *GmailService) ListMessages(ctx context.Context, token *oauth2.Token, maxResults int64) ([]entities.GmailMessage, error) {
-	client := s.oauthConfig.Client(ctx, token)
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := s.newService(ctx, token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gmail service: %w", err)
 	}
@@ -46,27 +59,14 @@ func (s *GmailService) ListMessages(ctx context.Context, token *oauth2.Token, ma
 			continue // Skip this email if we can't fetch it
 		}
 
-		body := s.extractBody(msg.Payload)
-		gmailMsg := entities.GmailMessage{
-			ID:              message.Id,
-			Sender:          s.getHeaderValue(msg.Payload.Headers, "From"),
-			Subject:         s.getHeaderValue(msg.Payload.Headers, "Subject"),
-			Body:            body,
-			Headers:         s.extractHeaders(msg.Payload.Headers),
-			Labels:          msg.LabelIds,
-			UnsubscribeLink: s.extractUnsubscribeLink(msg.Payload.Headers, body),
-			ReceivedAt:      time.Unix(msg.InternalDate/1000, 0),
-		}
-
-		gmailMessages = append(gmailMessages, gmailMsg)
+		gmailMessages = append(gmailMessages, *s.toGmailMessage(msg))
 	}
 
 	return gmailMessages, nil
 }
 
 func (s *GmailService) ListAllMessages(ctx context.Context, token *oauth2.Token) ([]entities.GmailMessage, error) {
-	client := s.oauthConfig.Client(ctx, token)
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := s.newService(ctx, token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gmail service: %w", err)
 	}
@@ -92,19 +92,7 @@ func (s *GmailService) ListAllMessages(ctx context.Context, token *oauth2.Token)
 				continue // Skip this email if we can't fetch it
 			}
 
-			body := s.extractBody(msg.Payload)
-			gmailMsg := entities.GmailMessage{
-				ID:              message.Id,
-				Sender:          s.getHeaderValue(msg.Payload.Headers, "From"),
-				Subject:         s.getHeaderValue(msg.Payload.Headers, "Subject"),
-				Body:            body,
-				Headers:         s.extractHeaders(msg.Payload.Headers),
-				Labels:          msg.LabelIds,
-				UnsubscribeLink: s.extractUnsubscribeLink(msg.Payload.Headers, body),
-				ReceivedAt:      time.Unix(msg.InternalDate/1000, 0),
-			}
-
-			allMessages = append(allMessages, gmailMsg)
+			allMessages = append(allMessages, *s.toGmailMessage(msg))
 		}
 
 		// Check if there are more pages
@@ -118,8 +106,7 @@ func (s *GmailService) ListAllMessages(ctx context.Context, token *oauth2.Token)
 }
 
 func (s *GmailService) GetMessage(ctx context.Context, token *oauth2.Token, messageID string) (*entities.GmailMessage, error) {
-	client := s.oauthConfig.Client(ctx, token)
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := s.newService(ctx, token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gmail service: %w", err)
 	}
@@ -129,24 +116,11 @@ func (s *GmailService) GetMessage(ctx context.Context, token *oauth2.Token, mess
 		return nil, fmt.Errorf("failed to get message: %w", err)
 	}
 
-	body := s.extractBody(msg.Payload)
-	gmailMsg := &entities.GmailMessage{
-		ID:              messageID,
-		Sender:          s.getHeaderValue(msg.Payload.Headers, "From"),
-		Subject:         s.getHeaderValue(msg.Payload.Headers, "Subject"),
-		Body:            body,
-		Headers:         s.extractHeaders(msg.Payload.Headers),
-		Labels:          msg.LabelIds,
-		UnsubscribeLink: s.extractUnsubscribeLink(msg.Payload.Headers, body),
-		ReceivedAt:      time.Unix(msg.InternalDate/1000, 0),
-	}
-
-	return gmailMsg, nil
+	return s.toGmailMessage(msg), nil
 }
 
 func (s *GmailService) ArchiveMessage(ctx context.Context, token *oauth2.Token, messageID string) error {
-	client := s.oauthConfig.Client(ctx, token)
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := s.newService(ctx, token)
 	if err != nil {
 		return fmt.Errorf("failed to create Gmail service: %w", err)
 	}
@@ -164,9 +138,73 @@ func (s *GmailService) ArchiveMessage(ctx context.Context, token *oauth2.Token,
 	return nil
 }
 
+func (s *GmailService) TrashMessage(ctx context.Context, token *oauth2.Token, messageID string) error {
+	srv, err := s.newService(ctx, token)
+	if err != nil {
+		return fmt.Errorf("failed to create Gmail service: %w", err)
+	}
+
+	_, err = srv.Users.Messages.Trash("me", messageID).Do()
+	if err != nil {
+		return fmt.Errorf("failed to trash message: %w", err)
+	}
+
+	return nil
+}
+
+func (s *GmailService) CreateDraft(ctx context.Context, token *oauth2.Token, msg *entities.OutgoingMessage) (string, error) {
+	srv, err := s.newService(ctx, token)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Gmail service: %w", err)
+	}
+
+	raw, err := buildRawMessage(msg)
+	if err != nil {
+		return "", err
+	}
+
+	draft := &gmail.Draft{
+		Message: &gmail.Message{
+			Raw:      raw,
+			ThreadId: msg.ThreadID,
+		},
+	}
+
+	created, err := srv.Users.Drafts.Create("me", draft).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to create draft: %w", err)
+	}
+
+	return created.Id, nil
+}
+
+// SendMessage sends a message from the account and returns the Gmail ID of the sent message
+func (s *GmailService) SendMessage(ctx context.Context, token *oauth2.Token, msg *entities.OutgoingMessage) (string, error) {
+	srv, err := s.newService(ctx, token)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Gmail service: %w", err)
+	}
+
+	raw, err := buildRawMessage(msg)
+	if err != nil {
+		return "", err
+	}
+
+	message := &gmail.Message{
+		Raw:      raw,
+		ThreadId: msg.ThreadID,
+	}
+
+	sent, err := srv.Users.Messages.Send("me", message).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return sent.Id, nil
+}
+
 func (s *GmailService) GetCurrentHistoryId(ctx context.Context, token *oauth2.Token) (string, error) {
-	client := s.oauthConfig.Client(ctx, token)
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := s.newService(ctx, token)
 	if err != nil {
 		return "", fmt.Errorf("failed to create Gmail service: %w", err)
 	}
@@ -181,8 +219,7 @@ func (s *GmailService) GetCurrentHistoryId(ctx context.Context, token *oauth2.To
 }
 
 func (s *GmailService) ListHistory(ctx context.Context, token *oauth2.Token, startHistoryId string) ([]entities.GmailMessage, string, error) {
-	client := s.oauthConfig.Client(ctx, token)
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := s.newService(ctx, token)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to create Gmail service: %w", err)
 	}
@@ -215,19 +252,7 @@ func (s *GmailService) ListHistory(ctx context.Context, token *oauth2.Token, sta
 					continue // Skip if we can't fetch it
 				}
 
-				body := s.extractBody(fullMsg.Payload)
-				gmailMsg := entities.GmailMessage{
-					ID:              msg.Message.Id,
-					Sender:          s.getHeaderValue(fullMsg.Payload.Headers, "From"),
-					Subject:         s.getHeaderValue(fullMsg.Payload.Headers, "Subject"),
-					Body:            body,
-					Headers:         s.extractHeaders(fullMsg.Payload.Headers),
-					Labels:          fullMsg.LabelIds,
-					UnsubscribeLink: s.extractUnsubscribeLink(fullMsg.Payload.Headers, body),
-					ReceivedAt:      time.Unix(fullMsg.InternalDate/1000, 0),
-				}
-
-				newMessages = append(newMessages, gmailMsg)
+				newMessages = append(newMessages, *s.toGmailMessage(fullMsg))
 			}
 		}
 
@@ -242,19 +267,7 @@ func (s *GmailService) ListHistory(ctx context.Context, token *oauth2.Token, sta
 					continue // Skip if we can't fetch it
 				}
 
-				body := s.extractBody(fullMsg.Payload)
-				gmailMsg := entities.GmailMessage{
-					ID:              labelChange.Message.Id,
-					Sender:          s.getHeaderValue(fullMsg.Payload.Headers, "From"),
-					Subject:         s.getHeaderValue(fullMsg.Payload.Headers, "Subject"),
-					Body:            body,
-					Headers:         s.extractHeaders(fullMsg.Payload.Headers),
-					Labels:          fullMsg.LabelIds,
-					UnsubscribeLink: s.extractUnsubscribeLink(fullMsg.Payload.Headers, body),
-					ReceivedAt:      time.Unix(fullMsg.InternalDate/1000, 0),
-				}
-
-				newMessages = append(newMessages, gmailMsg)
+				newMessages = append(newMessages, *s.toGmailMessage(fullMsg))
 			}
 		}
 
@@ -268,19 +281,7 @@ func (s *GmailService) ListHistory(ctx context.Context, token *oauth2.Token, sta
 					continue // Skip if we can't fetch it
 				}
 
-				body := s.extractBody(fullMsg.Payload)
-				gmailMsg := entities.GmailMessage{
-					ID:              labelChange.Message.Id,
-					Sender:          s.getHeaderValue(fullMsg.Payload.Headers, "From"),
-					Subject:         s.getHeaderValue(fullMsg.Payload.Headers, "Subject"),
-					Body:            body,
-					Headers:         s.extractHeaders(fullMsg.Payload.Headers),
-					Labels:          fullMsg.LabelIds,
-					UnsubscribeLink: s.extractUnsubscribeLink(fullMsg.Payload.Headers, body),
-					ReceivedAt:      time.Unix(fullMsg.InternalDate/1000, 0),
-				}
-
-				newMessages = append(newMessages, gmailMsg)
+				newMessages = append(newMessages, *s.toGmailMessage(fullMsg))
 			}
 		}
 	}
@@ -295,8 +296,7 @@ func (s *GmailService) GetLabelNames(ctx context.Context, token *oauth2.Token, l
 		return make(map[string]string), nil
 	}
 
-	client := s.oauthConfig.Client(ctx, token)
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := s.newService(ctx, token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gmail service: %w", err)
 	}
@@ -327,6 +327,34 @@ func (s *GmailService) GetLabelNames(ctx context.Context, token *oauth2.Token, l
 	return result, nil
 }
 
+// toGmailMessage converts a full Gmail API message into the domain representation
+func (s *GmailService) toGmailMessage(msg *gmail.Message) *entities.GmailMessage {
+	content := mimeparse.Extract(toMIMEPart(msg.Payload))
+	// The HTML is stored as sent, so risk scoring and tracker detection see the
+	// markup; it is sanitized whenever it is shown
+	body := content.HTML
+	if body == "" {
+		body = content.Text
+	}
+
+	return &entities.GmailMessage{
+		ID:              msg.Id,
+		ThreadID:        msg.ThreadId,
+		Sender:          mimeparse.DecodeHeader(s.getHeaderValue(msg.Payload.Headers, "From")),
+		Subject:         mimeparse.DecodeHeader(s.getHeaderValue(msg.Payload.Headers, "Subject")),
+		Body:            body,
+		TextBody:        content.Text,
+		TrackerCount:    mimeparse.CountTrackers(content.HTML),
+		Attachments:     toAttachments(content.Attachments),
+		Headers:         s.extractHeaders(msg.Payload.Headers),
+		Labels:          msg.LabelIds,
+		UnsubscribeLink: s.extractUnsubscribeLink(msg.Payload.Headers, body),
+		Snippet:         msg.Snippet,
+		SizeEstimate:    msg.SizeEstimate,
+		ReceivedAt:      time.Unix(msg.InternalDate/1000, 0),
+	}
+}
+
 func (s *GmailService) getHeaderValue(headers []*gmail.MessagePartHeader, name string) string {
 	for _, header := range headers {
 		if header.Name == name {
@@ -380,64 +408,128 @@ func (s *GmailService) extractUnsubscribeLink(headers []*gmail.MessagePartHeader
 				}
 			}
 		}
+
+		// Senders without a web link often still accept unsubscribe requests by email
+		if start := strings.Index(strings.ToLower(listUnsubscribe), "mailto:"); start != -1 {
+			remaining := listUnsubscribe[start:]
+			end := strings.IndexAny(remaining, ">,")
+			if end == -1 {
+				end = len(remaining)
+			}
+			link := strings.TrimSpace(remaining[:end])
+			return &link
+		}
 	}
 
 	return nil
 }
 
-func (s *GmailService) extractBody(part *gmail.MessagePart) string {
-	if part.Body != nil && part.Body.Data != "" {
-		data, err := base64.URLEncoding.DecodeString(part.Body.Data)
-		if err == nil {
-			return string(data)
+// toMIMEPart converts the MIME tree Gmail returns for a message. Gmail has already
+// undone the transfer encodings and leaves attachment content out of the message.
+func toMIMEPart(part *gmail.MessagePart) *mimeparse.Part {
+	header := make(textproto.MIMEHeader)
+	for _, h := range part.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	if header.Get("Content-Type") == "" && part.MimeType != "" {
+		header.Set("Content-Type", part.MimeType)
+	}
+
+	mimePart := &mimeparse.Part{Header: header, PartID: part.PartId}
+	if part.Body != nil {
+		mimePart.AttachmentID = part.Body.AttachmentId
+		mimePart.Size = part.Body.Size
+		if part.Body.Data != "" {
+			if data, err := io.ReadAll(bodyReader(part.Body.Data)); err == nil {
+				mimePart.Body = data
+			}
 		}
 	}
+	for _, child := range part.Parts {
+		mimePart.Parts = append(mimePart.Parts, toMIMEPart(child))
+	}
 
-	var htmlContent, plainContent string
+	return mimePart
+}
 
-	// Look for both HTML and plain text parts
-	for _, subPart := range part.Parts {
-		if subPart.Body != nil && subPart.Body.Data != "" {
-			data, err := base64.URLEncoding.DecodeString(subPart.Body.Data)
-			if err == nil {
-				switch subPart.MimeType {
-				case "text/html":
-					htmlContent = string(data)
-				case "text/plain":
-					plainContent = string(data)
-				}
-			}
+func toAttachments(parsed []mimeparse.Attachment) []entities.Attachment {
+	attachments := make([]entities.Attachment, 0, len(parsed))
+	for _, attachment := range parsed {
+		attachments = append(attachments, entities.Attachment{
+			Filename:          attachment.Filename,
+			MimeType:          attachment.MimeType,
+			Size:              attachment.Size,
+			ContentID:         attachment.ContentID,
+			Inline:            attachment.Inline,
+			GmailAttachmentID: attachment.AttachmentID,
+			GmailPartID:       attachment.PartID,
+			Data:              attachment.Data,
+		})
+	}
+	return attachments
+}
+
+// bodyReader decodes the base64url data of a message part, with or without padding
+func bodyReader(data string) io.Reader {
+	return base64.NewDecoder(base64.RawURLEncoding, strings.NewReader(strings.TrimRight(data, "=")))
+}
+
+// findPart returns the part of a message with the given part ID
+func findPart(part *gmail.MessagePart, partID string) *gmail.MessagePart {
+	if part == nil {
+		return nil
+	}
+	if part.PartId == partID {
+		return part
+	}
+	for _, child := range part.Parts {
+		if found := findPart(child, partID); found != nil {
+			return found
 		}
+	}
+	return nil
+}
 
-		// Recursively check nested parts
-		if len(subPart.Parts) > 0 {
-			nestedBody := s.extractBody(subPart)
-			if nestedBody != "" {
-				// If we find HTML in nested parts, prefer it
-				if subPart.MimeType == "multipart/alternative" || subPart.MimeType == "multipart/mixed" {
-					if htmlContent == "" && (nestedBody != plainContent) {
-						// Assume nested content is HTML if it's different from plain text
-						htmlContent = nestedBody
-					}
-				}
-			}
+// GetAttachment fetches the content of an attachment. Attachment IDs can go stale,
+// so when fetching by ID fails the part is looked up in the message again.
+func (s *GmailService) GetAttachment(ctx context.Context, token *oauth2.Token, messageID string, attachment *entities.Attachment) (io.ReadCloser, error) {
+	srv, err := s.newService(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gmail service: %w", err)
+	}
+
+	if attachment.GmailAttachmentID != "" {
+		body, err := srv.Users.Messages.Attachments.Get("me", messageID, attachment.GmailAttachmentID).Do()
+		if err == nil {
+			return io.NopCloser(bodyReader(body.Data)), nil
+		}
+		if attachment.GmailPartID == "" {
+			return nil, fmt.Errorf("failed to get attachment: %w", err)
 		}
 	}
 
-	// Prefer HTML content over plain text
-	if htmlContent != "" {
-		return htmlContent
+	msg, err := srv.Users.Messages.Get("me", messageID).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
 	}
-	if plainContent != "" {
-		return plainContent
+
+	part := findPart(msg.Payload, attachment.GmailPartID)
+	if part == nil || part.Body == nil {
+		return nil, fmt.Errorf("attachment part %q not found in message", attachment.GmailPartID)
+	}
+	if part.Body.Data == "" && part.Body.AttachmentId != "" {
+		body, err := srv.Users.Messages.Attachments.Get("me", messageID, part.Body.AttachmentId).Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get attachment: %w", err)
+		}
+		return io.NopCloser(bodyReader(body.Data)), nil
 	}
 
-	return ""
+	return io.NopCloser(bodyReader(part.Body.Data)), nil
 }
 
 func (s *GmailService) DeleteLabel(ctx context.Context, token *oauth2.Token, labelName string) error {
-	client := s.oauthConfig.Client(ctx, token)
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := s.newService(ctx, token)
 	if err != nil {
 		return fmt.Errorf("failed to create Gmail service: %w", err)
 	}
@@ -474,8 +566,7 @@ func (s *GmailService) DeleteLabel(ctx context.Context, token *oauth2.Token, lab
 }
 
 func (s *GmailService) CreateLabel(ctx context.Context, token *oauth2.Token, labelName string) error {
-	client := s.oauthConfig.Client(ctx, token)
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := s.newService(ctx, token)
 	if err != nil {
 		return fmt.Errorf("failed to create Gmail service: %w", err)
 	}
@@ -509,8 +600,7 @@ func (s *GmailService) CreateLabel(ctx context.Context, token *oauth2.Token, lab
 }
 
 func (s *GmailService) GetAllLabels(ctx context.Context, token *oauth2.Token) ([]entities.GmailLabel, error) {
-	client := s.oauthConfig.Client(ctx, token)
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	srv, err := s.newService(ctx, token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gmail service: %w", err)
 	}
@@ -531,3 +621,43 @@ func (s *GmailService) GetAllLabels(ctx context.Context, token *oauth2.Token) ([
 
 	return gmailLabels, nil
 }
+
+// buildRawMessage renders an outgoing message as a base64url-encoded RFC 2822 message,
+// adding the threading headers when it is a reply. The recipient must be a valid
+// address, so a crafted header of the original message can't inject headers.
+func buildRawMessage(msg *entities.OutgoingMessage) (string, error) {
+	var raw strings.Builder
+
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return "", fmt.Errorf("invalid recipient address %q: %w", msg.To, err)
+	}
+
+	raw.WriteString("To: " + to.String() + "\r\n")
+	raw.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	if inReplyTo := singleLine(msg.InReplyTo); inReplyTo != "" {
+		raw.WriteString("In-Reply-To: " + inReplyTo + "\r\n")
+	}
+	if references := singleLine(msg.References); references != "" {
+		raw.WriteString("References: " + references + "\r\n")
+	}
+	raw.WriteString("MIME-Version: 1.0\r\n")
+	raw.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n")
+	raw.WriteString("Content-Transfer-Encoding: base64\r\n")
+	raw.WriteString("\r\n")
+
+	// Wrap the encoded body at 76 characters per RFC 2045
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		raw.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	raw.WriteString(encoded + "\r\n")
+
+	return base64.URLEncoding.EncodeToString([]byte(raw.String())), nil
+}
+
+// singleLine joins a header value copied from another message onto one line
+func singleLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
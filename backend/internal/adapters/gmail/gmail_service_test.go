@@ -0,0 +1,344 @@
+package gmail
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/gmail/v1"
+)
+
+// newFakeGmailService returns a GmailService talking to a local stand-in for the Gmail API
+func newFakeGmailService(t *testing.T, handler http.Handler) *GmailService {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	service := NewGmailService(&oauth2.Config{}, nil)
+	service.endpoint = server.URL + "/"
+	return service
+}
+
+func testToken() *oauth2.Token {
+	return &oauth2.Token{AccessToken: "test-token"}
+}
+
+func TestGmailService_CreateDraft(t *testing.T) {
+	var received gmail.Draft
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /gmail/v1/users/me/drafts", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("Expected bearer token, got '%s'", r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatalf("Failed to decode draft: %v", err)
+		}
+		json.NewEncoder(w).Encode(gmail.Draft{Id: "draft-1", Message: &gmail.Message{Id: "msg-2", ThreadId: received.Message.ThreadId}})
+	})
+
+	service := newFakeGmailService(t, mux)
+	draftID, err := service.CreateDraft(context.Background(), testToken(), &entities.OutgoingMessage{
+		To:         "Jane Doe <jane@example.com>",
+		Subject:    "Re: Lunch on Friday?",
+		Body:       "Friday works for me.",
+		ThreadID:   "thread-1",
+		InReplyTo:  "<orig@example.com>",
+		References: "<first@example.com> <orig@example.com>",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if draftID != "draft-1" {
+		t.Errorf("Expected draft ID to be 'draft-1', got '%s'", draftID)
+	}
+
+	if received.Message.ThreadId != "thread-1" {
+		t.Errorf("Expected thread ID to be 'thread-1', got '%s'", received.Message.ThreadId)
+	}
+
+	raw, err := base64.URLEncoding.DecodeString(received.Message.Raw)
+	if err != nil {
+		t.Fatalf("Failed to decode raw message: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("Failed to parse raw message: %v", err)
+	}
+
+	expectedHeaders := map[string]string{
+		"To":          `"Jane Doe" <jane@example.com>`,
+		"Subject":     "Re: Lunch on Friday?",
+		"In-Reply-To": "<orig@example.com>",
+		"References":  "<first@example.com> <orig@example.com>",
+	}
+	for name, expected := range expectedHeaders {
+		if got := msg.Header.Get(name); got != expected {
+			t.Errorf("Expected %s to be '%s', got '%s'", name, expected, got)
+		}
+	}
+
+	encodedBody, _ := io.ReadAll(msg.Body)
+	body, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encodedBody), "\r\n", ""))
+	if err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if string(body) != "Friday works for me." {
+		t.Errorf("Expected body to be 'Friday works for me.', got '%s'", string(body))
+	}
+}
+
+func TestGmailService_GetMessage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /gmail/v1/users/me/messages/msg-1", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(gmail.Message{
+			Id:           "msg-1",
+			ThreadId:     "thread-1",
+			InternalDate: 1700000000000,
+			Payload: &gmail.MessagePart{
+				MimeType: "text/plain",
+				Headers: []*gmail.MessagePartHeader{
+					{Name: "From", Value: "Jane Doe <jane@example.com>"},
+					{Name: "Subject", Value: "Lunch on Friday?"},
+					{Name: "Message-Id", Value: "<orig@example.com>"},
+				},
+				Body: &gmail.MessagePartBody{Data: base64.URLEncoding.EncodeToString([]byte("Are you free?"))},
+			},
+		})
+	})
+
+	service := newFakeGmailService(t, mux)
+	msg, err := service.GetMessage(context.Background(), testToken(), "msg-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if msg.ThreadID != "thread-1" {
+		t.Errorf("Expected thread ID to be 'thread-1', got '%s'", msg.ThreadID)
+	}
+
+	if msg.Header("Message-ID") != "<orig@example.com>" {
+		t.Errorf("Expected Message-ID to be '<orig@example.com>', got '%s'", msg.Header("Message-ID"))
+	}
+
+	if msg.Body != "Are you free?" {
+		t.Errorf("Expected body to be 'Are you free?', got '%s'", msg.Body)
+	}
+}
+
+func TestGmailService_GetMessageMultipart(t *testing.T) {
+	encode := func(data []byte) string { return base64.URLEncoding.EncodeToString(data) }
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /gmail/v1/users/me/messages/msg-1", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(gmail.Message{
+			Id: "msg-1",
+			Payload: &gmail.MessagePart{
+				MimeType: "multipart/mixed",
+				Headers: []*gmail.MessagePartHeader{
+					{Name: "From", Value: "=?iso-8859-1?Q?Ren=E9?= <rene@example.fr>"},
+					{Name: "Content-Type", Value: `multipart/mixed; boundary="b1"`},
+				},
+				Parts: []*gmail.MessagePart{
+					{
+						MimeType: "multipart/alternative",
+						Headers:  []*gmail.MessagePartHeader{{Name: "Content-Type", Value: `multipart/alternative; boundary="b2"`}},
+						Parts: []*gmail.MessagePart{
+							{
+								MimeType: "text/plain",
+								// Gmail has already undone the quoted-printable encoding
+								Headers: []*gmail.MessagePartHeader{
+									{Name: "Content-Type", Value: "text/plain; charset=iso-8859-1"},
+									{Name: "Content-Transfer-Encoding", Value: "quoted-printable"},
+								},
+								Body: &gmail.MessagePartBody{Data: encode([]byte("Caf\xe9 =E9 ouvert"))},
+							},
+							{
+								MimeType: "text/html",
+								Headers:  []*gmail.MessagePartHeader{{Name: "Content-Type", Value: "text/html; charset=iso-8859-1"}},
+								Body:     &gmail.MessagePartBody{Data: encode([]byte("<p onclick=\"x()\">Caf\xe9 ouvert</p><script>x()</script>"))},
+							},
+						},
+					},
+					{
+						PartId:   "1",
+						MimeType: "application/pdf",
+						Filename: "menu.pdf",
+						Headers: []*gmail.MessagePartHeader{
+							{Name: "Content-Type", Value: `application/pdf; name="menu.pdf"`},
+							{Name: "Content-Disposition", Value: `attachment; filename="menu.pdf"`},
+						},
+						Body: &gmail.MessagePartBody{AttachmentId: "att-1", Size: 5120},
+					},
+				},
+			},
+		})
+	})
+
+	service := newFakeGmailService(t, mux)
+	msg, err := service.GetMessage(context.Background(), testToken(), "msg-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if msg.Sender != "René <rene@example.fr>" {
+		t.Errorf("Expected the sender to be decoded, got '%s'", msg.Sender)
+	}
+	if msg.Body != `<p onclick="x()">Café ouvert</p><script>x()</script>` {
+		t.Errorf("Expected the HTML body as sent, got '%s'", msg.Body)
+	}
+	if msg.TextBody != "Café =E9 ouvert" {
+		t.Errorf("Expected the text body to be decoded once, got '%s'", msg.TextBody)
+	}
+
+	if len(msg.Attachments) != 1 {
+		t.Fatalf("Expected 1 attachment, got %d", len(msg.Attachments))
+	}
+	attachment := msg.Attachments[0]
+	if attachment.Filename != "menu.pdf" || attachment.MimeType != "application/pdf" || attachment.Size != 5120 {
+		t.Errorf("Unexpected attachment metadata %+v", attachment)
+	}
+	if attachment.GmailAttachmentID != "att-1" || attachment.GmailPartID != "1" {
+		t.Errorf("Expected the attachment to be located by 'att-1' and part '1', got '%s' and '%s'",
+			attachment.GmailAttachmentID, attachment.GmailPartID)
+	}
+}
+
+func TestGmailService_GetAttachment(t *testing.T) {
+	content := []byte("%PDF-1.4 menu")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /gmail/v1/users/me/messages/msg-1/attachments/att-1", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(gmail.MessagePartBody{Data: base64.URLEncoding.EncodeToString(content), Size: int64(len(content))})
+	})
+	// Stale attachment IDs are rejected; the message then gives the current one
+	mux.HandleFunc("GET /gmail/v1/users/me/messages/msg-1/attachments/stale", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": {"code": 400, "message": "Invalid attachment token"}}`, http.StatusBadRequest)
+	})
+	mux.HandleFunc("GET /gmail/v1/users/me/messages/msg-1", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(gmail.Message{
+			Id: "msg-1",
+			Payload: &gmail.MessagePart{
+				MimeType: "multipart/mixed",
+				Parts: []*gmail.MessagePart{
+					{PartId: "0", MimeType: "text/plain", Body: &gmail.MessagePartBody{Data: "SGk"}},
+					{PartId: "1", MimeType: "application/pdf", Body: &gmail.MessagePartBody{AttachmentId: "att-1"}},
+					// Small parts carry their content inline
+					{PartId: "2", MimeType: "text/plain", Body: &gmail.MessagePartBody{Data: base64.RawURLEncoding.EncodeToString([]byte("notes?"))}},
+				},
+			},
+		})
+	})
+
+	service := newFakeGmailService(t, mux)
+	tests := []struct {
+		name       string
+		attachment entities.Attachment
+		expected   string
+	}{
+		{"by attachment ID", entities.Attachment{GmailAttachmentID: "att-1", GmailPartID: "1"}, string(content)},
+		{"stale attachment ID", entities.Attachment{GmailAttachmentID: "stale", GmailPartID: "1"}, string(content)},
+		{"inline part", entities.Attachment{GmailPartID: "2"}, "notes?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := service.GetAttachment(context.Background(), testToken(), "msg-1", &tt.attachment)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer reader.Close()
+
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Failed to read attachment: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Expected content '%s', got '%s'", tt.expected, data)
+			}
+		})
+	}
+
+	_, err := service.GetAttachment(context.Background(), testToken(), "msg-1", &entities.Attachment{GmailPartID: "7"})
+	if err == nil {
+		t.Error("Expected an error for a part that isn't in the message")
+	}
+}
+
+func TestGmailService_SendMessage(t *testing.T) {
+	var received gmail.Message
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /gmail/v1/users/me/messages/send", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatalf("Failed to decode message: %v", err)
+		}
+		json.NewEncoder(w).Encode(gmail.Message{Id: "sent-1", ThreadId: "thread-9"})
+	})
+
+	service := newFakeGmailService(t, mux)
+	messageID, err := service.SendMessage(context.Background(), testToken(), &entities.OutgoingMessage{
+		To:      "leave@lists.example.com",
+		Subject: "unsubscribe",
+		Body:    "Please remove me",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if messageID != "sent-1" {
+		t.Errorf("Expected message ID to be 'sent-1', got '%s'", messageID)
+	}
+
+	raw, err := base64.URLEncoding.DecodeString(received.Raw)
+	if err != nil {
+		t.Fatalf("Failed to decode raw message: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("Failed to parse raw message: %v", err)
+	}
+
+	if got := msg.Header.Get("To"); got != "<leave@lists.example.com>" {
+		t.Errorf("Expected To to be '<leave@lists.example.com>', got '%s'", got)
+	}
+	if got := msg.Header.Get("Subject"); got != "unsubscribe" {
+		t.Errorf("Expected Subject to be 'unsubscribe', got '%s'", got)
+	}
+}
+
+func TestBuildRawMessage_RejectsHeaderInjection(t *testing.T) {
+	_, err := buildRawMessage(&entities.OutgoingMessage{To: "jane@example.com\r\nBcc: all@example.com", Subject: "Re: Hi"})
+	if err == nil {
+		t.Error("Expected a recipient with a line break to be rejected")
+	}
+
+	encoded, err := buildRawMessage(&entities.OutgoingMessage{
+		To:         "jane@example.com",
+		Subject:    "Re: Hi",
+		InReplyTo:  "<orig@example.com>\r\nBcc: all@example.com",
+		References: "<first@example.com>\r\n <orig@example.com>",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	raw, _ := base64.URLEncoding.DecodeString(encoded)
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("Failed to parse raw message: %v", err)
+	}
+	if got := msg.Header.Get("Bcc"); got != "" {
+		t.Errorf("Expected no injected Bcc header, got '%s'", got)
+	}
+	if got := msg.Header.Get("References"); got != "<first@example.com> <orig@example.com>" {
+		t.Errorf("Expected References on one line, got '%s'", got)
+	}
+}
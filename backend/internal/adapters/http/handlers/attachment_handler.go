@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/email-sorting-app/internal/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentHandler struct {
+	attachmentUsecase *usecases.AttachmentUsecase
+}
+
+func NewAttachmentHandler(attachmentUsecase *usecases.AttachmentUsecase) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentUsecase: attachmentUsecase,
+	}
+}
+
+func (h *AttachmentHandler) GetEmailAttachments(c *gin.Context) {
+	emailID, err := strconv.ParseInt(c.Param("emailId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email ID"})
+		return
+	}
+
+	attachments, err := h.attachmentUsecase.GetEmailAttachments(c.Request.Context(), emailID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attachments": attachments})
+}
+
+// DownloadAttachment streams an attachment from Gmail as a download
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	emailID, err := strconv.ParseInt(c.Param("emailId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email ID"})
+		return
+	}
+
+	attachmentID, err := strconv.ParseInt(c.Param("attId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	attachment, err := h.attachmentUsecase.GetAttachment(c.Request.Context(), emailID, attachmentID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	reader, err := h.attachmentUsecase.OpenAttachment(c.Request.Context(), attachment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	contentType := attachment.MimeType
+	if _, _, err := mime.ParseMediaType(contentType); err != nil {
+		contentType = "application/octet-stream"
+	}
+
+	// Attachments come from anyone who can send mail; never let them render on our origin
+	extraHeaders := map[string]string{
+		"Content-Disposition":     mime.FormatMediaType("attachment", map[string]string{"filename": attachment.DownloadName()}),
+		"Content-Security-Policy": "sandbox",
+		"X-Content-Type-Options":  "nosniff",
+	}
+
+	c.DataFromReader(http.StatusOK, -1, contentType, reader, extraHeaders)
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -11,11 +13,14 @@ import (
 
 type EmailHandler struct {
 	emailUsecase *usecases.EmailUsecase
+	// publicBaseURL is the address clients reach the server at, for the image URLs in email bodies
+	publicBaseURL string
 }
 
-func NewEmailHandler(emailUsecase *usecases.EmailUsecase) *EmailHandler {
+func NewEmailHandler(emailUsecase *usecases.EmailUsecase, publicBaseURL string) *EmailHandler {
 	return &EmailHandler{
-		emailUsecase: emailUsecase,
+		emailUsecase:  emailUsecase,
+		publicBaseURL: publicBaseURL,
 	}
 }
 
@@ -38,6 +43,27 @@ func (h *EmailHandler) GetAccountEmails(c *gin.Context) {
 		pageSize = 20
 	}
 
+	// A cursor parameter, empty for the first page, switches to keyset pagination
+	if token, ok := c.GetQuery("cursor"); ok {
+		cursor, err := usecases.DecodeEmailCursor(token)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+
+		emailPage, err := h.emailUsecase.GetAccountEmailsCursor(c.Request.Context(), accountID, repositories.CursorParams{
+			After: cursor,
+			Limit: pageSize,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, emailPage)
+		return
+	}
+
 	params := repositories.PaginationParams{
 		Page:     page,
 		PageSize: pageSize,
@@ -93,6 +119,27 @@ func (h *EmailHandler) GetEmailsByCategory(c *gin.Context) {
 		pageSize = 20
 	}
 
+	// A cursor parameter, empty for the first page, switches to keyset pagination
+	if token, ok := c.GetQuery("cursor"); ok {
+		cursor, err := usecases.DecodeEmailCursor(token)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+
+		emailPage, err := h.emailUsecase.GetEmailsByCategoryCursor(c.Request.Context(), accountID, categoryID, repositories.CursorParams{
+			After: cursor,
+			Limit: pageSize,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, emailPage)
+		return
+	}
+
 	params := repositories.PaginationParams{
 		Page:     page,
 		PageSize: pageSize,
@@ -107,6 +154,46 @@ func (h *EmailHandler) GetEmailsByCategory(c *gin.Context) {
 	c.JSON(http.StatusOK, paginatedEmails)
 }
 
+// SearchEmails searches an account's emails; see usecases.ParseSearchQuery for the query language
+func (h *EmailHandler) SearchEmails(c *gin.Context) {
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	query, err := usecases.ParseSearchQuery(c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	// Validate pagination parameters
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	params := repositories.PaginationParams{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	results, err := h.emailUsecase.SearchEmails(c.Request.Context(), accountID, query, params)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
 func (h *EmailHandler) GenerateEmailSummary(c *gin.Context) {
 	emailID, err := strconv.ParseInt(c.Param("emailId"), 10, 64)
 	if err != nil {
@@ -139,43 +226,69 @@ func (h *EmailHandler) CategorizeEmailWithAI(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Email categorized successfully"})
 }
 
-func (h *EmailHandler) UnsubscribeFromEmail(c *gin.Context) {
+type DraftReplyRequest struct {
+	Instruction string `json:"instruction"`
+	Tone        string `json:"tone"`
+}
+
+func (h *EmailHandler) DraftReply(c *gin.Context) {
 	emailID, err := strconv.ParseInt(c.Param("emailId"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email ID"})
 		return
 	}
 
-	result, err := h.emailUsecase.UnsubscribeFromEmail(c.Request.Context(), emailID)
+	// Both fields are optional, so the body may be left out
+	var req DraftReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	draft, err := h.emailUsecase.DraftReply(c.Request.Context(), emailID, req.Instruction, req.Tone)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
-}
-
-type BulkUnsubscribeRequest struct {
-	EmailIDs []int64 `json:"email_ids" binding:"required"`
+	c.JSON(http.StatusCreated, draft)
 }
 
-func (h *EmailHandler) BulkUnsubscribe(c *gin.Context) {
-	var req BulkUnsubscribeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// GetEmail returns an email with a body that is safe to render. Remote images are
+// blocked unless images=proxy asks for them to be loaded through the image proxy.
+func (h *EmailHandler) GetEmail(c *gin.Context) {
+	emailID, err := strconv.ParseInt(c.Param("emailId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email ID"})
 		return
 	}
 
-	if len(req.EmailIDs) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No email IDs provided"})
+	detail, err := h.emailUsecase.GetEmailDetail(c.Request.Context(), emailID, usecases.EmailDetailOptions{
+		BaseURL:     h.publicBaseURL,
+		ProxyImages: c.Query("images") == "proxy",
+	})
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	results, err := h.emailUsecase.BulkUnsubscribe(c.Request.Context(), req.EmailIDs)
+	c.JSON(http.StatusOK, detail)
+}
+
+// ProxyImage serves a remote image of an email, for URLs signed by GetEmail
+func (h *EmailHandler) ProxyImage(c *gin.Context) {
+	image, err := h.emailUsecase.FetchRemoteImage(c.Request.Context(), c.Query("url"), c.Query("sig"))
+	if errors.Is(err, usecases.ErrInvalidImageSignature) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"results": results})
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Header("Content-Security-Policy", "sandbox")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, image.ContentType, image.Data)
 }
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	apperrors "github.com/email-sorting-app/pkg/errors"
+)
+
+// errorStatus returns the HTTP status for an error from a usecase: 404 for missing
+// resources, 400 for invalid input and 500 for everything else
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, apperrors.ErrInvalidInput):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/email-sorting-app/internal/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+type ExtractionHandler struct {
+	extractionUsecase *usecases.ExtractionUsecase
+}
+
+type UpdateActionItemRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+func NewExtractionHandler(extractionUsecase *usecases.ExtractionUsecase) *ExtractionHandler {
+	return &ExtractionHandler{
+		extractionUsecase: extractionUsecase,
+	}
+}
+
+func (h *ExtractionHandler) ExtractFromEmail(c *gin.Context) {
+	emailID, err := strconv.ParseInt(c.Param("emailId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email ID"})
+		return
+	}
+
+	extraction, err := h.extractionUsecase.ExtractFromEmail(c.Request.Context(), emailID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, extraction)
+}
+
+func (h *ExtractionHandler) GetEmailExtraction(c *gin.Context) {
+	emailID, err := strconv.ParseInt(c.Param("emailId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email ID"})
+		return
+	}
+
+	extraction, err := h.extractionUsecase.GetEmailExtraction(c.Request.Context(), emailID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, extraction)
+}
+
+func (h *ExtractionHandler) GetOpenActionItems(c *gin.Context) {
+	items, err := h.extractionUsecase.GetOpenActionItems(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"action_items": items})
+}
+
+func (h *ExtractionHandler) UpdateActionItemStatus(c *gin.Context) {
+	actionItemID, err := strconv.ParseInt(c.Param("actionItemId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action item ID"})
+		return
+	}
+
+	var req UpdateActionItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.extractionUsecase.UpdateActionItemStatus(c.Request.Context(), actionItemID, req.Status)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Action item updated successfully"})
+}
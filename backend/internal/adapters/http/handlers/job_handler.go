@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/email-sorting-app/internal/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+type JobHandler struct {
+	jobUsecase *usecases.JobUsecase
+}
+
+func NewJobHandler(jobUsecase *usecases.JobUsecase) *JobHandler {
+	return &JobHandler{
+		jobUsecase: jobUsecase,
+	}
+}
+
+type BulkUnsubscribeRequest struct {
+	EmailIDs []int64 `json:"email_ids" binding:"required"`
+}
+
+func (h *JobHandler) BulkUnsubscribe(c *gin.Context) {
+	var req BulkUnsubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.EmailIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No email IDs provided"})
+		return
+	}
+
+	job, err := h.jobUsecase.SubmitBulkUnsubscribe(c.Request.Context(), req.EmailIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (h *JobHandler) GetJob(c *gin.Context) {
+	jobID, err := strconv.ParseInt(c.Param("jobId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := h.jobUsecase.GetJob(c.Request.Context(), jobID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	jobID, err := strconv.ParseInt(c.Param("jobId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := h.jobUsecase.CancelJob(c.Request.Context(), jobID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
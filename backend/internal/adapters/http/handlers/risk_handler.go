@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/email-sorting-app/internal/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+type RiskHandler struct {
+	riskUsecase *usecases.RiskUsecase
+}
+
+func NewRiskHandler(riskUsecase *usecases.RiskUsecase) *RiskHandler {
+	return &RiskHandler{
+		riskUsecase: riskUsecase,
+	}
+}
+
+func (h *RiskHandler) AssessEmail(c *gin.Context) {
+	emailID, err := strconv.ParseInt(c.Param("emailId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email ID"})
+		return
+	}
+
+	assessment, err := h.riskUsecase.AssessEmail(c.Request.Context(), emailID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, assessment)
+}
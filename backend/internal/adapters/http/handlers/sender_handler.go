@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/email-sorting-app/internal/domain/repositories"
+	"github.com/email-sorting-app/internal/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+type SenderHandler struct {
+	senderUsecase *usecases.SenderUsecase
+}
+
+func NewSenderHandler(senderUsecase *usecases.SenderUsecase) *SenderHandler {
+	return &SenderHandler{
+		senderUsecase: senderUsecase,
+	}
+}
+
+// paginationParams reads the page and page_size query parameters
+func paginationParams(c *gin.Context) repositories.PaginationParams {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	// Validate pagination parameters
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	return repositories.PaginationParams{
+		Page:     page,
+		PageSize: pageSize,
+	}
+}
+
+func (h *SenderHandler) GetAccountSenders(c *gin.Context) {
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	senders, err := h.senderUsecase.GetAccountSenders(c.Request.Context(), accountID, paginationParams(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, senders)
+}
+
+func (h *SenderHandler) GetSender(c *gin.Context) {
+	senderID, err := strconv.ParseInt(c.Param("senderId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sender ID"})
+		return
+	}
+
+	sender, err := h.senderUsecase.GetSender(c.Request.Context(), senderID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sender)
+}
+
+func (h *SenderHandler) GetSenderEmails(c *gin.Context) {
+	senderID, err := strconv.ParseInt(c.Param("senderId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sender ID"})
+		return
+	}
+
+	emails, err := h.senderUsecase.GetSenderEmails(c.Request.Context(), senderID, paginationParams(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, emails)
+}
+
+func (h *SenderHandler) ArchiveAll(c *gin.Context) {
+	senderID, err := strconv.ParseInt(c.Param("senderId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sender ID"})
+		return
+	}
+
+	job, err := h.senderUsecase.ArchiveAll(c.Request.Context(), senderID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+type CategorizeSenderRequest struct {
+	CategoryID int64 `json:"category_id" binding:"required"`
+}
+
+func (h *SenderHandler) CategorizeAll(c *gin.Context) {
+	senderID, err := strconv.ParseInt(c.Param("senderId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sender ID"})
+		return
+	}
+
+	var req CategorizeSenderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.senderUsecase.CategorizeAll(c.Request.Context(), senderID, req.CategoryID)
+	if err != nil {
+		if errors.Is(err, usecases.ErrCategoryNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *SenderHandler) Unsubscribe(c *gin.Context) {
+	senderID, err := strconv.ParseInt(c.Param("senderId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sender ID"})
+		return
+	}
+
+	job, err := h.senderUsecase.Unsubscribe(c.Request.Context(), senderID)
+	if err != nil {
+		if errors.Is(err, usecases.ErrNoUnsubscribeLink) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
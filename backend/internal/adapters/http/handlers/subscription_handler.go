@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/email-sorting-app/internal/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+type SubscriptionHandler struct {
+	subscriptionUsecase *usecases.SubscriptionUsecase
+}
+
+func NewSubscriptionHandler(subscriptionUsecase *usecases.SubscriptionUsecase) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		subscriptionUsecase: subscriptionUsecase,
+	}
+}
+
+func (h *SubscriptionHandler) UnsubscribeFromEmail(c *gin.Context) {
+	emailID, err := strconv.ParseInt(c.Param("emailId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email ID"})
+		return
+	}
+
+	// A dry run only plans the unsubscribe; the plan is executed once approved
+	if dryRun, _ := strconv.ParseBool(c.Query("dry_run")); dryRun {
+		plan, result, err := h.subscriptionUsecase.PreviewUnsubscribe(c.Request.Context(), emailID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"dry_run": true, "plan": plan, "result": result})
+		return
+	}
+
+	result, err := h.subscriptionUsecase.UnsubscribeFromEmail(c.Request.Context(), emailID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *SubscriptionHandler) GetAccountSubscriptions(c *gin.Context) {
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	subscriptions, err := h.subscriptionUsecase.GetSubscriptions(c.Request.Context(), accountID, c.Query("status"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subscriptions})
+}
+
+func (h *SubscriptionHandler) GetSenderAttempts(c *gin.Context) {
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	attempts, err := h.subscriptionUsecase.GetSenderAttempts(c.Request.Context(), accountID, c.Param("sender"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attempts": attempts})
+}
+
+func (h *SubscriptionHandler) GetAttemptArtifact(c *gin.Context) {
+	attemptID, err := strconv.ParseInt(c.Param("attemptId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attempt ID"})
+		return
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid artifact index"})
+		return
+	}
+
+	key, reader, err := h.subscriptionUsecase.GetAttemptArtifact(c.Request.Context(), attemptID, index)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	serveArtifact(c, key, reader)
+}
+
+func (h *SubscriptionHandler) GetPlan(c *gin.Context) {
+	planID, err := strconv.ParseInt(c.Param("planId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plan ID"})
+		return
+	}
+
+	plan, err := h.subscriptionUsecase.GetPlan(c.Request.Context(), planID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *SubscriptionHandler) GetPlanScreenshot(c *gin.Context) {
+	planID, err := strconv.ParseInt(c.Param("planId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plan ID"})
+		return
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid screenshot index"})
+		return
+	}
+
+	key, reader, err := h.subscriptionUsecase.GetPlanScreenshot(c.Request.Context(), planID, index)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	serveArtifact(c, key, reader)
+}
+
+func (h *SubscriptionHandler) ExecutePlan(c *gin.Context) {
+	planID, err := strconv.ParseInt(c.Param("planId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plan ID"})
+		return
+	}
+
+	result, err := h.subscriptionUsecase.ExecutePlan(c.Request.Context(), planID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// serveArtifact streams a stored artifact with a content type based on its key
+func serveArtifact(c *gin.Context, key string, reader io.Reader) {
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// Captured pages are untrusted; never let their HTML run on our origin
+	extraHeaders := map[string]string{
+		"Content-Security-Policy": "sandbox",
+		"X-Content-Type-Options":  "nosniff",
+	}
+	if path.Ext(key) == ".html" {
+		extraHeaders["Content-Disposition"] = `attachment; filename="` + path.Base(key) + `"`
+	}
+
+	c.DataFromReader(http.StatusOK, -1, contentType, reader, extraHeaders)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/email-sorting-app/internal/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+type SummaryHandler struct {
+	summaryUsecase *usecases.SummaryUsecase
+}
+
+type SummarySettingsRequest struct {
+	AutoSummarize *bool `json:"auto_summarize" binding:"required"`
+}
+
+func NewSummaryHandler(summaryUsecase *usecases.SummaryUsecase) *SummaryHandler {
+	return &SummaryHandler{
+		summaryUsecase: summaryUsecase,
+	}
+}
+
+func (h *SummaryHandler) UpdateAccountSettings(c *gin.Context) {
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	var req SummarySettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.summaryUsecase.UpdateAccountSettings(c.Request.Context(), accountID, *req.AutoSummarize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Summary settings updated successfully"})
+}
+
+func (h *SummaryHandler) UpdateCategorySettings(c *gin.Context) {
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	categoryID, err := strconv.ParseInt(c.Param("categoryId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var req SummarySettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.summaryUsecase.UpdateCategorySettings(c.Request.Context(), accountID, categoryID, *req.AutoSummarize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Summary settings updated successfully"})
+}
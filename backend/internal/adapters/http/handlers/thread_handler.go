@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/email-sorting-app/internal/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+type ThreadHandler struct {
+	threadUsecase *usecases.ThreadUsecase
+}
+
+func NewThreadHandler(threadUsecase *usecases.ThreadUsecase) *ThreadHandler {
+	return &ThreadHandler{
+		threadUsecase: threadUsecase,
+	}
+}
+
+func (h *ThreadHandler) GetThread(c *gin.Context) {
+	threadID, err := strconv.ParseInt(c.Param("threadId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid thread ID"})
+		return
+	}
+
+	thread, err := h.threadUsecase.GetThread(c.Request.Context(), threadID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, thread)
+}
+
+func (h *ThreadHandler) SummarizeThread(c *gin.Context) {
+	threadID, err := strconv.ParseInt(c.Param("threadId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid thread ID"})
+		return
+	}
+
+	thread, err := h.threadUsecase.SummarizeThread(c.Request.Context(), threadID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, thread)
+}
@@ -11,6 +11,14 @@ func SetupRoutes(
 	accountHandler *handlers.AccountHandler,
 	categoryHandler *handlers.CategoryHandler,
 	emailHandler *handlers.EmailHandler,
+	summaryHandler *handlers.SummaryHandler,
+	extractionHandler *handlers.ExtractionHandler,
+	threadHandler *handlers.ThreadHandler,
+	riskHandler *handlers.RiskHandler,
+	subscriptionHandler *handlers.SubscriptionHandler,
+	jobHandler *handlers.JobHandler,
+	senderHandler *handlers.SenderHandler,
+	attachmentHandler *handlers.AttachmentHandler,
 ) *gin.Engine {
 	router := gin.Default()
 
@@ -33,12 +41,59 @@ func SetupRoutes(
 
 	// Email routes
 	router.GET("/accounts/:id/emails", emailHandler.GetAccountEmails)
+	router.GET("/accounts/:id/emails/search", emailHandler.SearchEmails)
 	router.POST("/accounts/:id/emails/refresh", emailHandler.RefreshAccountEmails)
 	router.GET("/accounts/:id/categories/:categoryId/emails", emailHandler.GetEmailsByCategory)
+	router.GET("/emails/:emailId", emailHandler.GetEmail)
 	router.POST("/emails/:emailId/summary", emailHandler.GenerateEmailSummary)
 	router.POST("/emails/:emailId/categorize", emailHandler.CategorizeEmailWithAI)
-	router.POST("/emails/:emailId/unsubscribe", emailHandler.UnsubscribeFromEmail)
-	router.POST("/emails/bulk-unsubscribe", emailHandler.BulkUnsubscribe)
+	router.POST("/emails/:emailId/draft-reply", emailHandler.DraftReply)
+
+	// Attachment routes
+	router.GET("/emails/:emailId/attachments", attachmentHandler.GetEmailAttachments)
+	router.GET("/emails/:emailId/attachments/:attId", attachmentHandler.DownloadAttachment)
+
+	// Remote images of emails, loaded on behalf of clients
+	router.GET("/image-proxy", emailHandler.ProxyImage)
+
+	// Summary routes
+	router.PUT("/accounts/:id/summary-settings", summaryHandler.UpdateAccountSettings)
+	router.PUT("/accounts/:id/categories/:categoryId/summary-settings", summaryHandler.UpdateCategorySettings)
+
+	// Extraction routes
+	router.POST("/emails/:emailId/extract", extractionHandler.ExtractFromEmail)
+	router.GET("/emails/:emailId/extraction", extractionHandler.GetEmailExtraction)
+	router.GET("/action-items", extractionHandler.GetOpenActionItems)
+	router.PUT("/action-items/:actionItemId/status", extractionHandler.UpdateActionItemStatus)
+
+	// Thread routes
+	router.GET("/threads/:threadId", threadHandler.GetThread)
+	router.POST("/threads/:threadId/summary", threadHandler.SummarizeThread)
+
+	// Subscription routes
+	router.POST("/emails/:emailId/unsubscribe", subscriptionHandler.UnsubscribeFromEmail)
+	router.GET("/accounts/:id/subscriptions", subscriptionHandler.GetAccountSubscriptions)
+	router.GET("/accounts/:id/subscriptions/:sender/attempts", subscriptionHandler.GetSenderAttempts)
+	router.GET("/unsubscribe-attempts/:attemptId/artifacts/:index", subscriptionHandler.GetAttemptArtifact)
+	router.GET("/unsubscribe-plans/:planId", subscriptionHandler.GetPlan)
+	router.GET("/unsubscribe-plans/:planId/screenshots/:index", subscriptionHandler.GetPlanScreenshot)
+	router.POST("/unsubscribe-plans/:planId/execute", subscriptionHandler.ExecutePlan)
+
+	// Bulk unsubscribe job routes
+	router.POST("/emails/bulk-unsubscribe", jobHandler.BulkUnsubscribe)
+	router.GET("/jobs/:jobId", jobHandler.GetJob)
+	router.POST("/jobs/:jobId/cancel", jobHandler.CancelJob)
+
+	// Sender routes
+	router.GET("/accounts/:id/senders", senderHandler.GetAccountSenders)
+	router.GET("/senders/:senderId", senderHandler.GetSender)
+	router.GET("/senders/:senderId/emails", senderHandler.GetSenderEmails)
+	router.POST("/senders/:senderId/archive", senderHandler.ArchiveAll)
+	router.POST("/senders/:senderId/categorize", senderHandler.CategorizeAll)
+	router.POST("/senders/:senderId/unsubscribe", senderHandler.Unsubscribe)
+
+	// Risk routes
+	router.POST("/emails/:emailId/risk-assessment", riskHandler.AssessEmail)
 
 	return router
 }
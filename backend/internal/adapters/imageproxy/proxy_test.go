@@ -0,0 +1,63 @@
+package imageproxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProxy_SignAndVerify(t *testing.T) {
+	proxy := NewProxy([]byte("secret"), nil)
+	signature := proxy.Sign("https://cdn.shop.example/banner.png")
+
+	if !proxy.Verify("https://cdn.shop.example/banner.png", signature) {
+		t.Error("Expected the signature to be accepted")
+	}
+	if proxy.Verify("https://cdn.shop.example/other.png", signature) {
+		t.Error("Expected the signature of another URL to be rejected")
+	}
+	if NewProxy([]byte("other"), nil).Verify("https://cdn.shop.example/banner.png", signature) {
+		t.Error("Expected a signature made with another secret to be rejected")
+	}
+}
+
+func TestProxy_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/banner.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("png"))
+		case "/logo.svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Write([]byte("<svg onload=\"x()\"/>"))
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<p>Hi</p>"))
+		case "/huge.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(strings.Repeat("x", maxImageSize+1)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	proxy := NewProxy(nil, server.Client())
+	ctx := context.Background()
+
+	image, err := proxy.Fetch(ctx, server.URL+"/banner.png")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if image.ContentType != "image/png" || string(image.Data) != "png" {
+		t.Errorf("Expected the PNG, got %q %q", image.ContentType, image.Data)
+	}
+
+	for _, src := range []string{server.URL + "/logo.svg", server.URL + "/page", server.URL + "/huge.png", server.URL + "/missing.png", "file:///etc/passwd"} {
+		if _, err := proxy.Fetch(ctx, src); err == nil {
+			t.Errorf("Expected %s to be refused", src)
+		}
+	}
+}
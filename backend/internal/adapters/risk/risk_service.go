@@ -0,0 +1,389 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	"golang.org/x/net/publicsuffix"
+)
+
+// aiMinScore is the heuristic score above which the AI is asked for a second opinion
+const aiMinScore = 0.3
+
+// Brands commonly impersonated in phishing, keyed by the registrable domain they send from
+var protectedDomains = map[string]string{
+	"paypal.com":          "paypal",
+	"google.com":          "google",
+	"apple.com":           "apple",
+	"icloud.com":          "icloud",
+	"microsoft.com":       "microsoft",
+	"office.com":          "office",
+	"outlook.com":         "outlook",
+	"amazon.com":          "amazon",
+	"netflix.com":         "netflix",
+	"facebook.com":        "facebook",
+	"instagram.com":       "instagram",
+	"linkedin.com":        "linkedin",
+	"dropbox.com":         "dropbox",
+	"docusign.com":        "docusign",
+	"chase.com":           "chase",
+	"wellsfargo.com":      "wellsfargo",
+	"bankofamerica.com":   "bankofamerica",
+	"fedex.com":           "fedex",
+	"ups.com":             "ups",
+	"dhl.com":             "dhl",
+	"usps.com":            "usps",
+	"coinbase.com":        "coinbase",
+	"americanexpress.com": "americanexpress",
+}
+
+var urlShorteners = map[string]bool{
+	"bit.ly": true, "tinyurl.com": true, "t.co": true, "goo.gl": true,
+	"is.gd": true, "ow.ly": true, "buff.ly": true, "rebrand.ly": true, "cutt.ly": true,
+}
+
+var urgencyPhrases = []string{
+	"verify your account",
+	"confirm your identity",
+	"account has been suspended",
+	"account will be suspended",
+	"unusual sign-in activity",
+	"urgent action required",
+	"your password expires",
+	"update your payment",
+	"you have won",
+	"claim your prize",
+	"wire transfer",
+	"gift card",
+}
+
+var (
+	anchorRegex = regexp.MustCompile(`(?is)<a\s[^>]*href=["']([^"']+)["'][^>]*>(.*?)</a>`)
+	urlRegex    = regexp.MustCompile(`https?://[^\s"'<>]+`)
+	tagRegex    = regexp.MustCompile(`<[^>]+>`)
+	authResult  = regexp.MustCompile(`(?i)\b(spf|dkim|dmarc)=([a-z]+)`)
+)
+
+type RiskService struct {
+	aiService repositories.AIService
+}
+
+// NewRiskService creates a risk scorer. When aiService is nil only the header and
+// link heuristics are used.
+func NewRiskService(aiService repositories.AIService) *RiskService {
+	return &RiskService{
+		aiService: aiService,
+	}
+}
+
+func (s *RiskService) AssessEmail(ctx context.Context, email *entities.Email, budget repositories.AIBudget) (*entities.RiskAssessment, error) {
+	signals := newSignals()
+	analyzeAuthentication(email.Headers, signals)
+	analyzeSender(email.Sender, headerValue(email.Headers, "Reply-To"), signals)
+	analyzeLinks(email.Body, signals)
+	analyzeContent(email.Subject, email.Body, signals)
+
+	assessment := &entities.RiskAssessment{
+		EmailID: email.ID,
+		Score:   signals.score(),
+		Reasons: signals.reasons,
+	}
+
+	// Only ask the AI about emails the heuristics already find questionable
+	if s.aiService != nil && assessment.Score >= aiMinScore && s.reserve(ctx, budget) {
+		judgement, err := s.aiService.AssessPhishingRisk(ctx, email, signals.reasons)
+		if err != nil {
+			fmt.Printf("Warning: AI risk assessment failed for email %d: %v\n", email.ID, err)
+		} else {
+			aiScore := math.Max(0, math.Min(1, judgement.Score))
+			assessment.AIScore = &aiScore
+			assessment.AIReason = judgement.Reason
+			assessment.Score = roundScore((assessment.Score + aiScore) / 2)
+		}
+	}
+
+	return assessment, nil
+}
+
+// reserve reports whether the budget allows another AI call. Without the AI the
+// heuristic score stands, so a budget that can't be checked is treated as spent.
+func (s *RiskService) reserve(ctx context.Context, budget repositories.AIBudget) bool {
+	if budget == nil {
+		return true
+	}
+
+	reserved, err := budget.Reserve(ctx)
+	if err != nil {
+		fmt.Printf("Warning: failed to check AI budget: %v\n", err)
+		return false
+	}
+	return reserved
+}
+
+// signals accumulates weighted risk indicators; each reason is counted once
+type signals struct {
+	total   float64
+	reasons []string
+	seen    map[string]bool
+}
+
+func newSignals() *signals {
+	return &signals{reasons: []string{}, seen: make(map[string]bool)}
+}
+
+func (s *signals) add(weight float64, reason string) {
+	if s.seen[reason] {
+		return
+	}
+	s.seen[reason] = true
+	s.total += weight
+	s.reasons = append(s.reasons, reason)
+}
+
+func (s *signals) score() float64 {
+	return roundScore(math.Min(1, s.total))
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
+
+func analyzeAuthentication(headers map[string]string, s *signals) {
+	results := make(map[string]string)
+	for _, match := range authResult.FindAllStringSubmatch(headerValue(headers, "Authentication-Results"), -1) {
+		mechanism := strings.ToLower(match[1])
+		if _, exists := results[mechanism]; !exists {
+			results[mechanism] = strings.ToLower(match[2])
+		}
+	}
+
+	// Fall back to Received-SPF when Authentication-Results has no SPF verdict
+	if _, exists := results["spf"]; !exists {
+		if receivedSPF := strings.Fields(strings.ToLower(headerValue(headers, "Received-SPF"))); len(receivedSPF) > 0 {
+			results["spf"] = receivedSPF[0]
+		}
+	}
+
+	if len(results) == 0 {
+		s.add(0.1, "No sender authentication results")
+		return
+	}
+
+	switch results["dmarc"] {
+	case "fail":
+		s.add(0.4, "DMARC check failed")
+	}
+	switch results["spf"] {
+	case "fail":
+		s.add(0.25, "SPF check failed")
+	case "softfail":
+		s.add(0.1, "SPF check soft-failed")
+	}
+	switch results["dkim"] {
+	case "fail":
+		s.add(0.2, "DKIM signature failed")
+	}
+}
+
+func analyzeSender(from, replyTo string, s *signals) {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return
+	}
+
+	fromDomain := registrableDomain(domainOf(fromAddr.Address))
+	if fromDomain == "" {
+		return
+	}
+
+	if brand, ok := lookalikeOf(fromDomain); ok {
+		s.add(0.45, fmt.Sprintf("Sender domain %s imitates %s", fromDomain, brand))
+	}
+
+	// A display name naming a brand the sending domain doesn't belong to
+	nameWords := strings.FieldsFunc(strings.ToLower(fromAddr.Name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	joinedName := strings.Join(nameWords, "")
+	for domain, brand := range protectedDomains {
+		if fromDomain == domain || len(brand) < 4 {
+			continue
+		}
+		if joinedName == brand || slices.Contains(nameWords, brand) {
+			s.add(0.25, fmt.Sprintf("Display name mentions %s but sender domain is %s", brand, fromDomain))
+			break
+		}
+	}
+
+	if replyTo == "" {
+		return
+	}
+	if replyToAddr, err := mail.ParseAddress(replyTo); err == nil {
+		replyToDomain := registrableDomain(domainOf(replyToAddr.Address))
+		if replyToDomain != "" && replyToDomain != fromDomain {
+			s.add(0.15, fmt.Sprintf("Reply-To domain %s differs from sender domain %s", replyToDomain, fromDomain))
+		}
+	}
+}
+
+func analyzeLinks(body string, s *signals) {
+	// Links whose visible text shows a different domain than they point to
+	for _, match := range anchorRegex.FindAllStringSubmatch(body, -1) {
+		hrefHost := hostOf(match[1])
+		textURL := urlRegex.FindString(tagRegex.ReplaceAllString(match[2], ""))
+		if hrefHost == "" || textURL == "" {
+			continue
+		}
+		textHost := hostOf(textURL)
+		if textHost != "" && registrableDomain(textHost) != registrableDomain(hrefHost) {
+			s.add(0.35, fmt.Sprintf("Link text shows %s but points to %s", textHost, hrefHost))
+		}
+	}
+
+	for _, link := range urlRegex.FindAllString(body, -1) {
+		parsed, err := url.Parse(link)
+		if err != nil || parsed.Hostname() == "" {
+			continue
+		}
+		host := strings.ToLower(parsed.Hostname())
+
+		if parsed.User != nil {
+			s.add(0.25, "Link hides its destination behind an @ sign")
+		}
+		if net.ParseIP(host) != nil {
+			s.add(0.25, fmt.Sprintf("Link points to a raw IP address (%s)", host))
+			continue
+		}
+		if strings.HasPrefix(host, "xn--") || strings.Contains(host, ".xn--") {
+			s.add(0.2, fmt.Sprintf("Link uses a punycode domain (%s)", host))
+		}
+		if urlShorteners[host] {
+			s.add(0.1, fmt.Sprintf("Link uses a URL shortener (%s)", host))
+		}
+		if brand, ok := lookalikeOf(registrableDomain(host)); ok {
+			s.add(0.35, fmt.Sprintf("Link domain %s imitates %s", host, brand))
+		}
+	}
+}
+
+func analyzeContent(subject, body string, s *signals) {
+	text := strings.ToLower(subject + " " + tagRegex.ReplaceAllString(body, " "))
+	matches := 0
+	for _, phrase := range urgencyPhrases {
+		if strings.Contains(text, phrase) {
+			matches++
+		}
+	}
+	if matches > 0 {
+		s.add(math.Min(0.2, 0.1*float64(matches)), "Uses urgent or pressuring language")
+	}
+}
+
+// lookalikeOf reports whether a registrable domain resembles, but is not, a protected brand domain
+func lookalikeOf(domain string) (string, bool) {
+	if domain == "" {
+		return "", false
+	}
+	if _, protected := protectedDomains[domain]; protected {
+		return "", false
+	}
+
+	label := strings.SplitN(domain, ".", 2)[0]
+	normalizer := strings.NewReplacer("0", "o", "1", "l", "3", "e", "5", "s", "rn", "m", "vv", "w")
+
+	// Check the whole label and each hyphenated part, e.g. paypal-secure.com or secure-paypa1.net
+	candidates := []string{label}
+	if strings.Contains(label, "-") {
+		candidates = append(candidates, strings.Split(label, "-")...)
+	}
+
+	for protectedDomain, brand := range protectedDomains {
+		if len(brand) < 4 {
+			continue // Short brands like "ups" produce too many false positives
+		}
+		for _, candidate := range candidates {
+			if len(candidate) < 4 {
+				continue
+			}
+			// The brand's own name under another suffix (amazon.de) is not a lookalike
+			if candidate == label && label == brand {
+				continue
+			}
+			if candidate == brand || normalizer.Replace(candidate) == brand || levenshtein(candidate, brand) == 1 {
+				return protectedDomain, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+func registrableDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return ""
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+func domainOf(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at == -1 {
+		return ""
+	}
+	return address[at+1:]
+}
+
+func hostOf(link string) string {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,160 @@
+package risk
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+func assess(t *testing.T, email *entities.Email) *entities.RiskAssessment {
+	t.Helper()
+	assessment, err := NewRiskService(nil).AssessEmail(context.Background(), email, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return assessment
+}
+
+func hasReason(assessment *entities.RiskAssessment, prefix string) bool {
+	return slices.ContainsFunc(assessment.Reasons, func(reason string) bool {
+		return strings.HasPrefix(reason, prefix)
+	})
+}
+
+func TestAssessEmail_LegitimateEmail(t *testing.T) {
+	assessment := assess(t, &entities.Email{
+		Sender:  "PayPal <service@paypal.com>",
+		Subject: "Your receipt",
+		Body:    `<p>Thanks for your payment. <a href="https://www.paypal.com/activity">https://www.paypal.com/activity</a></p>`,
+		Headers: map[string]string{
+			"Authentication-Results": "mx.google.com; dkim=pass header.i=@paypal.com; spf=pass smtp.mailfrom=paypal.com; dmarc=pass (p=REJECT) header.from=paypal.com",
+		},
+	})
+
+	if assessment.Score != 0 {
+		t.Errorf("Expected score to be 0, got %v (reasons: %v)", assessment.Score, assessment.Reasons)
+	}
+}
+
+func TestAssessEmail_PhishingEmail(t *testing.T) {
+	assessment := assess(t, &entities.Email{
+		Sender:  "PayPal Security <alert@paypa1-secure.com>",
+		Subject: "Urgent action required: verify your account",
+		Body:    `<a href="http://192.168.4.20/login">https://www.paypal.com/signin</a>`,
+		Headers: map[string]string{
+			"Authentication-Results": "mx.google.com; spf=fail smtp.mailfrom=paypa1-secure.com; dmarc=fail header.from=paypa1-secure.com",
+			"Reply-To":               "collect@another-domain.net",
+		},
+	})
+
+	if assessment.Score != 1 {
+		t.Errorf("Expected score to be capped at 1, got %v", assessment.Score)
+	}
+
+	expected := []string{
+		"DMARC check failed",
+		"SPF check failed",
+		"Sender domain paypa1-secure.com imitates paypal.com",
+		"Display name mentions paypal",
+		"Reply-To domain another-domain.net differs",
+		"Link text shows www.paypal.com but points to 192.168.4.20",
+		"Link points to a raw IP address",
+		"Uses urgent or pressuring language",
+	}
+	for _, reason := range expected {
+		if !hasReason(assessment, reason) {
+			t.Errorf("Expected reason starting with '%s', got %v", reason, assessment.Reasons)
+		}
+	}
+}
+
+func TestAssessEmail_MissingAuthenticationFallsBackToReceivedSPF(t *testing.T) {
+	assessment := assess(t, &entities.Email{
+		Sender:  "Newsletter <news@example.org>",
+		Headers: map[string]string{"Received-SPF": "softfail (google.com: domain of transitioning news@example.org)"},
+	})
+
+	if !hasReason(assessment, "SPF check soft-failed") {
+		t.Errorf("Expected SPF soft-fail reason, got %v", assessment.Reasons)
+	}
+
+	assessment = assess(t, &entities.Email{Sender: "Newsletter <news@example.org>"})
+	if !hasReason(assessment, "No sender authentication results") {
+		t.Errorf("Expected missing authentication reason, got %v", assessment.Reasons)
+	}
+}
+
+type fakeAIService struct {
+	repositories.AIService
+	calls int
+}
+
+func (s *fakeAIService) AssessPhishingRisk(ctx context.Context, email *entities.Email, signals []string) (*entities.RiskJudgement, error) {
+	s.calls++
+	return &entities.RiskJudgement{Score: 0, Reason: "Looks like a real receipt"}, nil
+}
+
+type fakeBudget struct {
+	left int
+}
+
+func (b *fakeBudget) Reserve(ctx context.Context) (bool, error) {
+	if b.left == 0 {
+		return false, nil
+	}
+	b.left--
+	return true, nil
+}
+
+func TestAssessEmail_AISecondOpinionIsBudgeted(t *testing.T) {
+	aiService := &fakeAIService{}
+	service := NewRiskService(aiService)
+	email := &entities.Email{
+		Sender:  "Newsletter <news@example.org>",
+		Subject: "Claim your prize",
+		Headers: map[string]string{"Authentication-Results": "mx.google.com; spf=fail smtp.mailfrom=example.org"},
+	}
+	budget := &fakeBudget{left: 1}
+
+	for range 2 {
+		if _, err := service.AssessEmail(context.Background(), email, budget); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if aiService.calls != 1 {
+		t.Errorf("Expected the AI to be asked once within budget, got %d calls", aiService.calls)
+	}
+
+	// Assessments the user asks for aren't budgeted
+	assessment, err := service.AssessEmail(context.Background(), email, nil)
+	if err != nil || aiService.calls != 2 || assessment.AIScore == nil {
+		t.Errorf("Expected an unbudgeted AI opinion, got %v, %d calls and %v", assessment, aiService.calls, err)
+	}
+}
+
+func TestLookalikeOf(t *testing.T) {
+	tests := []struct {
+		domain    string
+		lookalike bool
+	}{
+		{"paypal.com", false},
+		{"paypa1.com", true},
+		{"arnazon.com", true},
+		{"netflix-billing.com", true},
+		{"micros0ft.net", true},
+		{"example.com", false},
+		{"amazon.de", false},
+		{"ups-tracking.com", false}, // short brands are ignored
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if _, got := lookalikeOf(tt.domain); got != tt.lookalike {
+			t.Errorf("Expected lookalikeOf(%q) to be %v, got %v", tt.domain, tt.lookalike, got)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package unsubscribe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// How often the pool checks that Chromium is still running
+const browserHealthCheckInterval = 30 * time.Second
+
+var errPoolClosed = errors.New("browser pool is shut down")
+
+// browserPool shares one Chromium process between unsubscribe attempts. Each attempt
+// gets its own browser context, so cookies and storage never leak between senders,
+// and at most maxContexts attempts run at once.
+type browserPool struct {
+	// launch starts Chromium; stop shuts down what launch started
+	launch func() (playwright.Browser, func() error, error)
+
+	slots  chan struct{}
+	active sync.WaitGroup
+
+	mu      sync.Mutex
+	browser playwright.Browser
+	stop    func() error
+	closed  bool
+}
+
+func newBrowserPool(maxContexts int, policy *URLPolicy) *browserPool {
+	return &browserPool{
+		launch: func() (playwright.Browser, func() error, error) {
+			return launchChromium(policy)
+		},
+		slots: make(chan struct{}, max(maxContexts, 1)),
+	}
+}
+
+// launchChromium starts Chromium behind a policy proxy, so every connection it makes
+// goes to an address the URL policy allows
+func launchChromium(policy *URLPolicy) (playwright.Browser, func() error, error) {
+	proxy, err := startPolicyProxy(policy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pw, err := playwright.Run()
+	if err != nil {
+		proxy.Close()
+		return nil, nil, fmt.Errorf("failed to run playwright: %w", err)
+	}
+
+	browser, err := pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
+		Headless: playwright.Bool(true),
+		// Chromium bypasses proxies for loopback unless told otherwise
+		Proxy: &playwright.Proxy{Server: proxy.URL(), Bypass: playwright.String("<-loopback>")},
+		Args: []string{
+			"--no-sandbox",
+			"--disable-setuid-sandbox",
+			"--disable-dev-shm-usage",
+			"--disable-accelerated-2d-canvas",
+			"--no-first-run",
+			"--no-zygote",
+			"--disable-gpu",
+		},
+	})
+	if err != nil {
+		pw.Stop()
+		proxy.Close()
+		return nil, nil, fmt.Errorf("failed to launch browser: %w", err)
+	}
+
+	stop := func() error {
+		defer proxy.Close()
+		return pw.Stop()
+	}
+	return browser, stop, nil
+}
+
+// acquire waits for a free slot and returns a fresh, isolated browser context.
+// The caller must call release when done with it.
+func (p *browserPool) acquire(ctx context.Context) (playwright.BrowserContext, func(), error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case p.slots <- struct{}{}:
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.slots
+		return nil, nil, errPoolClosed
+	}
+	// Registered under the lock so shutdown can't miss an attempt that is starting
+	p.active.Add(1)
+	browser, err := p.ensureBrowserLocked()
+	p.mu.Unlock()
+
+	if err == nil {
+		var browserContext playwright.BrowserContext
+		browserContext, err = browser.NewContext(playwright.BrowserNewContextOptions{
+			AcceptDownloads: playwright.Bool(false),
+		})
+		if err == nil {
+			release := func() {
+				if err := browserContext.Close(); err != nil {
+					fmt.Printf("Warning: failed to close browser context: %v\n", err)
+				}
+				p.active.Done()
+				<-p.slots
+			}
+			return browserContext, release, nil
+		}
+		err = fmt.Errorf("failed to create browser context: %w", err)
+	}
+
+	p.active.Done()
+	<-p.slots
+	return nil, nil, err
+}
+
+// ensureBrowserLocked returns a connected browser, relaunching Chromium if it crashed
+func (p *browserPool) ensureBrowserLocked() (playwright.Browser, error) {
+	if p.browser != nil && p.browser.IsConnected() {
+		return p.browser, nil
+	}
+
+	if p.browser != nil {
+		fmt.Printf("Warning: browser disconnected, relaunching\n")
+		p.stopLocked()
+	}
+
+	browser, stop, err := p.launch()
+	if err != nil {
+		return nil, err
+	}
+	p.browser = browser
+	p.stop = stop
+
+	return browser, nil
+}
+
+// checkHealth relaunches the browser if it is no longer connected. An idle pool
+// without a browser is left alone; it launches on the next attempt.
+func (p *browserPool) checkHealth() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || p.browser == nil || p.browser.IsConnected() {
+		return
+	}
+
+	if _, err := p.ensureBrowserLocked(); err != nil {
+		fmt.Printf("Warning: failed to relaunch browser: %v\n", err)
+	}
+}
+
+// Start runs periodic health checks until ctx is cancelled
+func (p *browserPool) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(browserHealthCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.checkHealth()
+			}
+		}
+	}()
+}
+
+// Shutdown stops handing out contexts, waits for running attempts to finish or
+// ctx to expire, then closes the browser
+func (p *browserPool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.active.Wait()
+		close(done)
+	}()
+
+	var waitErr error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		waitErr = fmt.Errorf("unsubscribe attempts still running at shutdown: %w", ctx.Err())
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.stopLocked(); err != nil {
+		return err
+	}
+
+	return waitErr
+}
+
+func (p *browserPool) stopLocked() error {
+	browser, stop := p.browser, p.stop
+	p.browser, p.stop = nil, nil
+
+	if browser != nil && browser.IsConnected() {
+		if err := browser.Close(); err != nil {
+			return fmt.Errorf("failed to close browser: %w", err)
+		}
+	}
+	if stop != nil {
+		if err := stop(); err != nil {
+			return fmt.Errorf("failed to stop playwright: %w", err)
+		}
+	}
+
+	return nil
+}
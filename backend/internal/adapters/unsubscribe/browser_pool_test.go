@@ -0,0 +1,167 @@
+package unsubscribe
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+type fakeBrowser struct {
+	playwright.Browser
+	mu        sync.Mutex
+	connected bool
+	contexts  int
+	closed    bool
+}
+
+func (b *fakeBrowser) IsConnected() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.connected
+}
+
+func (b *fakeBrowser) NewContext(options ...playwright.BrowserNewContextOptions) (playwright.BrowserContext, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.contexts++
+	return &fakeBrowserContext{}, nil
+}
+
+func (b *fakeBrowser) Close(options ...playwright.BrowserCloseOptions) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.connected = false
+	b.closed = true
+	return nil
+}
+
+func (b *fakeBrowser) crash() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.connected = false
+}
+
+type fakeBrowserContext struct {
+	playwright.BrowserContext
+}
+
+func (c *fakeBrowserContext) Close(options ...playwright.BrowserContextCloseOptions) error {
+	return nil
+}
+
+// newFakeBrowserPool returns a pool that launches fake browsers, and the browsers it launched
+func newFakeBrowserPool(maxContexts int) (*browserPool, *[]*fakeBrowser) {
+	var launched []*fakeBrowser
+	pool := newBrowserPool(maxContexts, nil)
+	pool.launch = func() (playwright.Browser, func() error, error) {
+		browser := &fakeBrowser{connected: true}
+		launched = append(launched, browser)
+		return browser, func() error { return nil }, nil
+	}
+	return pool, &launched
+}
+
+func TestBrowserPool_IsolatesAttemptsInOneBrowser(t *testing.T) {
+	pool, launched := newFakeBrowserPool(2)
+
+	first, releaseFirst, err := pool.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, releaseSecond, err := pool.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer releaseFirst()
+	defer releaseSecond()
+
+	if first == second {
+		t.Error("Expected each attempt to get its own context")
+	}
+	if len(*launched) != 1 || (*launched)[0].contexts != 2 {
+		t.Errorf("Expected one browser with two contexts, got %d browsers", len(*launched))
+	}
+}
+
+func TestBrowserPool_LimitsConcurrency(t *testing.T) {
+	pool, _ := newFakeBrowserPool(1)
+
+	_, release, err := pool.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := pool.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected second attempt to wait for a free slot, got %v", err)
+	}
+
+	release()
+	_, release, err = pool.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected slot to be free after release, got %v", err)
+	}
+	release()
+}
+
+func TestBrowserPool_RelaunchesCrashedBrowser(t *testing.T) {
+	pool, launched := newFakeBrowserPool(1)
+
+	_, release, err := pool.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	release()
+
+	(*launched)[0].crash()
+	pool.checkHealth()
+
+	if len(*launched) != 2 {
+		t.Fatalf("Expected health check to relaunch the browser, got %d launches", len(*launched))
+	}
+
+	if _, release, err = pool.acquire(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	release()
+	if (*launched)[1].contexts != 1 {
+		t.Error("Expected the relaunched browser to be used")
+	}
+}
+
+func TestBrowserPool_ShutdownWaitsForAttempts(t *testing.T) {
+	pool, launched := newFakeBrowserPool(2)
+
+	_, release, err := pool.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- pool.Shutdown(context.Background())
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Expected shutdown to wait for the running attempt")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release()
+	if err := <-done; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !(*launched)[0].closed {
+		t.Error("Expected the browser to be closed")
+	}
+
+	if _, _, err := pool.acquire(context.Background()); !errors.Is(err, errPoolClosed) {
+		t.Errorf("Expected no new attempts after shutdown, got %v", err)
+	}
+}
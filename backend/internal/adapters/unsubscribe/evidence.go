@@ -0,0 +1,102 @@
+package unsubscribe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/repositories"
+	"github.com/playwright-community/playwright-go"
+)
+
+// executedAction is an AI-planned action as it was carried out on the page
+type executedAction struct {
+	repositories.UnsubscribeAction
+	Error string `json:"error,omitempty"`
+}
+
+type evidenceFile struct {
+	name string
+	data []byte
+}
+
+// evidence collects what the browser saw and did during one unsubscribe attempt,
+// so failures and unclear results can be reviewed afterwards
+type evidence struct {
+	prefix  string
+	files   []evidenceFile
+	actions []executedAction
+}
+
+func newEvidence(emailID int64) *evidence {
+	return &evidence{
+		prefix: fmt.Sprintf("unsubscribe/email-%d/%d", emailID, time.Now().UnixNano()),
+	}
+}
+
+// screenshot captures the full page; failures are logged since evidence is best effort.
+// Like recordAction, it does nothing on a nil evidence.
+func (e *evidence) screenshot(page playwright.Page, name string) {
+	if e == nil {
+		return
+	}
+	data, err := page.Screenshot(playwright.PageScreenshotOptions{
+		FullPage: playwright.Bool(true),
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to capture %s screenshot: %v\n", name, err)
+		return
+	}
+	e.add(name+".png", data)
+}
+
+// snapshot captures the final screenshot and HTML of the page
+func (e *evidence) snapshot(page playwright.Page) {
+	e.screenshot(page, "after")
+
+	content, err := page.Content()
+	if err != nil {
+		fmt.Printf("Warning: failed to capture page HTML: %v\n", err)
+		return
+	}
+	e.add("page.html", []byte(content))
+}
+
+func (e *evidence) recordAction(action repositories.UnsubscribeAction, err error) {
+	if e == nil {
+		return
+	}
+	executed := executedAction{UnsubscribeAction: action}
+	if err != nil {
+		executed.Error = err.Error()
+	}
+	e.actions = append(e.actions, executed)
+}
+
+func (e *evidence) add(name string, data []byte) {
+	e.files = append(e.files, evidenceFile{name: name, data: data})
+}
+
+// save writes the collected evidence to the store and returns the keys that were saved
+func (e *evidence) save(ctx context.Context, store repositories.ArtifactStore) []string {
+	files := e.files
+	if len(e.actions) > 0 {
+		data, err := json.MarshalIndent(e.actions, "", "  ")
+		if err == nil {
+			files = append(files, evidenceFile{name: "actions.json", data: data})
+		}
+	}
+
+	var keys []string
+	for _, file := range files {
+		key := e.prefix + "/" + file.name
+		if err := store.Save(ctx, key, file.data); err != nil {
+			fmt.Printf("Warning: failed to save unsubscribe artifact %s: %v\n", key, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
@@ -0,0 +1,61 @@
+package unsubscribe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+type fakeArtifactStore struct {
+	saved map[string][]byte
+	fail  string
+}
+
+func (s *fakeArtifactStore) Save(ctx context.Context, key string, data []byte) error {
+	if s.fail != "" && strings.HasSuffix(key, s.fail) {
+		return errors.New("disk full")
+	}
+	s.saved[key] = data
+	return nil
+}
+
+func (s *fakeArtifactStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(string(s.saved[key]))), nil
+}
+
+func TestEvidenceSave(t *testing.T) {
+	record := newEvidence(7)
+	record.add("before.png", []byte("before"))
+	record.add("page.html", []byte("<html></html>"))
+	record.recordAction(repositories.UnsubscribeAction{Action: "click", Selector: "#unsubscribe"}, nil)
+	record.recordAction(repositories.UnsubscribeAction{Action: "submit"}, errors.New("no submit button found"))
+
+	store := &fakeArtifactStore{saved: make(map[string][]byte), fail: "page.html"}
+	keys := record.save(context.Background(), store)
+
+	// The failed HTML upload is left out, the rest is kept
+	if len(keys) != 2 || !strings.HasSuffix(keys[0], "/before.png") || !strings.HasSuffix(keys[1], "/actions.json") {
+		t.Fatalf("Unexpected keys: %v", keys)
+	}
+	if !strings.HasPrefix(keys[0], "unsubscribe/email-7/") {
+		t.Errorf("Expected keys to be grouped by email, got %s", keys[0])
+	}
+
+	var actions []executedAction
+	if err := json.Unmarshal(store.saved[keys[1]], &actions); err != nil {
+		t.Fatalf("Expected actions to be JSON, got %v", err)
+	}
+	if len(actions) != 2 || actions[0].Selector != "#unsubscribe" || actions[1].Error != "no submit button found" {
+		t.Errorf("Unexpected actions: %+v", actions)
+	}
+}
+
+func TestNilEvidenceIgnoresActions(t *testing.T) {
+	var record *evidence
+	record.recordAction(repositories.UnsubscribeAction{Action: "click"}, nil)
+}
@@ -0,0 +1,177 @@
+package unsubscribe
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+// Used when the mailto link doesn't specify a subject or body; list managers
+// commonly act on the word "unsubscribe" in either
+const (
+	defaultMailtoSubject = "unsubscribe"
+	defaultMailtoBody    = "unsubscribe"
+)
+
+// mailtoTarget is a parsed mailto: URL (RFC 6068)
+type mailtoTarget struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// parseMailto parses a mailto: link, including its to, subject and body fields.
+// Other header fields (cc, bcc, ...) are ignored.
+func parseMailto(link string) (*mailtoTarget, error) {
+	link = strings.TrimSpace(link)
+	if len(link) < len("mailto:") || !strings.EqualFold(link[:len("mailto:")], "mailto:") {
+		return nil, fmt.Errorf("not a mailto link: %s", link)
+	}
+	rest := link[len("mailto:"):]
+
+	addresses, query, _ := strings.Cut(rest, "?")
+	target := &mailtoTarget{}
+
+	addAddresses := func(list string) error {
+		decoded, err := url.PathUnescape(list)
+		if err != nil {
+			return fmt.Errorf("invalid mailto address: %w", err)
+		}
+		for _, address := range strings.Split(decoded, ",") {
+			address = strings.TrimSpace(address)
+			if address == "" {
+				continue
+			}
+			parsed, err := mail.ParseAddress(address)
+			if err != nil {
+				return fmt.Errorf("invalid mailto address %q: %w", address, err)
+			}
+			target.To = append(target.To, parsed.Address)
+		}
+		return nil
+	}
+
+	if err := addAddresses(addresses); err != nil {
+		return nil, err
+	}
+
+	// Decode fields by hand: unlike form encoding, '+' is a literal plus in mailto URLs
+	for _, field := range strings.Split(query, "&") {
+		name, value, _ := strings.Cut(field, "=")
+		if name == "" {
+			continue
+		}
+
+		switch strings.ToLower(name) {
+		case "to":
+			if err := addAddresses(value); err != nil {
+				return nil, err
+			}
+		case "subject", "body":
+			decoded, err := url.PathUnescape(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid mailto %s: %w", name, err)
+			}
+			if strings.EqualFold(name, "subject") {
+				target.Subject = decoded
+			} else {
+				target.Body = decoded
+			}
+		}
+	}
+
+	if len(target.To) == 0 {
+		return nil, fmt.Errorf("mailto link has no recipient: %s", link)
+	}
+
+	return target, nil
+}
+
+// mailtoStrategy unsubscribes by sending the request email from the user's own
+// account through Gmail
+type mailtoStrategy struct {
+	gmailService repositories.GmailService
+	accountRepo  repositories.AccountRepository
+}
+
+func newMailtoStrategy(gmailService repositories.GmailService, accountRepo repositories.AccountRepository) *mailtoStrategy {
+	return &mailtoStrategy{
+		gmailService: gmailService,
+		accountRepo:  accountRepo,
+	}
+}
+
+func (s *mailtoStrategy) Name() string {
+	return repositories.UnsubscribeStrategyMailto
+}
+
+func (s *mailtoStrategy) CanHandle(email *entities.Email) bool {
+	return s.gmailService != nil && len(mailtoLinks(email)) > 0
+}
+
+func (s *mailtoStrategy) Unsubscribe(ctx context.Context, email *entities.Email) (*repositories.UnsubscribeResult, error) {
+	target, err := parseMailto(mailtoLinks(email)[0])
+	if err != nil {
+		return &repositories.UnsubscribeResult{
+			Success:   false,
+			Message:   fmt.Sprintf("Invalid mailto unsubscribe link: %v", err),
+			ErrorType: "invalid_link",
+		}, nil
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, email.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("account not found: %w", err)
+	}
+
+	subject := target.Subject
+	if subject == "" {
+		subject = defaultMailtoSubject
+	}
+	body := target.Body
+	if body == "" {
+		body = defaultMailtoBody
+	}
+
+	messageID, err := s.gmailService.SendMessage(ctx, account.ToOAuth2Token(), &entities.OutgoingMessage{
+		To:      strings.Join(target.To, ", "),
+		Subject: subject,
+		Body:    body,
+	})
+	if err != nil {
+		return &repositories.UnsubscribeResult{
+			Success:   false,
+			Message:   fmt.Sprintf("Failed to send unsubscribe email: %v", err),
+			ErrorType: "send_error",
+		}, nil
+	}
+
+	return &repositories.UnsubscribeResult{
+		Success:       true,
+		Message:       fmt.Sprintf("Sent unsubscribe request to %s", strings.Join(target.To, ", ")),
+		SentMessageID: messageID,
+	}, nil
+}
+
+func (s *mailtoStrategy) Describe(email *entities.Email) string {
+	target, err := parseMailto(mailtoLinks(email)[0])
+	if err != nil {
+		return "Send an unsubscribe email"
+	}
+	return fmt.Sprintf("Send an unsubscribe email to %s", strings.Join(target.To, ", "))
+}
+
+// mailtoLinks returns the mailto targets from the List-Unsubscribe header, falling
+// back to the extracted unsubscribe link when that is a mailto link itself
+func mailtoLinks(email *entities.Email) []string {
+	_, links := listUnsubscribeTargets(email.ListUnsubscribe)
+	if email.UnsubscribeLink != nil && strings.HasPrefix(strings.ToLower(*email.UnsubscribeLink), "mailto:") {
+		links = append(links, *email.UnsubscribeLink)
+	}
+	return links
+}
@@ -0,0 +1,115 @@
+package unsubscribe
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	"golang.org/x/oauth2"
+)
+
+func TestParseMailto(t *testing.T) {
+	tests := []struct {
+		link    string
+		to      []string
+		subject string
+		body    string
+	}{
+		{"mailto:leave@example.com", []string{"leave@example.com"}, "", ""},
+		{"MAILTO:leave@example.com?subject=unsubscribe", []string{"leave@example.com"}, "unsubscribe", ""},
+		{"mailto:leave@example.com?subject=Remove%20me&body=list+id%3D42", []string{"leave@example.com"}, "Remove me", "list+id=42"},
+		{"mailto:a@example.com,b@example.com?to=c@example.com", []string{"a@example.com", "b@example.com", "c@example.com"}, "", ""},
+		{"mailto:?to=leave%40example.com&cc=ignored@example.com", []string{"leave@example.com"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		target, err := parseMailto(tt.link)
+		if err != nil {
+			t.Errorf("Expected no error for %s, got %v", tt.link, err)
+			continue
+		}
+		if !slices.Equal(target.To, tt.to) {
+			t.Errorf("Expected recipients %v for %s, got %v", tt.to, tt.link, target.To)
+		}
+		if target.Subject != tt.subject {
+			t.Errorf("Expected subject '%s' for %s, got '%s'", tt.subject, tt.link, target.Subject)
+		}
+		if target.Body != tt.body {
+			t.Errorf("Expected body '%s' for %s, got '%s'", tt.body, tt.link, target.Body)
+		}
+	}
+
+	for _, link := range []string{"https://example.com", "mailto:", "mailto:not-an-address"} {
+		if _, err := parseMailto(link); err == nil {
+			t.Errorf("Expected error for %s", link)
+		}
+	}
+}
+
+func TestMailtoStrategy(t *testing.T) {
+	gmailService := &fakeGmailService{}
+	strategy := newMailtoStrategy(gmailService, &fakeAccountRepository{})
+
+	email := &entities.Email{
+		ID:              7,
+		AccountID:       1,
+		ListUnsubscribe: "<mailto:leave@lists.example.com?subject=unsubscribe%20weekly>",
+	}
+
+	if !strategy.CanHandle(email) {
+		t.Fatal("Expected mailto strategy to handle email with a mailto target")
+	}
+
+	result, err := strategy.Unsubscribe(context.Background(), email)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.Success {
+		t.Errorf("Expected success, got %s", result.Message)
+	}
+	if result.SentMessageID != "sent-1" {
+		t.Errorf("Expected sent message ID 'sent-1', got '%s'", result.SentMessageID)
+	}
+
+	if len(gmailService.sent) != 1 {
+		t.Fatalf("Expected one message to be sent, got %d", len(gmailService.sent))
+	}
+	sent := gmailService.sent[0]
+	if sent.To != "leave@lists.example.com" || sent.Subject != "unsubscribe weekly" || sent.Body != defaultMailtoBody {
+		t.Errorf("Unexpected unsubscribe message: %+v", sent)
+	}
+}
+
+func TestMailtoStrategyUsesMailtoUnsubscribeLink(t *testing.T) {
+	strategy := newMailtoStrategy(&fakeGmailService{}, &fakeAccountRepository{})
+
+	link := "mailto:leave@example.com"
+	if !strategy.CanHandle(&entities.Email{UnsubscribeLink: &link}) {
+		t.Error("Expected mailto strategy to handle a mailto unsubscribe link")
+	}
+
+	httpsLink := "https://example.com/unsubscribe"
+	if strategy.CanHandle(&entities.Email{UnsubscribeLink: &httpsLink}) {
+		t.Error("Expected mailto strategy to skip an https unsubscribe link")
+	}
+}
+
+type fakeGmailService struct {
+	repositories.GmailService
+	sent []*entities.OutgoingMessage
+}
+
+func (s *fakeGmailService) SendMessage(ctx context.Context, token *oauth2.Token, msg *entities.OutgoingMessage) (string, error) {
+	s.sent = append(s.sent, msg)
+	return "sent-1", nil
+}
+
+type fakeAccountRepository struct {
+	repositories.AccountRepository
+}
+
+func (r *fakeAccountRepository) GetByID(ctx context.Context, id int64) (*entities.Account, error) {
+	return &entities.Account{ID: id, AccessToken: "test-token"}, nil
+}
@@ -0,0 +1,148 @@
+package unsubscribe
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// navigationGuard enforces the URL policy on every request a browser context makes.
+// Navigations are fetched without following redirects so each hop can be checked,
+// and once actions start the page may not leave the site it landed on. The addresses
+// actually connected to are checked again by the browser's policyProxy.
+type navigationGuard struct {
+	ctx    context.Context
+	policy *URLPolicy
+
+	mu        sync.Mutex
+	redirects map[string]int // redirect depth of each pending redirect target
+	site      string         // page URL navigation is locked to, once set
+	blocked   error          // first blocked navigation
+}
+
+func newNavigationGuard(ctx context.Context, policy *URLPolicy) *navigationGuard {
+	return &navigationGuard{
+		ctx:       ctx,
+		policy:    policy,
+		redirects: make(map[string]int),
+	}
+}
+
+// route is the playwright route handler for all requests of the context
+func (g *navigationGuard) route(route playwright.Route) {
+	request := route.Request()
+
+	if !request.IsNavigationRequest() {
+		// Subresources only need a safe destination
+		if err := g.policy.CheckURL(g.ctx, request.URL()); err != nil {
+			route.Abort("blockedbyclient")
+			return
+		}
+		route.Continue()
+		return
+	}
+
+	if err := g.checkNavigation(request.URL()); err != nil {
+		g.block(err)
+		route.Abort("blockedbyclient")
+		return
+	}
+
+	response, err := route.Fetch(playwright.RouteFetchOptions{
+		MaxRedirects: playwright.Int(0),
+	})
+	if err != nil {
+		route.Abort("failed")
+		return
+	}
+
+	if isRedirect(response.Status()) {
+		if _, err := g.followRedirect(request.URL(), response.Headers()["location"]); err != nil {
+			g.block(err)
+			route.Abort("blockedbyclient")
+			return
+		}
+	}
+
+	// The browser follows a fulfilled redirect with a new request, which is routed again
+	route.Fulfill(playwright.RouteFulfillOptions{Response: response})
+}
+
+// checkNavigation validates a navigation target against the policy and the site lock
+func (g *navigationGuard) checkNavigation(target string) error {
+	if err := g.policy.CheckURL(g.ctx, target); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	site := g.site
+	g.mu.Unlock()
+
+	if site != "" && !SameSite(site, target) {
+		return fmt.Errorf("%w: navigation to %s leaves the unsubscribe site", ErrBlockedURL, target)
+	}
+
+	return nil
+}
+
+// followRedirect records a redirect from one URL to a Location header and returns
+// the absolute target, failing once the chain is longer than the policy allows
+func (g *navigationGuard) followRedirect(from, location string) (string, error) {
+	base, err := url.Parse(from)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid url: %v", ErrBlockedURL, err)
+	}
+	next, err := base.Parse(location)
+	if err != nil || location == "" {
+		return "", fmt.Errorf("%w: invalid redirect location %q", ErrBlockedURL, location)
+	}
+	target := next.String()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	depth := g.redirects[from] + 1
+	delete(g.redirects, from)
+	if depth > g.policy.MaxRedirects() {
+		return "", fmt.Errorf("%w: more than %d redirects", ErrBlockedURL, g.policy.MaxRedirects())
+	}
+	g.redirects[target] = depth
+
+	return target, nil
+}
+
+// lockSite restricts further navigation to the registrable domain of pageURL.
+// An empty pageURL lifts the lock.
+func (g *navigationGuard) lockSite(pageURL string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.site = pageURL
+}
+
+func (g *navigationGuard) block(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.blocked == nil {
+		g.blocked = err
+	}
+}
+
+// Blocked returns the first navigation the guard refused, if any
+func (g *navigationGuard) Blocked() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.blocked
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
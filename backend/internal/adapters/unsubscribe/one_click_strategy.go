@@ -0,0 +1,102 @@
+package unsubscribe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+// oneClickBody is the fixed POST body defined by RFC 8058
+const oneClickBody = "List-Unsubscribe=One-Click"
+
+// oneClickStrategy unsubscribes with a single HTTPS POST to the List-Unsubscribe
+// URL for senders that advertise List-Unsubscribe-Post: List-Unsubscribe=One-Click
+type oneClickStrategy struct {
+	client *http.Client
+	policy *URLPolicy
+}
+
+func newOneClickStrategy(client *http.Client, policy *URLPolicy) *oneClickStrategy {
+	if client == nil {
+		client = policy.HTTPClient(15 * time.Second)
+	}
+
+	// RFC 8058 senders must not redirect; treat a redirect as a failed request
+	noRedirects := *client
+	noRedirects.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &oneClickStrategy{client: &noRedirects, policy: policy}
+}
+
+func (s *oneClickStrategy) Name() string {
+	return repositories.UnsubscribeStrategyOneClick
+}
+
+func (s *oneClickStrategy) CanHandle(email *entities.Email) bool {
+	return supportsOneClick(email) && s.target(email) != ""
+}
+
+func (s *oneClickStrategy) Unsubscribe(ctx context.Context, email *entities.Email) (*repositories.UnsubscribeResult, error) {
+	target := s.target(email)
+
+	if err := s.policy.CheckURL(ctx, target); err != nil {
+		return blockedURLResult(err), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(oneClickBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create one-click request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return &repositories.UnsubscribeResult{
+			Success:   false,
+			Message:   fmt.Sprintf("One-click unsubscribe request failed: %v", err),
+			ErrorType: "request_error",
+		}, nil
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &repositories.UnsubscribeResult{
+			Success:      false,
+			Message:      fmt.Sprintf("One-click unsubscribe returned HTTP %d", resp.StatusCode),
+			ErrorType:    "http_error",
+			RequiresAuth: resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden,
+		}, nil
+	}
+
+	return &repositories.UnsubscribeResult{
+		Success: true,
+		Message: fmt.Sprintf("Successfully unsubscribed from %s via one-click", email.Sender),
+	}, nil
+}
+
+func (s *oneClickStrategy) Describe(email *entities.Email) string {
+	return fmt.Sprintf("Send a one-click unsubscribe request to %s", s.target(email))
+}
+
+// target returns the first https List-Unsubscribe URL, the one RFC 8058 says to POST to
+func (s *oneClickStrategy) target(email *entities.Email) string {
+	httpsURLs, _ := listUnsubscribeTargets(email.ListUnsubscribe)
+	if len(httpsURLs) == 0 {
+		return ""
+	}
+	return httpsURLs[0]
+}
+
+func supportsOneClick(email *entities.Email) bool {
+	post := strings.ReplaceAll(email.ListUnsubscribePost, " ", "")
+	return strings.EqualFold(post, oneClickBody)
+}
@@ -0,0 +1,187 @@
+package unsubscribe
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+func TestListUnsubscribeTargets(t *testing.T) {
+	httpsURLs, mailtoURLs := listUnsubscribeTargets("<mailto:leave@example.com?subject=unsubscribe>, <https://example.com/u?id=1>, <http://example.com/insecure>")
+
+	if len(httpsURLs) != 1 || httpsURLs[0] != "https://example.com/u?id=1" {
+		t.Errorf("Expected one https target, got %v", httpsURLs)
+	}
+	if len(mailtoURLs) != 1 || mailtoURLs[0] != "mailto:leave@example.com?subject=unsubscribe" {
+		t.Errorf("Expected one mailto target, got %v", mailtoURLs)
+	}
+}
+
+func TestOneClickStrategy(t *testing.T) {
+	var gotMethod, gotContentType, gotBody string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotContentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	strategy := newOneClickStrategy(server.Client(), newTestURLPolicy())
+
+	email := &entities.Email{
+		ID:                  1,
+		Sender:              "news@example.com",
+		ListUnsubscribe:     "<mailto:leave@example.com>, <" + server.URL + "/unsubscribe?id=42>",
+		ListUnsubscribePost: "List-Unsubscribe=One-Click",
+	}
+
+	if !strategy.CanHandle(email) {
+		t.Fatal("Expected one-click strategy to handle email with List-Unsubscribe-Post")
+	}
+
+	result, err := strategy.Unsubscribe(context.Background(), email)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.Success {
+		t.Errorf("Expected success, got %s", result.Message)
+	}
+	if gotMethod != http.MethodPost {
+		t.Errorf("Expected POST, got %s", gotMethod)
+	}
+	if gotContentType != "application/x-www-form-urlencoded" {
+		t.Errorf("Expected form content type, got %s", gotContentType)
+	}
+	if gotBody != "List-Unsubscribe=One-Click" {
+		t.Errorf("Expected RFC 8058 body, got %q", gotBody)
+	}
+
+	for _, path := range []string{"/gone", "/redirect"} {
+		email.ListUnsubscribe = "<" + server.URL + path + ">"
+		result, err = strategy.Unsubscribe(context.Background(), email)
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", path, err)
+		}
+		if result.Success {
+			t.Errorf("Expected %s to fail", path)
+		}
+	}
+}
+
+func TestOneClickStrategyRequiresPostHeader(t *testing.T) {
+	strategy := newOneClickStrategy(nil, NewURLPolicy(DefaultMaxRedirects, nil))
+
+	email := &entities.Email{ListUnsubscribe: "<https://example.com/unsubscribe>"}
+	if strategy.CanHandle(email) {
+		t.Error("Expected one-click strategy to skip email without List-Unsubscribe-Post")
+	}
+}
+
+func TestUnsubscribeFallsBackToNextStrategy(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	fallback := &fakeStrategy{name: repositories.UnsubscribeStrategyBrowser}
+	service := &WebAutomationService{
+		strategies: []strategy{newOneClickStrategy(server.Client(), newTestURLPolicy()), fallback},
+	}
+
+	email := &entities.Email{
+		ListUnsubscribe:     "<" + server.URL + "/unsubscribe>",
+		ListUnsubscribePost: "List-Unsubscribe=One-Click",
+	}
+
+	result, err := service.UnsubscribeFromEmail(context.Background(), email)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !fallback.called {
+		t.Error("Expected fallback strategy to be tried after one-click failed")
+	}
+	if !result.Success || result.Strategy != repositories.UnsubscribeStrategyBrowser {
+		t.Errorf("Expected success via browser strategy, got %+v", result)
+	}
+}
+
+func TestUnsubscribeWithoutAnyStrategy(t *testing.T) {
+	service := NewWebAutomationService(nil, nil, nil, nil, nil, nil, 1)
+
+	result, err := service.UnsubscribeFromEmail(context.Background(), &entities.Email{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Success || result.ErrorType != "no_link" {
+		t.Errorf("Expected no_link failure, got %+v", result)
+	}
+}
+
+type fakeStrategy struct {
+	name   string
+	called bool
+}
+
+func (s *fakeStrategy) Name() string { return s.name }
+
+func (s *fakeStrategy) CanHandle(email *entities.Email) bool { return true }
+
+func (s *fakeStrategy) Unsubscribe(ctx context.Context, email *entities.Email) (*repositories.UnsubscribeResult, error) {
+	s.called = true
+	return &repositories.UnsubscribeResult{Success: true, Message: "ok"}, nil
+}
+
+func TestUnsubscribeExcludingSkipsStrategies(t *testing.T) {
+	oneClick := &fakeStrategy{name: repositories.UnsubscribeStrategyOneClick}
+	browser := &fakeStrategy{name: repositories.UnsubscribeStrategyBrowser}
+	service := &WebAutomationService{strategies: []strategy{oneClick, browser}}
+
+	result, err := service.UnsubscribeExcluding(context.Background(), &entities.Email{}, []string{repositories.UnsubscribeStrategyOneClick})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if oneClick.called || !browser.called || result.Strategy != repositories.UnsubscribeStrategyBrowser {
+		t.Errorf("Expected only the browser strategy to run, got %+v", result)
+	}
+
+	result, err = service.UnsubscribeExcluding(context.Background(), &entities.Email{}, []string{repositories.UnsubscribeStrategyOneClick, repositories.UnsubscribeStrategyBrowser})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Success || result.ErrorType != "no_strategy" {
+		t.Errorf("Expected no_strategy failure when every strategy is excluded, got %+v", result)
+	}
+}
+
+func TestOneClickStrategyBlocksPrivateAddresses(t *testing.T) {
+	strategy := newOneClickStrategy(nil, NewURLPolicy(DefaultMaxRedirects, nil))
+
+	email := &entities.Email{
+		ListUnsubscribe:     "<https://169.254.169.254/latest/meta-data>",
+		ListUnsubscribePost: "List-Unsubscribe=One-Click",
+	}
+
+	result, err := strategy.Unsubscribe(context.Background(), email)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Success || result.ErrorType != "blocked_url" {
+		t.Errorf("Expected blocked_url failure, got %+v", result)
+	}
+}
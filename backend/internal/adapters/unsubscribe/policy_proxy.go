@@ -0,0 +1,163 @@
+package unsubscribe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// policyProxy is the HTTP proxy the unsubscribe browser sends all of its traffic
+// through. Chromium would otherwise resolve hosts again after the navigation guard
+// checked them, so a host could pass CheckURL and then rebind to an internal address.
+// The proxy does the only resolution, and its dialer checks every address it connects to.
+type policyProxy struct {
+	policy    *URLPolicy
+	transport *http.Transport
+	listener  net.Listener
+	server    *http.Server
+}
+
+// Hop-by-hop headers are meant for the proxy and are not forwarded (RFC 9110 7.6.1)
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// startPolicyProxy starts a proxy on a random loopback port
+func startPolicyProxy(policy *URLPolicy) (*policyProxy, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start unsubscribe proxy: %w", err)
+	}
+
+	transport := policy.transport()
+	// The browser is the client; it must not be sent on to another proxy
+	transport.Proxy = nil
+
+	proxy := &policyProxy{
+		policy:    policy,
+		transport: transport,
+		listener:  listener,
+	}
+	proxy.server = &http.Server{
+		Handler:           proxy,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := proxy.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("Warning: unsubscribe proxy stopped: %v\n", err)
+		}
+	}()
+
+	return proxy, nil
+}
+
+// URL is the address to configure the browser with
+func (p *policyProxy) URL() string {
+	return "http://" + p.listener.Addr().String()
+}
+
+func (p *policyProxy) Close() error {
+	p.transport.CloseIdleConnections()
+	return p.server.Close()
+}
+
+func (p *policyProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+
+	if !r.URL.IsAbs() {
+		http.Error(w, "only proxy requests are accepted", http.StatusBadRequest)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	removeHopHeaders(out.Header)
+
+	response, err := p.transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, err.Error(), proxyErrorStatus(err))
+		return
+	}
+	defer response.Body.Close()
+
+	removeHopHeaders(response.Header)
+	for name, values := range response.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(response.StatusCode)
+	io.Copy(w, response.Body)
+}
+
+// tunnel connects a CONNECT request, used for HTTPS and WebSockets, to its target
+func (p *policyProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	target, err := p.policy.dialer().DialContext(r.Context(), "tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), proxyErrorStatus(err))
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		target.Close()
+		http.Error(w, "tunnelling is not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		target.Close()
+		return
+	}
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		client.Close()
+		target.Close()
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		// Bytes the client sent after the CONNECT request are already buffered
+		io.Copy(target, buffered)
+		closeWrite(target)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(client, target)
+		closeWrite(client)
+	}()
+	wg.Wait()
+
+	client.Close()
+	target.Close()
+}
+
+func removeHopHeaders(header http.Header) {
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+}
+
+// proxyErrorStatus tells the browser whether the policy refused a connection
+func proxyErrorStatus(err error) int {
+	if errors.Is(err, ErrBlockedURL) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadGateway
+}
+
+func closeWrite(conn net.Conn) {
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.CloseWrite()
+	}
+}
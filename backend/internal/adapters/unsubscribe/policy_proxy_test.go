@@ -0,0 +1,65 @@
+package unsubscribe
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// newProxiedClient returns a client sending its requests through a policy proxy
+func newProxiedClient(t *testing.T, policy *URLPolicy) *http.Client {
+	t.Helper()
+	proxy, err := startPolicyProxy(policy)
+	if err != nil {
+		t.Fatalf("Expected the proxy to start, got %v", err)
+	}
+	t.Cleanup(func() { proxy.Close() })
+
+	proxyURL, _ := url.Parse(proxy.URL())
+	return &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+}
+
+func TestPolicyProxy(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=1")
+		w.Write([]byte("unsubscribed"))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(handler)
+	defer tlsServer.Close()
+
+	client := newProxiedClient(t, newTestURLPolicy())
+	for _, target := range []string{server.URL, tlsServer.URL} {
+		response, err := client.Get(target + "/unsubscribe")
+		if err != nil {
+			t.Fatalf("Expected %s to be reachable through the proxy, got %v", target, err)
+		}
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		if string(body) != "unsubscribed" || response.Header.Get("Set-Cookie") != "session=1" {
+			t.Errorf("Expected the response of %s to be passed on, got %q", target, body)
+		}
+	}
+
+	// Loopback stands in for an internal address the host rebound to
+	client = newProxiedClient(t, NewURLPolicy(DefaultMaxRedirects, nil))
+	response, err := client.Get(server.URL + "/unsubscribe")
+	if err != nil {
+		t.Fatalf("Expected a response from the proxy, got %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected a plain request to an internal address to be refused, got %d", response.StatusCode)
+	}
+
+	if _, err := client.Get(tlsServer.URL + "/unsubscribe"); err == nil {
+		t.Error("Expected a tunnel to an internal address to be refused")
+	}
+}
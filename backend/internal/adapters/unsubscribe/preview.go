@@ -0,0 +1,176 @@
+package unsubscribe
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	"github.com/playwright-community/playwright-go"
+)
+
+// Outlines the element an action targets in preview screenshots
+const (
+	highlightScript   = `(el) => { el.dataset.unsubscribePreviewOutline = el.style.outline; el.style.outline = '4px solid #ef4444'; el.scrollIntoView({block: 'center'}); }`
+	unhighlightScript = `(el) => { el.style.outline = el.dataset.unsubscribePreviewOutline || ''; delete el.dataset.unsubscribePreviewOutline; }`
+)
+
+// describer is implemented by strategies that can say what they would do for an email
+type describer interface {
+	Describe(email *entities.Email) string
+}
+
+// PreviewUnsubscribe picks the strategy UnsubscribeExcluding would try first. For the
+// browser strategy it loads the page and has the AI plan the actions, capturing a
+// screenshot of each target element, but clicks nothing. Loading the page is itself
+// a GET of the sender's link; when that already unsubscribed, the result says so.
+func (w *WebAutomationService) PreviewUnsubscribe(ctx context.Context, email *entities.Email, excludedStrategies []string) (*repositories.UnsubscribePlan, *repositories.UnsubscribeResult, error) {
+	excluded := false
+	for _, candidate := range w.strategies {
+		if !candidate.CanHandle(email) {
+			continue
+		}
+		if slices.Contains(excludedStrategies, candidate.Name()) {
+			excluded = true
+			continue
+		}
+
+		if candidate.Name() == repositories.UnsubscribeStrategyBrowser {
+			return w.previewBrowser(ctx, email)
+		}
+
+		message := fmt.Sprintf("Unsubscribe using the %s method", candidate.Name())
+		if d, ok := candidate.(describer); ok {
+			message = d.Describe(email)
+		}
+		return &repositories.UnsubscribePlan{
+			Strategy: candidate.Name(),
+			Message:  message,
+		}, nil, nil
+	}
+
+	return nil, noStrategyResult(excluded), nil
+}
+
+func (w *WebAutomationService) previewBrowser(ctx context.Context, email *entities.Email) (*repositories.UnsubscribePlan, *repositories.UnsubscribeResult, error) {
+	link := *email.UnsubscribeLink
+	if result := w.checkLink(ctx, link); result != nil {
+		return nil, result, nil
+	}
+
+	var plan *repositories.UnsubscribePlan
+	result := w.withPage(ctx, func(page playwright.Page, guard *navigationGuard) *repositories.UnsubscribeResult {
+		if result := w.openPage(page, guard, link); result != nil {
+			return result
+		}
+
+		// Many senders unsubscribe as soon as the link is opened, so the dry run
+		// reports it rather than offering a plan that would do nothing
+		if w.verifyUnsubscribeSuccess(page) {
+			return &repositories.UnsubscribeResult{
+				Success: true,
+				Message: fmt.Sprintf("Opening the unsubscribe page of %s already unsubscribed you", page.URL()),
+			}
+		}
+
+		analysis, result := w.planActions(ctx, page, link)
+		if result != nil {
+			return result
+		}
+
+		plan = &repositories.UnsubscribePlan{
+			Strategy: repositories.UnsubscribeStrategyBrowser,
+			Message:  fmt.Sprintf("Perform %d action(s) on %s", len(analysis.Actions), page.URL()),
+			PageURL:  page.URL(),
+			Actions:  analysis.Actions,
+		}
+		if w.artifactStore != nil {
+			plan.Screenshots = w.highlightActions(ctx, page, email, analysis.Actions)
+		}
+		return nil
+	})
+	if result != nil {
+		result.Strategy = repositories.UnsubscribeStrategyBrowser
+		return nil, result, nil
+	}
+
+	return plan, nil, nil
+}
+
+// highlightActions saves a screenshot per action with its target element outlined.
+// Actions without a target that can be found get an empty key.
+func (w *WebAutomationService) highlightActions(ctx context.Context, page playwright.Page, email *entities.Email, actions []repositories.UnsubscribeAction) []string {
+	prefix := fmt.Sprintf("unsubscribe/email-%d/preview-%d", email.ID, time.Now().UnixNano())
+
+	keys := make([]string, len(actions))
+	for i, action := range actions {
+		if action.Selector == "" || action.Action == "wait" {
+			continue
+		}
+
+		element, err := page.QuerySelector(action.Selector)
+		if err != nil || element == nil {
+			continue
+		}
+
+		if _, err := element.Evaluate(highlightScript); err != nil {
+			fmt.Printf("Warning: failed to highlight %s: %v\n", action.Selector, err)
+			continue
+		}
+		data, err := page.Screenshot(playwright.PageScreenshotOptions{})
+		element.Evaluate(unhighlightScript)
+		if err != nil {
+			fmt.Printf("Warning: failed to capture preview screenshot: %v\n", err)
+			continue
+		}
+
+		key := fmt.Sprintf("%s/action-%d.png", prefix, i+1)
+		if err := w.artifactStore.Save(ctx, key, data); err != nil {
+			fmt.Printf("Warning: failed to save unsubscribe artifact %s: %v\n", key, err)
+			continue
+		}
+		keys[i] = key
+	}
+
+	return keys
+}
+
+// ExecuteUnsubscribePlan runs the previewed strategy. Browser plans replay the
+// approved actions on the previewed page instead of asking the AI again.
+func (w *WebAutomationService) ExecuteUnsubscribePlan(ctx context.Context, email *entities.Email, plan *repositories.UnsubscribePlan) (*repositories.UnsubscribeResult, error) {
+	if plan.Strategy != repositories.UnsubscribeStrategyBrowser {
+		for _, candidate := range w.strategies {
+			if candidate.Name() == plan.Strategy && candidate.CanHandle(email) {
+				return runStrategy(ctx, candidate, email), nil
+			}
+		}
+
+		return &repositories.UnsubscribeResult{
+			Success:   false,
+			Message:   "The previewed unsubscribe method is no longer available for this email",
+			ErrorType: "no_strategy",
+			Strategy:  plan.Strategy,
+		}, nil
+	}
+
+	if err := w.policy.CheckURL(ctx, plan.PageURL); err != nil {
+		result := blockedURLResult(err)
+		result.Strategy = plan.Strategy
+		return result, nil
+	}
+
+	result := w.withEvidence(ctx, email, func(page playwright.Page, guard *navigationGuard, record *evidence) *repositories.UnsubscribeResult {
+		if result := w.openPage(page, guard, plan.PageURL); result != nil {
+			return result
+		}
+		record.screenshot(page, "before")
+
+		result, _ := w.runActions(page, guard, record, plan.Actions, email)
+		return result
+	})
+	result.Strategy = plan.Strategy
+
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package unsubscribe
+
+import (
+	"context"
+	"testing"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+func TestPreviewUnsubscribeDescribesFirstStrategy(t *testing.T) {
+	oneClick := newOneClickStrategy(nil, NewURLPolicy(DefaultMaxRedirects, nil))
+	browser := &fakeStrategy{name: repositories.UnsubscribeStrategyBrowser}
+	service := &WebAutomationService{strategies: []strategy{oneClick, browser}}
+
+	email := &entities.Email{
+		ListUnsubscribe:     "<https://example.com/unsubscribe?id=1>",
+		ListUnsubscribePost: "List-Unsubscribe=One-Click",
+	}
+
+	plan, result, err := service.PreviewUnsubscribe(context.Background(), email, nil)
+	if err != nil || result != nil {
+		t.Fatalf("Expected a plan, got result=%+v err=%v", result, err)
+	}
+	if plan.Strategy != repositories.UnsubscribeStrategyOneClick || plan.Message != "Send a one-click unsubscribe request to https://example.com/unsubscribe?id=1" {
+		t.Errorf("Unexpected plan: %+v", plan)
+	}
+	if browser.called {
+		t.Error("Expected a preview not to unsubscribe")
+	}
+}
+
+func TestPreviewUnsubscribeWithoutStrategy(t *testing.T) {
+	oneClick := &fakeStrategy{name: repositories.UnsubscribeStrategyOneClick}
+	service := &WebAutomationService{strategies: []strategy{oneClick}}
+
+	plan, result, err := service.PreviewUnsubscribe(context.Background(), &entities.Email{}, []string{repositories.UnsubscribeStrategyOneClick})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if plan != nil || result.ErrorType != "no_strategy" {
+		t.Errorf("Expected no_strategy failure, got plan=%+v result=%+v", plan, result)
+	}
+}
+
+func TestExecuteUnsubscribePlanRunsPreviewedStrategy(t *testing.T) {
+	oneClick := &fakeStrategy{name: repositories.UnsubscribeStrategyOneClick}
+	mailto := &fakeStrategy{name: repositories.UnsubscribeStrategyMailto}
+	service := &WebAutomationService{strategies: []strategy{oneClick, mailto}}
+
+	result, err := service.ExecuteUnsubscribePlan(context.Background(), &entities.Email{}, &repositories.UnsubscribePlan{
+		Strategy: repositories.UnsubscribeStrategyMailto,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if oneClick.called || !mailto.called || result.Strategy != repositories.UnsubscribeStrategyMailto {
+		t.Errorf("Expected only the previewed strategy to run, got %+v", result)
+	}
+
+	service.strategies = []strategy{oneClick}
+	result, err = service.ExecuteUnsubscribePlan(context.Background(), &entities.Email{}, &repositories.UnsubscribePlan{
+		Strategy: repositories.UnsubscribeStrategyMailto,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Success || result.ErrorType != "no_strategy" {
+		t.Errorf("Expected no_strategy failure when the strategy is gone, got %+v", result)
+	}
+}
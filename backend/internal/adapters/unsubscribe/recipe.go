@@ -0,0 +1,188 @@
+package unsubscribe
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	"github.com/playwright-community/playwright-go"
+)
+
+var (
+	numericSegment = regexp.MustCompile(`^[0-9]+$`)
+	uuidSegment    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// Path segments at least this long that mix in digits are taken to be tokens
+const minTokenSegmentLength = 16
+
+// recipeKey identifies the kind of unsubscribe page at pageURL. Sender platforms put
+// subscriber and campaign IDs in the path and query, so the query is dropped and
+// ID-like path segments become "*", letting every email from the platform share a key.
+func recipeKey(pageURL string) (host, pathPattern string, ok bool) {
+	parsed, err := url.Parse(pageURL)
+	if err != nil || parsed.Hostname() == "" {
+		return "", "", false
+	}
+
+	segments := strings.Split(strings.Trim(parsed.EscapedPath(), "/"), "/")
+	for i, segment := range segments {
+		if isIDSegment(segment) {
+			segments[i] = "*"
+		}
+	}
+
+	return strings.ToLower(parsed.Hostname()), "/" + strings.Join(segments, "/"), true
+}
+
+func isIDSegment(segment string) bool {
+	if numericSegment.MatchString(segment) || uuidSegment.MatchString(segment) {
+		return true
+	}
+	return len(segment) >= minTokenSegmentLength && strings.ContainsAny(segment, "0123456789")
+}
+
+// maxRecipeMisses is how many replays in a row may go unconfirmed before a recipe is
+// dropped, so a recipe that stopped working doesn't keep the AI away from the site
+const maxRecipeMisses = 3
+
+// recipientPlaceholder stands in for the recipient's address in the fills of a recipe
+const recipientPlaceholder = "{{recipient}}"
+
+// recipientAddress is the address the email was sent to, or "" if it has none
+func recipientAddress(email *entities.Email) string {
+	if len(email.To) == 0 {
+		return ""
+	}
+	return entities.SenderAddress(email.To[0])
+}
+
+// recipeActions turns actions into a recipe that can be replayed for other subscribers.
+// Recipes are shared by every account, so only clicks and fills of the recipient's
+// address, which is replaced by recipientPlaceholder, are stored; anything else could
+// carry one subscriber's data to another.
+func recipeActions(actions []repositories.UnsubscribeAction, recipient string) ([]repositories.UnsubscribeAction, bool) {
+	if len(actions) == 0 {
+		return nil, false
+	}
+
+	recipe := make([]repositories.UnsubscribeAction, len(actions))
+	for i, action := range actions {
+		switch {
+		case action.Action == "click":
+			recipe[i] = repositories.UnsubscribeAction{Action: action.Action, Selector: action.Selector}
+		case action.Action == "fill" && recipient != "" && strings.EqualFold(strings.TrimSpace(action.Value), recipient):
+			recipe[i] = repositories.UnsubscribeAction{Action: action.Action, Selector: action.Selector, Value: recipientPlaceholder}
+		default:
+			return nil, false
+		}
+	}
+	return recipe, true
+}
+
+// fillRecipient returns the actions of a recipe with the placeholder replaced by
+// recipient. It fails if the recipe needs an address and the email has none.
+func fillRecipient(actions []repositories.UnsubscribeAction, recipient string) ([]repositories.UnsubscribeAction, bool) {
+	filled := make([]repositories.UnsubscribeAction, len(actions))
+	for i, action := range actions {
+		if action.Value == recipientPlaceholder {
+			if recipient == "" {
+				return nil, false
+			}
+			action.Value = recipient
+		}
+		filled[i] = action
+	}
+	return filled, true
+}
+
+// replayRecipe unsubscribes with a recipe learned for the open page, if there is one.
+// A replay that can't find the recipe's elements means the page has changed; the
+// recipe is then invalidated and link reloaded so the caller can fall back to the AI,
+// signalled by returning nil. Any other outcome of the replay is the result, and one
+// the page doesn't confirm counts as a miss towards dropping the recipe.
+func (w *WebAutomationService) replayRecipe(ctx context.Context, page playwright.Page, guard *navigationGuard, record *evidence, link string, email *entities.Email) *repositories.UnsubscribeResult {
+	if w.recipeRepo == nil {
+		return nil
+	}
+	host, pathPattern, ok := recipeKey(page.URL())
+	if !ok {
+		return nil
+	}
+
+	recipe, err := w.recipeRepo.GetByKey(ctx, host, pathPattern)
+	if err != nil {
+		fmt.Printf("Warning: failed to look up unsubscribe recipe: %v\n", err)
+		return nil
+	}
+	if recipe == nil {
+		return nil
+	}
+	actions, ok := fillRecipient(recipe.Actions, recipientAddress(email))
+	if !ok {
+		return nil
+	}
+
+	result, verified := w.runActions(page, guard, record, actions, email)
+	if verified {
+		if err := w.recipeRepo.RecordSuccess(ctx, recipe.ID); err != nil {
+			fmt.Printf("Warning: failed to record unsubscribe recipe success: %v\n", err)
+		}
+	}
+	if result.ErrorType != "element_not_found" {
+		if !verified {
+			dropped, err := w.recipeRepo.RecordMiss(ctx, recipe.ID, maxRecipeMisses)
+			if err != nil {
+				fmt.Printf("Warning: failed to record unsubscribe recipe miss: %v\n", err)
+			} else if dropped {
+				fmt.Printf("Warning: unsubscribe recipe for %s%s went unconfirmed %d times in a row, dropping it\n", host, pathPattern, maxRecipeMisses)
+			}
+		}
+		result.Message += " (using a learned recipe)"
+		return result
+	}
+
+	// The page has changed since the recipe was learned
+	fmt.Printf("Warning: unsubscribe recipe for %s%s no longer matches the page, falling back to AI\n", host, pathPattern)
+	if err := w.recipeRepo.Delete(ctx, recipe.ID); err != nil {
+		fmt.Printf("Warning: failed to invalidate unsubscribe recipe: %v\n", err)
+	}
+
+	// Start over from the link, which may redirect through another site
+	guard.lockSite("")
+	if result := w.openPage(page, guard, link); result != nil {
+		return result
+	}
+	record.screenshot(page, "before-retry")
+
+	return nil
+}
+
+// learnRecipe stores actions that the page at pageURL confirmed unsubscribed. Callers
+// only pass verified runs, as an unconfirmed recipe would keep the AI away from the site.
+func (w *WebAutomationService) learnRecipe(ctx context.Context, pageURL string, actions []repositories.UnsubscribeAction, email *entities.Email) {
+	if w.recipeRepo == nil {
+		return
+	}
+	recipeActions, ok := recipeActions(actions, recipientAddress(email))
+	if !ok {
+		return
+	}
+	host, pathPattern, ok := recipeKey(pageURL)
+	if !ok {
+		return
+	}
+
+	recipe := &repositories.UnsubscribeRecipe{
+		Host:        host,
+		PathPattern: pathPattern,
+		Actions:     recipeActions,
+	}
+	if err := w.recipeRepo.Save(ctx, recipe); err != nil {
+		fmt.Printf("Warning: failed to save unsubscribe recipe: %v\n", err)
+	}
+}
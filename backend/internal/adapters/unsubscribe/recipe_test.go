@@ -0,0 +1,230 @@
+package unsubscribe
+
+import (
+	"context"
+	"testing"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	"github.com/playwright-community/playwright-go"
+)
+
+func TestRecipeKey(t *testing.T) {
+	tests := []struct {
+		url         string
+		host        string
+		pathPattern string
+	}{
+		{"https://Example.us1.list-manage.com/unsubscribe?u=abc&id=123", "example.us1.list-manage.com", "/unsubscribe"},
+		{"https://u123.ct.sendgrid.net/asm/unsubscribe/48213", "u123.ct.sendgrid.net", "/asm/unsubscribe/*"},
+		{"https://news.example.com/u/9b2f4c1e-8a7d-4e3b-9c6f-1d2e3f4a5b6c/confirm", "news.example.com", "/u/*/confirm"},
+		{"https://news.example.com/unsub/aGVsbG8gd29ybGQx2345/", "news.example.com", "/unsub/*"},
+		{"https://news.example.com/preferences-center", "news.example.com", "/preferences-center"},
+		{"https://news.example.com", "news.example.com", "/"},
+	}
+
+	for _, tt := range tests {
+		host, pathPattern, ok := recipeKey(tt.url)
+		if !ok {
+			t.Errorf("recipeKey(%q) failed", tt.url)
+			continue
+		}
+		if host != tt.host || pathPattern != tt.pathPattern {
+			t.Errorf("recipeKey(%q) = %q, %q, want %q, %q", tt.url, host, pathPattern, tt.host, tt.pathPattern)
+		}
+	}
+
+	if _, _, ok := recipeKey("not a url"); ok {
+		t.Error("recipeKey accepted a URL without a host")
+	}
+}
+
+func TestRecipeActions(t *testing.T) {
+	click := repositories.UnsubscribeAction{Action: "click", Selector: "#unsubscribe"}
+	reason := repositories.UnsubscribeAction{Action: "select", Selector: "#reason", Value: "too_many"}
+	fillEmail := repositories.UnsubscribeAction{Action: "fill", Selector: "#email", Value: "User@Example.com"}
+	fillName := repositories.UnsubscribeAction{Action: "fill", Selector: "#name", Value: "Jo"}
+
+	recipe, ok := recipeActions([]repositories.UnsubscribeAction{fillEmail, click}, "user@example.com")
+	if !ok {
+		t.Fatal("expected clicks and fills of the recipient's address to be learnable")
+	}
+	if recipe[0].Value != recipientPlaceholder || recipe[1].Selector != click.Selector {
+		t.Errorf("expected the address to be replaced by the placeholder, got %+v", recipe)
+	}
+
+	for _, actions := range [][]repositories.UnsubscribeAction{
+		{reason, click},
+		{fillName, click},
+		{fillEmail, click},
+		nil,
+	} {
+		if _, ok := recipeActions(actions, "other@example.com"); ok {
+			t.Errorf("expected %+v not to be learnable", actions)
+		}
+	}
+}
+
+type fakeRecipeRepository struct {
+	repositories.UnsubscribeRecipeRepository
+	recipes   map[string]*repositories.UnsubscribeRecipe
+	successes int
+}
+
+func (r *fakeRecipeRepository) GetByKey(ctx context.Context, host, pathPattern string) (*repositories.UnsubscribeRecipe, error) {
+	return r.recipes[host+pathPattern], nil
+}
+
+func (r *fakeRecipeRepository) Save(ctx context.Context, recipe *repositories.UnsubscribeRecipe) error {
+	r.recipes[recipe.Host+recipe.PathPattern] = recipe
+	return nil
+}
+
+func (r *fakeRecipeRepository) RecordSuccess(ctx context.Context, id int64) error {
+	r.successes++
+	return nil
+}
+
+func (r *fakeRecipeRepository) RecordMiss(ctx context.Context, id int64, maxMisses int) (bool, error) {
+	for key, recipe := range r.recipes {
+		if recipe.ID == id {
+			recipe.MissCount++
+			if recipe.MissCount >= maxMisses {
+				delete(r.recipes, key)
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRecipeRepository) Delete(ctx context.Context, id int64) error {
+	for key, recipe := range r.recipes {
+		if recipe.ID == id {
+			delete(r.recipes, key)
+		}
+	}
+	return nil
+}
+
+// fakePage is an unsubscribe page whose elements update its body text when used
+type fakePage struct {
+	playwright.Page
+	url      string
+	body     string
+	elements map[string]*fakeElement
+	visits   int
+}
+
+func (p *fakePage) URL() string { return p.url }
+
+func (p *fakePage) Goto(url string, options ...playwright.PageGotoOptions) (playwright.Response, error) {
+	p.visits++
+	return nil, nil
+}
+
+func (p *fakePage) WaitForLoadState(options ...playwright.PageWaitForLoadStateOptions) error {
+	return nil
+}
+
+func (p *fakePage) QuerySelector(selector string, options ...playwright.PageQuerySelectorOptions) (playwright.ElementHandle, error) {
+	if element, ok := p.elements[selector]; ok {
+		return element, nil
+	}
+	return nil, nil
+}
+
+func (p *fakePage) QuerySelectorAll(selector string) ([]playwright.ElementHandle, error) {
+	return nil, nil
+}
+
+func (p *fakePage) TextContent(selector string, options ...playwright.PageTextContentOptions) (string, error) {
+	return p.body, nil
+}
+
+type fakeElement struct {
+	playwright.ElementHandle
+	page    *fakePage
+	onClick string
+	filled  string
+}
+
+func (e *fakeElement) Click(options ...playwright.ElementHandleClickOptions) error {
+	e.page.body = e.onClick
+	return nil
+}
+
+func (e *fakeElement) Fill(value string, options ...playwright.ElementHandleFillOptions) error {
+	e.filled = value
+	return nil
+}
+
+func newRecipeTestPage(onClick string) (*fakePage, *fakeElement) {
+	page := &fakePage{url: "https://news.example.com/unsub/48213", body: "Unsubscribe from our newsletter"}
+	input := &fakeElement{page: page}
+	page.elements = map[string]*fakeElement{
+		"#email":       input,
+		"#unsubscribe": {page: page, onClick: onClick},
+	}
+	return page, input
+}
+
+func newRecipeTestService() (*WebAutomationService, *fakeRecipeRepository) {
+	recipeRepo := &fakeRecipeRepository{recipes: map[string]*repositories.UnsubscribeRecipe{
+		"news.example.com/unsub/*": {ID: 1, Host: "news.example.com", PathPattern: "/unsub/*", Actions: []repositories.UnsubscribeAction{
+			{Action: "fill", Selector: "#email", Value: recipientPlaceholder},
+			{Action: "click", Selector: "#unsubscribe"},
+		}},
+	}}
+	return &WebAutomationService{recipeRepo: recipeRepo}, recipeRepo
+}
+
+func TestReplayRecipe(t *testing.T) {
+	service, recipeRepo := newRecipeTestService()
+	page, input := newRecipeTestPage("You have been unsubscribed")
+	email := &entities.Email{Sender: "news@example.com", To: []string{"Jo <jo@example.org>"}}
+
+	result := service.replayRecipe(context.Background(), page, newNavigationGuard(context.Background(), nil), nil, page.url, email)
+	if result == nil || !result.Success {
+		t.Fatalf("Expected the recipe to unsubscribe, got %+v", result)
+	}
+	if input.filled != "jo@example.org" || recipeRepo.successes != 1 {
+		t.Errorf("Expected the recipient's address to be filled in and the success recorded, got %q and %d", input.filled, recipeRepo.successes)
+	}
+}
+
+func TestReplayRecipeDropsRecipeAfterUnverifiedReplays(t *testing.T) {
+	service, recipeRepo := newRecipeTestService()
+	email := &entities.Email{Sender: "news@example.com", To: []string{"jo@example.org"}}
+
+	for i := 1; i <= maxRecipeMisses; i++ {
+		page, _ := newRecipeTestPage("Thanks")
+		result := service.replayRecipe(context.Background(), page, newNavigationGuard(context.Background(), nil), nil, page.url, email)
+		if result == nil || result.Success || result.ErrorType != "unverified" {
+			t.Fatalf("Expected the replay's unverified result without falling back to the AI, got %+v", result)
+		}
+		if page.visits != 0 || recipeRepo.successes != 0 {
+			t.Fatalf("Expected an unverified replay not to reload the link or count as a success")
+		}
+
+		kept := len(recipeRepo.recipes) == 1
+		if kept != (i < maxRecipeMisses) {
+			t.Fatalf("After %d unverified replays, expected the recipe kept to be %v", i, i < maxRecipeMisses)
+		}
+	}
+}
+
+func TestReplayRecipeInvalidatesChangedPage(t *testing.T) {
+	service, recipeRepo := newRecipeTestService()
+	page, _ := newRecipeTestPage("You have been unsubscribed")
+	delete(page.elements, "#unsubscribe")
+	email := &entities.Email{Sender: "news@example.com", To: []string{"jo@example.org"}}
+
+	result := service.replayRecipe(context.Background(), page, newNavigationGuard(context.Background(), nil), nil, page.url, email)
+	if result != nil {
+		t.Fatalf("Expected a fall back to the AI, got %+v", result)
+	}
+	if len(recipeRepo.recipes) != 0 || page.visits != 1 {
+		t.Errorf("Expected the recipe to be invalidated and the link reloaded, got %d recipes and %d visits", len(recipeRepo.recipes), page.visits)
+	}
+}
@@ -0,0 +1,40 @@
+package unsubscribe
+
+import (
+	"context"
+	"strings"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+// strategy is one way of unsubscribing from a mailing list. Strategies are tried
+// in order, cheapest and most reliable first, until one succeeds.
+type strategy interface {
+	Name() string
+	// CanHandle reports whether the email offers what this strategy needs
+	CanHandle(email *entities.Email) bool
+	Unsubscribe(ctx context.Context, email *entities.Email) (*repositories.UnsubscribeResult, error)
+}
+
+// listUnsubscribeTargets splits a List-Unsubscribe header (RFC 2369) into its
+// https and mailto targets, e.g. "<https://x.com/u?id=1>, <mailto:leave@x.com>"
+func listUnsubscribeTargets(header string) (httpsURLs, mailtoURLs []string) {
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if !strings.HasPrefix(part, "<") || !strings.HasSuffix(part, ">") {
+			continue
+		}
+		target := strings.TrimSpace(part[1 : len(part)-1])
+
+		lower := strings.ToLower(target)
+		switch {
+		case strings.HasPrefix(lower, "https://"):
+			httpsURLs = append(httpsURLs, target)
+		case strings.HasPrefix(lower, "mailto:"):
+			mailtoURLs = append(mailtoURLs, target)
+		}
+	}
+
+	return httpsURLs, mailtoURLs
+}
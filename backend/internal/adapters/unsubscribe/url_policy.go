@@ -0,0 +1,257 @@
+package unsubscribe
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// DefaultMaxRedirects bounds the redirect chain followed from an unsubscribe link
+const DefaultMaxRedirects = 5
+
+// ErrBlockedURL is returned for URLs the unsubscribe browser must not visit
+var ErrBlockedURL = errors.New("blocked url")
+
+// Ranges that IsPrivate/IsLoopback/IsLinkLocal* don't cover but that still reach
+// internal infrastructure
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved
+}
+
+// URLPolicy decides which URLs automated unsubscribes may request. It keeps the
+// browser and one-click requests away from internal addresses and known bad domains.
+type URLPolicy struct {
+	maxRedirects int
+	blocklist    map[string]bool
+	lookupIP     func(ctx context.Context, host string) ([]net.IP, error)
+	// allowLoopback lets tests talk to servers on 127.0.0.1
+	allowLoopback bool
+}
+
+func NewURLPolicy(maxRedirects int, blockedDomains []string) *URLPolicy {
+	if maxRedirects < 0 {
+		maxRedirects = DefaultMaxRedirects
+	}
+
+	blocklist := make(map[string]bool, len(blockedDomains))
+	for _, domain := range blockedDomains {
+		blocklist[normalizeHost(domain)] = true
+	}
+
+	return &URLPolicy{
+		maxRedirects: maxRedirects,
+		blocklist:    blocklist,
+		lookupIP: func(ctx context.Context, host string) ([]net.IP, error) {
+			return net.DefaultResolver.LookupIP(ctx, "ip", host)
+		},
+	}
+}
+
+// LoadBlocklist reads blocked domains from a file with one domain per line.
+// Blank lines and lines starting with # are ignored.
+func LoadBlocklist(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer file.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blocklist: %w", err)
+	}
+
+	return domains, nil
+}
+
+// MaxRedirects is the longest redirect chain a navigation may follow
+func (p *URLPolicy) MaxRedirects() int {
+	return p.maxRedirects
+}
+
+// CheckURL returns an error wrapping ErrBlockedURL if the URL isn't plain http(s),
+// is on the blocklist, or resolves to an address that isn't publicly routable
+func (p *URLPolicy) CheckURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: invalid url: %v", ErrBlockedURL, err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q is not allowed", ErrBlockedURL, parsed.Scheme)
+	}
+
+	host := normalizeHost(parsed.Hostname())
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrBlockedURL)
+	}
+
+	if domain, blocked := p.blockedDomain(host); blocked {
+		return fmt.Errorf("%w: %s is on the blocklist", ErrBlockedURL, domain)
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.checkAddr(addr)
+	}
+
+	ips, err := p.lookupIP(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%w: failed to resolve %s: %v", ErrBlockedURL, host, err)
+	}
+	// Every address must pass, since any of them may end up being used
+	for _, ip := range ips {
+		addr, ok := netip.AddrFromSlice(ip)
+		if !ok {
+			return fmt.Errorf("%w: invalid address for %s", ErrBlockedURL, host)
+		}
+		if err := p.checkAddr(addr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *URLPolicy) checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+
+	if addr.IsLoopback() && p.allowLoopback {
+		return nil
+	}
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf("%w: %s is not a public address", ErrBlockedURL, addr)
+	}
+
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: %s is not a public address", ErrBlockedURL, addr)
+		}
+	}
+
+	return nil
+}
+
+// blockedDomain checks the host and each of its parent domains against the blocklist
+func (p *URLPolicy) blockedDomain(host string) (string, bool) {
+	for domain := host; domain != ""; {
+		if p.blocklist[domain] {
+			return domain, true
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return "", false
+}
+
+// dialControl re-checks the address actually being connected to, so a host can't
+// pass CheckURL and then resolve to an internal address for the real request
+func (p *URLPolicy) dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: invalid address %s", ErrBlockedURL, address)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: invalid address %s", ErrBlockedURL, address)
+	}
+	return p.checkAddr(addr)
+}
+
+// HTTPClient returns a client that only connects to publicly routable addresses and
+// follows at most MaxRedirects redirects, each checked with CheckURL
+func (p *URLPolicy) HTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: p.transport(),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > p.maxRedirects {
+				return fmt.Errorf("%w: more than %d redirects", ErrBlockedURL, p.maxRedirects)
+			}
+			return p.CheckURL(req.Context(), req.URL.String())
+		},
+	}
+}
+
+// dialer checks the address actually dialed, not just the one CheckURL resolved
+func (p *URLPolicy) dialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   p.dialControl,
+	}
+}
+
+// transport is the default transport, with its timeout settings, dialing only addresses
+// the policy allows. It never uses a proxy: the dial check would then see the proxy's
+// address rather than the destination's.
+func (p *URLPolicy) transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = p.dialer().DialContext
+	transport.ResponseHeaderTimeout = 15 * time.Second
+	return transport
+}
+
+// SameSite reports whether two URLs share a registrable domain (eTLD+1), e.g.
+// https://mail.example.co.uk and https://www.example.co.uk
+func SameSite(a, b string) bool {
+	siteA, err := registrableDomain(a)
+	if err != nil {
+		return false
+	}
+	siteB, err := registrableDomain(b)
+	if err != nil {
+		return false
+	}
+	return siteA == siteB
+}
+
+func registrableDomain(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	host := normalizeHost(parsed.Hostname())
+	if host == "" {
+		return "", fmt.Errorf("missing host in %s", rawURL)
+	}
+	// IP addresses have no registrable domain; only the same address matches
+	if _, err := netip.ParseAddr(host); err == nil {
+		return host, nil
+	}
+
+	return publicsuffix.EffectiveTLDPlusOne(host)
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
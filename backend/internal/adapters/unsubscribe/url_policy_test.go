@@ -0,0 +1,162 @@
+package unsubscribe
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestURLPolicy allows loopback so tests can use httptest servers
+func newTestURLPolicy() *URLPolicy {
+	policy := NewURLPolicy(DefaultMaxRedirects, nil)
+	policy.allowLoopback = true
+	return policy
+}
+
+func TestURLPolicy_CheckURL(t *testing.T) {
+	policy := NewURLPolicy(DefaultMaxRedirects, []string{"Evil.example"})
+	policy.lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+		switch host {
+		case "news.example.com":
+			return []net.IP{net.ParseIP("93.184.216.34")}, nil
+		case "internal.example.com":
+			// One public and one private address: the private one could be used
+			return []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("10.0.0.5")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://news.example.com/unsubscribe", true},
+		{"http://news.example.com/unsubscribe", true},
+		{"https://internal.example.com/unsubscribe", false},
+		{"https://missing.example.com/unsubscribe", false},
+		{"https://127.0.0.1/unsubscribe", false},
+		{"https://[::1]/unsubscribe", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"https://192.168.1.1/", false},
+		{"https://100.64.0.1/", false},
+		{"https://[::ffff:10.0.0.1]/", false},
+		{"https://[fe80::1]/", false},
+		{"https://0.0.0.0/", false},
+		{"https://evil.example/unsubscribe", false},
+		{"https://track.EVIL.example./unsubscribe", false},
+		{"file:///etc/passwd", false},
+		{"javascript:alert(1)", false},
+		{"data:text/html,hi", false},
+		{"ftp://news.example.com/", false},
+	}
+
+	for _, tt := range tests {
+		err := policy.CheckURL(context.Background(), tt.url)
+		if tt.allowed && err != nil {
+			t.Errorf("Expected %s to be allowed, got %v", tt.url, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrBlockedURL) {
+			t.Errorf("Expected %s to be blocked, got %v", tt.url, err)
+		}
+	}
+}
+
+func TestURLPolicy_AllowLoopback(t *testing.T) {
+	policy := newTestURLPolicy()
+
+	if err := policy.CheckURL(context.Background(), "https://127.0.0.1:8443/unsubscribe"); err != nil {
+		t.Errorf("Expected loopback to be allowed, got %v", err)
+	}
+	if err := policy.CheckURL(context.Background(), "https://10.0.0.1/unsubscribe"); err == nil {
+		t.Error("Expected private addresses to stay blocked")
+	}
+}
+
+func TestURLPolicy_DialControl(t *testing.T) {
+	policy := NewURLPolicy(DefaultMaxRedirects, nil)
+
+	if err := policy.dialControl("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("Expected public address to be dialable, got %v", err)
+	}
+	if err := policy.dialControl("tcp", "127.0.0.1:443", nil); !errors.Is(err, ErrBlockedURL) {
+		t.Errorf("Expected loopback dial to be blocked, got %v", err)
+	}
+}
+
+func TestURLPolicy_HTTPClientIgnoresProxy(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://10.0.0.1:3128")
+	client := NewURLPolicy(DefaultMaxRedirects, nil).HTTPClient(time.Second)
+
+	if proxy := client.Transport.(*http.Transport).Proxy; proxy != nil {
+		t.Error("Expected the policy's client not to use a proxy, whose address the dial check would see instead of the destination's")
+	}
+}
+
+func TestLoadBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	content := "# phishing domains\nevil.example\n\n  bad.example  \n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	domains, err := LoadBlocklist(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(domains) != 2 || domains[0] != "evil.example" || domains[1] != "bad.example" {
+		t.Errorf("Unexpected domains: %v", domains)
+	}
+}
+
+func TestSameSite(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"https://mail.example.com/u", "https://www.example.com/done", true},
+		{"https://mail.example.co.uk/u", "https://example.co.uk/", true},
+		{"https://example.com/u", "https://example.org/u", false},
+		{"https://alice.github.io/u", "https://bob.github.io/u", false},
+		{"https://93.184.216.34/u", "https://93.184.216.34/done", true},
+	}
+
+	for _, tt := range tests {
+		if got := SameSite(tt.a, tt.b); got != tt.same {
+			t.Errorf("SameSite(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.same)
+		}
+	}
+}
+
+func TestNavigationGuard(t *testing.T) {
+	policy := NewURLPolicy(2, nil)
+	policy.lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("93.184.216.34")}, nil
+	}
+	guard := newNavigationGuard(context.Background(), policy)
+
+	// Relative locations resolve against the redirecting URL
+	next, err := guard.followRedirect("https://click.tracker.com/a", "https://news.example.com/u")
+	if err != nil || next != "https://news.example.com/u" {
+		t.Fatalf("Expected first redirect to be followed, got %s, %v", next, err)
+	}
+	next, err = guard.followRedirect(next, "/unsubscribe?id=1")
+	if err != nil || next != "https://news.example.com/unsubscribe?id=1" {
+		t.Fatalf("Expected second redirect to be followed, got %s, %v", next, err)
+	}
+	if _, err := guard.followRedirect(next, "/again"); !errors.Is(err, ErrBlockedURL) {
+		t.Errorf("Expected third redirect to exceed the limit, got %v", err)
+	}
+
+	guard.lockSite("https://news.example.com/unsubscribe?id=1")
+	if err := guard.checkNavigation("https://www.example.com/done"); err != nil {
+		t.Errorf("Expected navigation within the site to be allowed, got %v", err)
+	}
+	if err := guard.checkNavigation("https://elsewhere.com/"); !errors.Is(err, ErrBlockedURL) {
+		t.Errorf("Expected navigation off the site to be blocked, got %v", err)
+	}
+}
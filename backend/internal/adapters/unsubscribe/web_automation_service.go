@@ -2,8 +2,10 @@ package unsubscribe
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,122 +14,174 @@ import (
 	"github.com/playwright-community/playwright-go"
 )
 
+// errElementNotFound is returned by executeAction when the page has no element for the action
+var errElementNotFound = errors.New("element not found")
+
 type WebAutomationService struct {
-	aiService  repositories.AIService
-	timeout    time.Duration
-	playwright *playwright.Playwright
-	browser    playwright.Browser
+	aiService     repositories.AIService
+	policy        *URLPolicy
+	artifactStore repositories.ArtifactStore
+	recipeRepo    repositories.UnsubscribeRecipeRepository
+	timeout       time.Duration
+	pool          *browserPool
+	strategies    []strategy
 }
 
-func NewWebAutomationService(aiService repositories.AIService) *WebAutomationService {
-	return &WebAutomationService{
-		aiService: aiService,
-		timeout:   30 * time.Second,
+func NewWebAutomationService(
+	aiService repositories.AIService,
+	gmailService repositories.GmailService,
+	accountRepo repositories.AccountRepository,
+	policy *URLPolicy,
+	artifactStore repositories.ArtifactStore,
+	recipeRepo repositories.UnsubscribeRecipeRepository,
+	maxBrowserContexts int,
+) *WebAutomationService {
+	if policy == nil {
+		policy = NewURLPolicy(DefaultMaxRedirects, nil)
+	}
+
+	w := &WebAutomationService{
+		aiService:     aiService,
+		policy:        policy,
+		artifactStore: artifactStore,
+		recipeRepo:    recipeRepo,
+		timeout:       30 * time.Second,
+		pool:          newBrowserPool(maxBrowserContexts, policy),
+	}
+
+	// Browser automation is slow and AI-driven, so it is the last resort
+	w.strategies = []strategy{
+		newOneClickStrategy(nil, policy),
+		newMailtoStrategy(gmailService, accountRepo),
+		&browserStrategy{service: w},
 	}
+
+	return w
 }
 
-func (w *WebAutomationService) initBrowser(ctx context.Context) error {
-	if w.playwright == nil {
-		pw, err := playwright.Run()
-		if err != nil {
-			return fmt.Errorf("failed to run playwright: %w", err)
+// Start runs the browser health checks until ctx is cancelled
+func (w *WebAutomationService) Start(ctx context.Context) {
+	w.pool.Start(ctx)
+}
+
+// Shutdown waits for running browser unsubscribes to finish, or ctx to expire,
+// and closes the browser
+func (w *WebAutomationService) Shutdown(ctx context.Context) error {
+	return w.pool.Shutdown(ctx)
+}
+
+// UnsubscribeFromEmail tries each strategy the email supports in order and
+// returns the first success, or the last failure if none succeeded
+func (w *WebAutomationService) UnsubscribeFromEmail(ctx context.Context, email *entities.Email) (*repositories.UnsubscribeResult, error) {
+	return w.UnsubscribeExcluding(ctx, email, nil)
+}
+
+func (w *WebAutomationService) UnsubscribeExcluding(ctx context.Context, email *entities.Email, excludedStrategies []string) (*repositories.UnsubscribeResult, error) {
+	excluded := false
+	var lastResult *repositories.UnsubscribeResult
+	for _, candidate := range w.strategies {
+		if !candidate.CanHandle(email) {
+			continue
 		}
-		w.playwright = pw
-	}
-
-	if w.browser == nil {
-		browser, err := w.playwright.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
-			Headless: playwright.Bool(true),
-			Args: []string{
-				"--no-sandbox",
-				"--disable-setuid-sandbox",
-				"--disable-dev-shm-usage",
-				"--disable-accelerated-2d-canvas",
-				"--no-first-run",
-				"--no-zygote",
-				"--disable-gpu",
-			},
-		})
-		if err != nil {
-			return fmt.Errorf("failed to launch browser: %w", err)
+		if slices.Contains(excludedStrategies, candidate.Name()) {
+			excluded = true
+			continue
+		}
+
+		result := runStrategy(ctx, candidate, email)
+		if result.Success {
+			return result, nil
 		}
-		w.browser = browser
+
+		fmt.Printf("Warning: %s unsubscribe failed for email %d: %s\n", candidate.Name(), email.ID, result.Message)
+		lastResult = result
 	}
 
-	return nil
+	if lastResult == nil {
+		return noStrategyResult(excluded), nil
+	}
+
+	return lastResult, nil
 }
 
-func (w *WebAutomationService) Close() error {
-	if w.browser != nil {
-		if err := w.browser.Close(); err != nil {
-			return fmt.Errorf("failed to close browser: %w", err)
-		}
-	}
-	if w.playwright != nil {
-		if err := w.playwright.Stop(); err != nil {
-			return fmt.Errorf("failed to stop playwright: %w", err)
+// runStrategy runs one strategy, turning errors into a failed result
+func runStrategy(ctx context.Context, candidate strategy, email *entities.Email) *repositories.UnsubscribeResult {
+	result, err := candidate.Unsubscribe(ctx, email)
+	if err != nil {
+		result = &repositories.UnsubscribeResult{
+			Success:   false,
+			Message:   fmt.Sprintf("Failed to process unsubscribe: %v", err),
+			ErrorType: "processing_error",
 		}
 	}
-	return nil
+	result.Strategy = candidate.Name()
+	return result
 }
 
-func (w *WebAutomationService) UnsubscribeFromEmail(ctx context.Context, email *entities.Email) (*repositories.UnsubscribeResult, error) {
-	if email.UnsubscribeLink == nil || *email.UnsubscribeLink == "" {
+// noStrategyResult is the failure for an email no strategy could be tried on
+func noStrategyResult(excluded bool) *repositories.UnsubscribeResult {
+	if excluded {
 		return &repositories.UnsubscribeResult{
 			Success:   false,
-			Message:   "No unsubscribe link found in email",
-			ErrorType: "no_link",
-		}, nil
+			Message:   "No other unsubscribe method is available for this email",
+			ErrorType: "no_strategy",
+		}
 	}
 
-	link := *email.UnsubscribeLink
+	return &repositories.UnsubscribeResult{
+		Success:   false,
+		Message:   "No unsubscribe link found in email",
+		ErrorType: "no_link",
+	}
+}
 
-	// Validate the link before processing
+func (w *WebAutomationService) ValidateUnsubscribeLink(ctx context.Context, link string) (bool, error) {
 	if !w.isValidUnsubscribeLink(link) {
-		return &repositories.UnsubscribeResult{
-			Success:   false,
-			Message:   "Invalid or suspicious unsubscribe link",
-			ErrorType: "invalid_link",
-		}, nil
+		return false, nil
 	}
+	return w.policy.CheckURL(ctx, link) == nil, nil
+}
 
-	// For now, implement a simplified approach that handles common unsubscribe patterns
-	// This can be extended with actual browser automation using Playwright
-	result, err := w.processUnsubscribeLink(ctx, link, email)
-	if err != nil {
-		return &repositories.UnsubscribeResult{
-			Success:   false,
-			Message:   fmt.Sprintf("Failed to process unsubscribe: %v", err),
-			ErrorType: "processing_error",
-		}, nil
-	}
-	return result, nil
+// browserStrategy opens the unsubscribe link in headless Chromium and lets the AI
+// work out which elements to click
+type browserStrategy struct {
+	service *WebAutomationService
 }
 
-func (w *WebAutomationService) BulkUnsubscribe(ctx context.Context, emails []*entities.Email) (map[int64]*repositories.UnsubscribeResult, error) {
-	results := make(map[int64]*repositories.UnsubscribeResult)
+func (s *browserStrategy) Name() string {
+	return repositories.UnsubscribeStrategyBrowser
+}
 
-	for _, email := range emails {
-		// Add delay between requests to be respectful
-		time.Sleep(2 * time.Second)
+func (s *browserStrategy) CanHandle(email *entities.Email) bool {
+	return email.UnsubscribeLink != nil && *email.UnsubscribeLink != ""
+}
 
-		result, err := w.UnsubscribeFromEmail(ctx, email)
-		if err != nil {
-			results[email.ID] = &repositories.UnsubscribeResult{
-				Success:   false,
-				Message:   fmt.Sprintf("Error: %v", err),
-				ErrorType: "processing_error",
-			}
-		} else {
-			results[email.ID] = result
-		}
+func (s *browserStrategy) Unsubscribe(ctx context.Context, email *entities.Email) (*repositories.UnsubscribeResult, error) {
+	link := *email.UnsubscribeLink
+
+	// Validate the link before processing
+	if result := s.service.checkLink(ctx, link); result != nil {
+		return result, nil
 	}
 
-	return results, nil
+	return s.service.processUnsubscribeLink(ctx, link, email)
 }
 
-func (w *WebAutomationService) ValidateUnsubscribeLink(ctx context.Context, link string) (bool, error) {
-	return w.isValidUnsubscribeLink(link), nil
+// checkLink returns a failed result if the browser must not open the link
+func (w *WebAutomationService) checkLink(ctx context.Context, link string) *repositories.UnsubscribeResult {
+	if !w.isValidUnsubscribeLink(link) {
+		return &repositories.UnsubscribeResult{
+			Success:   false,
+			Message:   "Invalid or suspicious unsubscribe link",
+			ErrorType: "invalid_link",
+		}
+	}
+
+	if err := w.policy.CheckURL(ctx, link); err != nil {
+		return blockedURLResult(err)
+	}
+
+	return nil
 }
 
 func (w *WebAutomationService) isValidUnsubscribeLink(link string) bool {
@@ -162,39 +216,108 @@ func (w *WebAutomationService) isValidUnsubscribeLink(link string) bool {
 }
 
 func (w *WebAutomationService) processUnsubscribeLink(ctx context.Context, link string, email *entities.Email) (*repositories.UnsubscribeResult, error) {
-	// Initialize browser if not already done
-	if err := w.initBrowser(ctx); err != nil {
+	return w.withEvidence(ctx, email, func(page playwright.Page, guard *navigationGuard, record *evidence) *repositories.UnsubscribeResult {
+		if result := w.openPage(page, guard, link); result != nil {
+			return result
+		}
+		record.screenshot(page, "before")
+
+		// Pages from the same sender platform usually unsubscribe the same way, so a
+		// recipe learned on an earlier attempt saves asking the AI
+		pageURL := page.URL()
+		if result := w.replayRecipe(ctx, page, guard, record, link, email); result != nil {
+			return result
+		}
+
+		analysis, result := w.planActions(ctx, page, link)
+		if result != nil {
+			return result
+		}
+
+		result, verified := w.runActions(page, guard, record, analysis.Actions, email)
+		if verified {
+			w.learnRecipe(ctx, pageURL, analysis.Actions, email)
+		}
+
+		return result
+	}), nil
+}
+
+// withPage opens a page in its own browser context and passes it to fn. Each
+// attempt gets its own context so cookies and routes don't leak between senders.
+func (w *WebAutomationService) withPage(ctx context.Context, fn func(page playwright.Page, guard *navigationGuard) *repositories.UnsubscribeResult) *repositories.UnsubscribeResult {
+	browserContext, release, err := w.pool.acquire(ctx)
+	if err != nil {
 		return &repositories.UnsubscribeResult{
 			Success:   false,
 			Message:   fmt.Sprintf("Failed to initialize browser: %v", err),
 			ErrorType: "browser_init_error",
-		}, nil
+		}
+	}
+	defer release()
+
+	// Every request the page makes goes through the URL policy
+	guard := newNavigationGuard(ctx, w.policy)
+	if err := browserContext.Route("**/*", guard.route); err != nil {
+		return &repositories.UnsubscribeResult{
+			Success:   false,
+			Message:   fmt.Sprintf("Failed to install URL policy: %v", err),
+			ErrorType: "page_creation_error",
+		}
 	}
 
 	// Create a new page
-	page, err := w.browser.NewPage()
+	page, err := browserContext.NewPage()
 	if err != nil {
 		return &repositories.UnsubscribeResult{
 			Success:   false,
 			Message:   fmt.Sprintf("Failed to create new page: %v", err),
 			ErrorType: "page_creation_error",
-		}, nil
+		}
 	}
 	defer page.Close()
 
 	// Set page timeout
 	page.SetDefaultTimeout(float64(w.timeout.Milliseconds()))
 
-	// Navigate to the unsubscribe page
-	_, err = page.Goto(link, playwright.PageGotoOptions{
+	return fn(page, guard)
+}
+
+// withEvidence works like withPage and attaches the evidence fn collected to its result
+func (w *WebAutomationService) withEvidence(ctx context.Context, email *entities.Email, fn func(page playwright.Page, guard *navigationGuard, record *evidence) *repositories.UnsubscribeResult) *repositories.UnsubscribeResult {
+	return w.withPage(ctx, func(page playwright.Page, guard *navigationGuard) *repositories.UnsubscribeResult {
+		// Evidence is only collected when there is somewhere to keep it
+		var record *evidence
+		if w.artifactStore != nil {
+			record = newEvidence(email.ID)
+		}
+
+		result := fn(page, guard, record)
+
+		// Keep a record of what the page looked like, whatever the outcome
+		if record != nil {
+			record.snapshot(page)
+			result.Artifacts = record.save(ctx, w.artifactStore)
+		}
+
+		return result
+	})
+}
+
+// openPage navigates to link and waits for the page to settle
+func (w *WebAutomationService) openPage(page playwright.Page, guard *navigationGuard, link string) *repositories.UnsubscribeResult {
+	_, err := page.Goto(link, playwright.PageGotoOptions{
 		WaitUntil: playwright.WaitUntilStateLoad,
 	})
+	if blocked := guard.Blocked(); blocked != nil {
+		return blockedURLResult(blocked)
+	}
 	if err != nil {
 		return &repositories.UnsubscribeResult{
 			Success:   false,
 			Message:   fmt.Sprintf("Failed to navigate to page: %v", err),
 			ErrorType: "navigation_error",
-		}, nil
+		}
 	}
 
 	// Wait for page to fully load
@@ -205,53 +328,76 @@ func (w *WebAutomationService) processUnsubscribeLink(ctx context.Context, link
 		fmt.Printf("Warning: page didn't reach networkidle state: %v\n", err)
 	}
 
+	return nil
+}
+
+// planActions has the AI analyze the open page and work out the actions to unsubscribe
+func (w *WebAutomationService) planActions(ctx context.Context, page playwright.Page, link string) (*repositories.UnsubscribePageAnalysis, *repositories.UnsubscribeResult) {
 	// Get page content for AI analysis
 	content, err := page.Content()
 	if err != nil {
-		return &repositories.UnsubscribeResult{
+		return nil, &repositories.UnsubscribeResult{
 			Success:   false,
 			Message:   fmt.Sprintf("Failed to get page content: %v", err),
 			ErrorType: "content_extraction_error",
-		}, nil
+		}
 	}
 
 	// Use AI to analyze the page and determine actions
 	analysis, err := w.aiService.AnalyzeUnsubscribePage(ctx, content, link)
 	if err != nil {
-		return &repositories.UnsubscribeResult{
+		return nil, &repositories.UnsubscribeResult{
 			Success:   false,
 			Message:   fmt.Sprintf("Failed to analyze page with AI: %v", err),
 			ErrorType: "ai_analysis_error",
-		}, nil
+		}
 	}
 
 	// Handle authentication requirement
 	if analysis.RequiresAuth {
-		return &repositories.UnsubscribeResult{
+		return nil, &repositories.UnsubscribeResult{
 			Success:      false,
 			Message:      "Page requires authentication to unsubscribe",
 			ErrorType:    "auth_required",
 			RequiresAuth: true,
-		}, nil
+		}
 	}
 
 	// If AI indicates it's not an unsubscribe page
 	if !analysis.IsUnsubscribePage {
-		return &repositories.UnsubscribeResult{
+		return nil, &repositories.UnsubscribeResult{
 			Success:   false,
 			Message:   "Page does not appear to be a valid unsubscribe page",
 			ErrorType: "invalid_page",
-		}, nil
+		}
 	}
 
-	// Execute the actions determined by AI
-	for i, action := range analysis.Actions {
-		if err := w.executeAction(page, action); err != nil {
+	return analysis, nil
+}
+
+// runActions carries out planned actions on the open page and checks whether the
+// unsubscribe worked. verified reports whether the page confirmed it.
+func (w *WebAutomationService) runActions(page playwright.Page, guard *navigationGuard, record *evidence, actions []repositories.UnsubscribeAction, email *entities.Email) (result *repositories.UnsubscribeResult, verified bool) {
+	// Actions may submit forms or follow links, but only within the site we landed on
+	guard.lockSite(page.URL())
+
+	// Execute the planned actions
+	for i, action := range actions {
+		err := w.executeAction(page, action)
+		record.recordAction(action, err)
+		if blocked := guard.Blocked(); blocked != nil {
+			return blockedURLResult(blocked), false
+		}
+		if err != nil {
+			errorType := "action_execution_error"
+			if errors.Is(err, errElementNotFound) {
+				errorType = "element_not_found"
+			}
 			return &repositories.UnsubscribeResult{
 				Success:   false,
 				Message:   fmt.Sprintf("Failed to execute action %d (%s): %v", i+1, action.Action, err),
-				ErrorType: "action_execution_error",
-			}, nil
+				ErrorType: errorType,
+			}, false
 		}
 
 		// Small delay between actions
@@ -265,12 +411,22 @@ func (w *WebAutomationService) processUnsubscribeLink(ctx context.Context, link
 		return &repositories.UnsubscribeResult{
 			Success: true,
 			Message: fmt.Sprintf("Successfully unsubscribed from %s", email.Sender),
-		}, nil
+		}, true
 	} else {
+		// Not counted as an unsubscribe, so the sender isn't held to it
 		return &repositories.UnsubscribeResult{
-			Success: true, // Still consider it successful if actions completed
-			Message: fmt.Sprintf("Unsubscribe actions completed for %s (verification unclear)", email.Sender),
-		}, nil
+			Success:   false,
+			Message:   fmt.Sprintf("Unsubscribe actions completed for %s (verification unclear)", email.Sender),
+			ErrorType: "unverified",
+		}, false
+	}
+}
+
+func blockedURLResult(err error) *repositories.UnsubscribeResult {
+	return &repositories.UnsubscribeResult{
+		Success:   false,
+		Message:   fmt.Sprintf("Unsubscribe link blocked by URL policy: %v", err),
+		ErrorType: "blocked_url",
 	}
 }
 
@@ -292,14 +448,14 @@ func (w *WebAutomationService) executeAction(page playwright.Page, action reposi
 					}
 				}
 			}
-			return fmt.Errorf("element not found: %s", action.Selector)
+			return fmt.Errorf("%w: %s", errElementNotFound, action.Selector)
 		}
 		return element.Click()
 
 	case "fill":
 		element, err := page.QuerySelector(action.Selector)
 		if err != nil || element == nil {
-			return fmt.Errorf("input element not found: %s", action.Selector)
+			return fmt.Errorf("input %w: %s", errElementNotFound, action.Selector)
 		}
 		return element.Fill(action.Value)
 
@@ -322,7 +478,7 @@ func (w *WebAutomationService) executeAction(page playwright.Page, action reposi
 		if action.Selector != "" {
 			element, err := page.QuerySelector(action.Selector)
 			if err != nil || element == nil {
-				return fmt.Errorf("form not found: %s", action.Selector)
+				return fmt.Errorf("form %w: %s", errElementNotFound, action.Selector)
 			}
 			return element.Click() // Usually submit button
 		} else {
@@ -331,7 +487,7 @@ func (w *WebAutomationService) executeAction(page playwright.Page, action reposi
 			if err == nil && submitBtn != nil {
 				return submitBtn.Click()
 			}
-			return fmt.Errorf("no submit button found")
+			return fmt.Errorf("submit button %w", errElementNotFound)
 		}
 
 	default:
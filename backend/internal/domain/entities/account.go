@@ -14,6 +14,7 @@ type Account struct {
 	RefreshToken      string    `json:"-"`
 	TokenExpiry       time.Time `json:"-"`
 	LastSyncHistoryID *string   `json:"-"`
+	AutoSummarize     bool      `json:"auto_summarize"`
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
 }
@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+// Attachment is a file attached to an email. Its content stays in Gmail and is
+// fetched when downloaded.
+type Attachment struct {
+	ID        int64  `json:"id"`
+	EmailID   int64  `json:"email_id"`
+	Filename  string `json:"filename"`
+	MimeType  string `json:"mime_type"`
+	Size      int64  `json:"size"`
+	ContentID string `json:"content_id,omitempty"`
+	Inline    bool   `json:"inline"`
+	// Gmail keeps large parts behind an attachment ID and small ones inline in the
+	// message part with this ID
+	GmailAttachmentID string `json:"gmail_attachment_id,omitempty"`
+	GmailPartID       string `json:"-"`
+	// ExtractedText is the text of a document, indexed for search and given to AI categorization
+	ExtractedText *string `json:"-"`
+	HasText       bool    `json:"has_text"`
+	// TextExtracted is set once the text has been extracted, or when there is none to extract
+	TextExtracted bool      `json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// Data holds the content during sync when the message carried it; it is not persisted
+	Data []byte `json:"-"`
+}
+
+// DownloadName returns a file name that is safe to offer in a download
+func (a *Attachment) DownloadName() string {
+	// Senders control the name: keep the base name only and drop characters that
+	// would break the Content-Disposition header
+	name := strings.Map(func(r rune) rune {
+		if r < ' ' || r == '"' || r == '\\' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, path.Base(strings.ReplaceAll(a.Filename, "\\", "/")))
+
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" || name == ".." {
+		return "attachment"
+	}
+	return name
+}
@@ -0,0 +1,24 @@
+package entities
+
+import "testing"
+
+func TestAttachment_DownloadName(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected string
+	}{
+		{"invoice.pdf", "invoice.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Users\me\report.docx`, "report.docx"},
+		{"quote\"d\r\nname.txt", "quotedname.txt"},
+		{"", "attachment"},
+		{"..", "attachment"},
+	}
+
+	for _, tt := range tests {
+		attachment := &Attachment{Filename: tt.filename}
+		if got := attachment.DownloadName(); got != tt.expected {
+			t.Errorf("DownloadName(%q): expected %q, got %q", tt.filename, tt.expected, got)
+		}
+	}
+}
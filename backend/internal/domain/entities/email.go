@@ -1,6 +1,11 @@
 package entities
 
-import "time"
+import (
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+)
 
 type Email struct {
 	ID                int64      `json:"id"`
@@ -8,15 +13,59 @@ type Email struct {
 	CategoryIDs       []int64    `json:"category_ids"`
 	Categories        []Category `json:"categories,omitempty"`
 	GmailMessageID    string     `json:"gmail_message_id"`
+	GmailThreadID     string     `json:"gmail_thread_id"`
+	ThreadID          *int64     `json:"thread_id"`
+	SenderID          *int64     `json:"sender_id"`
 	Sender            string     `json:"sender"`
 	Subject           string     `json:"subject"`
 	Body              string     `json:"body"`
+	BodyText          string     `json:"body_text"`
+	AttachmentText    string     `json:"-"`
 	AISummary         *string    `json:"ai_summary"`
 	ReceivedAt        time.Time  `json:"received_at"`
 	IsArchivedInGmail bool       `json:"is_archived_in_gmail"`
 	UnsubscribeLink   *string    `json:"unsubscribe_link"`
-	CreatedAt         time.Time  `json:"created_at"`
-	UpdatedAt         time.Time  `json:"updated_at"`
+	// Raw List-Unsubscribe and List-Unsubscribe-Post headers (RFC 2369 / RFC 8058)
+	ListUnsubscribe     string   `json:"list_unsubscribe,omitempty"`
+	ListUnsubscribePost string   `json:"list_unsubscribe_post,omitempty"`
+	RiskScore           *float64 `json:"risk_score"`
+	RiskReasons         []string `json:"risk_reasons,omitempty"`
+	// Received after the grace period of a successful unsubscribe from the sender
+	MailedAfterUnsubscribe bool `json:"mailed_after_unsubscribe"`
+	// Covered by the AI summary of the email's thread
+	ThreadSummarized bool `json:"-"`
+	// Recipient addresses, lower-cased
+	To      []string `json:"to"`
+	Cc      []string `json:"cc"`
+	ReplyTo string   `json:"reply_to,omitempty"`
+	// RFC 5322 message IDs, used to thread replies
+	MessageID  string   `json:"message_id,omitempty"`
+	InReplyTo  string   `json:"in_reply_to,omitempty"`
+	References []string `json:"references,omitempty"`
+	// ListID identifies the mailing list the email was sent through (RFC 2919)
+	ListID string `json:"list_id,omitempty"`
+	// Snippet and SizeEstimate are as reported by Gmail
+	Snippet      string    `json:"snippet"`
+	SizeEstimate int64     `json:"size_estimate"`
+	IsRead       bool      `json:"is_read"`
+	IsStarred    bool      `json:"is_starred"`
+	TrackerCount *int      `json:"tracker_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Headers holds the raw message headers during sync; they are not persisted
+	Headers map[string]string `json:"-"`
+	// Attachments are carried from Gmail during sync and stored separately
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// PlainBody returns the plain text body, or Body for emails stored before plain
+// text bodies were kept
+func (e *Email) PlainBody() string {
+	if e.BodyText != "" {
+		return e.BodyText
+	}
+	return e.Body
 }
 
 type EmailCategory struct {
@@ -27,16 +76,106 @@ type EmailCategory struct {
 }
 
 type GmailMessage struct {
-	ID              string
-	Sender          string
-	Subject         string
-	Body            string
+	ID       string
+	ThreadID string
+	Sender   string
+	Subject  string
+	// Body is the HTML body as sent, or the plain text one for text-only messages
+	Body string
+	// TextBody is the plain text body, converted from HTML when the message has no text part
+	TextBody        string
+	TrackerCount    int
+	Attachments     []Attachment
 	Headers         map[string]string
 	Labels          []string
 	UnsubscribeLink *string
+	Snippet         string
+	SizeEstimate    int64
 	ReceivedAt      time.Time
 }
 
+// Gmail system labels that carry message state
+const (
+	GmailLabelUnread  = "UNREAD"
+	GmailLabelStarred = "STARRED"
+)
+
+// Header returns the value of the named header, matching the name case-insensitively
+// since senders differ in how they spell e.g. Message-ID
+func (m *GmailMessage) Header(name string) string {
+	if value, exists := m.Headers[name]; exists {
+		return value
+	}
+	for key, value := range m.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// HasLabel reports whether the message carries the Gmail label with the given ID
+func (m *GmailMessage) HasLabel(labelID string) bool {
+	return slices.Contains(m.Labels, labelID)
+}
+
+// AddressList returns the lower-cased addresses in an address header such as To or
+// Cc. Malformed headers are split on commas so a bad entry doesn't lose the rest.
+func (m *GmailMessage) AddressList(name string) []string {
+	value := m.Header(name)
+	if strings.TrimSpace(value) == "" {
+		return []string{}
+	}
+
+	addresses := []string{}
+	if parsed, err := mail.ParseAddressList(value); err == nil {
+		for _, address := range parsed {
+			addresses = append(addresses, strings.ToLower(address.Address))
+		}
+		return addresses
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		if address := SenderAddress(part); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// MessageIDList returns the message IDs in a header such as References
+func (m *GmailMessage) MessageIDList(name string) []string {
+	return strings.Fields(m.Header(name))
+}
+
+// ListID returns the list identifier from the List-Id header, e.g. "news.example.com"
+// for "Example News <news.example.com>"
+func (m *GmailMessage) ListID() string {
+	value := m.Header("List-Id")
+	if matches := angleAddressRegex.FindStringSubmatch(value); len(matches) > 1 {
+		return strings.ToLower(strings.TrimSpace(matches[1]))
+	}
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// OutgoingMessage is a plain-text message created through Gmail, optionally as a reply
+type OutgoingMessage struct {
+	To         string
+	Subject    string
+	Body       string
+	ThreadID   string
+	InReplyTo  string
+	References string
+}
+
+type DraftReply struct {
+	DraftID  string `json:"draft_id"`
+	ThreadID string `json:"thread_id"`
+	To       string `json:"to"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body"`
+}
+
 type GmailLabel struct {
 	ID   string
 	Name string
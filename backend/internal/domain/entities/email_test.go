@@ -0,0 +1,74 @@
+package entities
+
+import "testing"
+
+func TestGmailMessage_Header(t *testing.T) {
+	msg := &GmailMessage{
+		Headers: map[string]string{
+			"Message-Id": "<abc@example.com>",
+			"Subject":    "Hello",
+		},
+	}
+
+	if got := msg.Header("Message-ID"); got != "<abc@example.com>" {
+		t.Errorf("Expected Message-ID to be '<abc@example.com>', got '%s'", got)
+	}
+
+	if got := msg.Header("Subject"); got != "Hello" {
+		t.Errorf("Expected Subject to be 'Hello', got '%s'", got)
+	}
+
+	if got := msg.Header("References"); got != "" {
+		t.Errorf("Expected missing header to be empty, got '%s'", got)
+	}
+}
+
+func TestGmailMessage_AddressList(t *testing.T) {
+	msg := &GmailMessage{
+		Headers: map[string]string{
+			"To": `"Doe, Jane" <Jane@Example.com>, bob@example.com`,
+			"Cc": "Team <team@example.com>, not an address <ops@example.com",
+		},
+	}
+
+	to := msg.AddressList("To")
+	if len(to) != 2 || to[0] != "jane@example.com" || to[1] != "bob@example.com" {
+		t.Errorf("Expected both To addresses lower-cased, got %v", to)
+	}
+
+	cc := msg.AddressList("Cc")
+	if len(cc) != 2 || cc[0] != "team@example.com" {
+		t.Errorf("Expected a malformed Cc header to keep its entries, got %v", cc)
+	}
+
+	if bcc := msg.AddressList("Bcc"); len(bcc) != 0 {
+		t.Errorf("Expected a missing header to give no addresses, got %v", bcc)
+	}
+}
+
+func TestGmailMessage_ListID(t *testing.T) {
+	tests := map[string]string{
+		"Example News <News.Example.com>": "news.example.com",
+		"<list.example.org>":              "list.example.org",
+		"bare.example.net":                "bare.example.net",
+		"":                                "",
+	}
+
+	for header, expected := range tests {
+		msg := &GmailMessage{Headers: map[string]string{"List-Id": header}}
+		if got := msg.ListID(); got != expected {
+			t.Errorf("Expected List-Id '%s' to give '%s', got '%s'", header, expected, got)
+		}
+	}
+}
+
+func TestGmailMessage_MessageIDList(t *testing.T) {
+	msg := &GmailMessage{
+		Headers: map[string]string{"References": "<a@example.com>\r\n <b@example.com>"},
+	}
+
+	references := msg.MessageIDList("References")
+	if len(references) != 2 || references[0] != "<a@example.com>" || references[1] != "<b@example.com>" {
+		t.Errorf("Expected both references, got %v", references)
+	}
+}
@@ -0,0 +1,44 @@
+package entities
+
+import "time"
+
+const (
+	ActionItemStatusOpen = "open"
+	ActionItemStatusDone = "done"
+)
+
+const (
+	ExtractedEntityAmount         = "amount"
+	ExtractedEntityPerson         = "person"
+	ExtractedEntityTrackingNumber = "tracking_number"
+	ExtractedEntityMeetingTime    = "meeting_time"
+	ExtractedEntityDate           = "date"
+)
+
+type ActionItem struct {
+	ID           int64      `json:"id"`
+	EmailID      int64      `json:"email_id"`
+	AccountID    int64      `json:"account_id"`
+	Description  string     `json:"description"`
+	DueAt        *time.Time `json:"due_at"`
+	Status       string     `json:"status"`
+	EmailSubject string     `json:"email_subject,omitempty"`
+	EmailSender  string     `json:"email_sender,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type ExtractedEntity struct {
+	ID        int64      `json:"id"`
+	EmailID   int64      `json:"email_id"`
+	Type      string     `json:"type"`
+	Value     string     `json:"value"`
+	OccursAt  *time.Time `json:"occurs_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type EmailExtraction struct {
+	EmailID     int64             `json:"email_id"`
+	ActionItems []ActionItem      `json:"action_items"`
+	Entities    []ExtractedEntity `json:"entities"`
+}
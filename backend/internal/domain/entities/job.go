@@ -0,0 +1,74 @@
+package entities
+
+import "time"
+
+// What a job does with the email of each of its items
+const (
+	JobKindUnsubscribe = "unsubscribe"
+	JobKindArchive     = "archive"
+)
+
+// Job states
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusCancelled = "cancelled"
+)
+
+// Job item states
+const (
+	JobItemStatusPending   = "pending"
+	JobItemStatusSucceeded = "succeeded"
+	JobItemStatusFailed    = "failed"
+	JobItemStatusSkipped   = "skipped"
+	JobItemStatusCancelled = "cancelled"
+)
+
+// Job is a bulk operation on emails, such as unsubscribing from or archiving
+// them, processed in the background one item per email.
+type Job struct {
+	ID         int64      `json:"id"`
+	Kind       string     `json:"kind"`
+	Status     string     `json:"status"`
+	Total      int        `json:"total"`
+	Processed  int        `json:"processed"`
+	Succeeded  int        `json:"succeeded"`
+	Failed     int        `json:"failed"`
+	Items      []JobItem  `json:"items,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+type JobItem struct {
+	ID        int64     `json:"id"`
+	JobID     int64     `json:"job_id"`
+	EmailID   int64     `json:"email_id"`
+	Status    string    `json:"status"`
+	Strategy  string    `json:"strategy,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	ErrorType string    `json:"error_type,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsFinished reports whether the job has stopped for good
+func (j *Job) IsFinished() bool {
+	return j.Status == JobStatusCompleted || j.Status == JobStatusCancelled
+}
+
+// Tally recomputes the progress counters from the job's items
+func (j *Job) Tally() {
+	j.Total, j.Processed, j.Succeeded, j.Failed = len(j.Items), 0, 0, 0
+	for _, item := range j.Items {
+		switch item.Status {
+		case JobItemStatusSucceeded:
+			j.Succeeded++
+		case JobItemStatusFailed:
+			j.Failed++
+		}
+		if item.Status != JobItemStatusPending {
+			j.Processed++
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package entities
+
+// SuspiciousCategoryName is the category emails are tagged with when their risk score crosses the threshold
+const SuspiciousCategoryName = "Suspicious"
+
+type RiskAssessment struct {
+	EmailID  int64    `json:"email_id"`
+	Score    float64  `json:"score"`
+	Reasons  []string `json:"reasons"`
+	AIScore  *float64 `json:"ai_score,omitempty"`
+	AIReason string   `json:"ai_reason,omitempty"`
+}
+
+type RiskJudgement struct {
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
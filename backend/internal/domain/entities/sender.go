@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"strings"
+	"time"
+)
+
+// Sender is an address that has mailed an account, with statistics over its emails
+type Sender struct {
+	ID          int64  `json:"id"`
+	AccountID   int64  `json:"account_id"`
+	Address     string `json:"address"`
+	DisplayName string `json:"display_name"`
+	Domain      string `json:"domain"`
+	// Statistics over the sender's stored emails
+	FirstSeenAt  time.Time `json:"first_seen_at"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+	MessageCount int       `json:"message_count"`
+	// HasUnsubscribe reports whether any of the sender's emails offered an unsubscribe
+	HasUnsubscribe bool `json:"has_unsubscribe"`
+	// UnsubscribeStatus is one of the SubscriptionStatus values, or
+	// SenderUnsubscribeStatusNone when the sender offers no way to unsubscribe
+	UnsubscribeStatus string    `json:"unsubscribe_status"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+const SenderUnsubscribeStatusNone = "none"
+
+// SenderName extracts the display name from a From header such as
+// "\"Jane Doe\" <jane@example.com>", or returns "" when there is none
+func SenderName(sender string) string {
+	name, _, found := strings.Cut(sender, "<")
+	if !found {
+		return ""
+	}
+	return strings.Trim(name, ` "`)
+}
@@ -0,0 +1,18 @@
+package entities
+
+import "testing"
+
+func TestSenderName(t *testing.T) {
+	tests := map[string]string{
+		`"Jane Doe" <jane@example.com>`: "Jane Doe",
+		"News <news@example.com>":       "News",
+		"<bare@example.com>":            "",
+		"plain@example.com":             "",
+	}
+
+	for sender, expected := range tests {
+		if got := SenderName(sender); got != expected {
+			t.Errorf("Expected SenderName(%q) to be %q, got %q", sender, expected, got)
+		}
+	}
+}
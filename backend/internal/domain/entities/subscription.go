@@ -0,0 +1,81 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Subscription states of a sender
+const (
+	SubscriptionStatusSubscribed   = "subscribed"
+	SubscriptionStatusUnsubscribed = "unsubscribed"
+	SubscriptionStatusFailed       = "failed"
+	SubscriptionStatusRequiresAuth = "requires_auth"
+	// The sender kept mailing after a successful unsubscribe
+	SubscriptionStatusIneffective = "ineffective"
+	// The unsubscribe actions ran but the page didn't confirm them
+	SubscriptionStatusUnverified = "unverified"
+)
+
+// What to do with mail that arrives after a sender was unsubscribed
+const (
+	UnsubscribeViolationActionNone    = "none"
+	UnsubscribeViolationActionArchive = "archive"
+	UnsubscribeViolationActionTrash   = "trash"
+)
+
+// UnsubscribeAttempt records one run of the unsubscribe flow for an email
+type UnsubscribeAttempt struct {
+	ID            int64      `json:"id"`
+	AccountID     int64      `json:"account_id"`
+	EmailID       int64      `json:"email_id"`
+	SenderAddress string     `json:"sender_address"`
+	SenderDomain  string     `json:"sender_domain"`
+	Strategy      string     `json:"strategy,omitempty"`
+	Success       bool       `json:"success"`
+	Message       string     `json:"message"`
+	ErrorType     string     `json:"error_type,omitempty"`
+	RequiresAuth  bool       `json:"requires_auth"`
+	SentMessageID string     `json:"sent_message_id,omitempty"`
+	Artifacts     []string   `json:"artifacts"`
+	StartedAt     time.Time  `json:"started_at"`
+	CompletedAt   time.Time  `json:"completed_at"`
+	IneffectiveAt *time.Time `json:"ineffective_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Subscription aggregates the mailing-list emails of one sender with the outcome
+// of the most relevant unsubscribe attempt
+type Subscription struct {
+	AccountID      int64      `json:"account_id"`
+	SenderAddress  string     `json:"sender_address"`
+	SenderDomain   string     `json:"sender_domain"`
+	SenderName     string     `json:"sender_name"`
+	EmailCount     int        `json:"email_count"`
+	LastReceivedAt time.Time  `json:"last_received_at"`
+	Status         string     `json:"status"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	LastStrategy   *string    `json:"last_strategy"`
+	LastErrorType  *string    `json:"last_error_type"`
+}
+
+var angleAddressRegex = regexp.MustCompile(`<([^>]+)>`)
+
+// SenderAddress extracts the lower-cased address from a From header such as
+// "Jane <Jane@Example.com>". It mirrors the expression the subscriptions view uses.
+func SenderAddress(sender string) string {
+	if matches := angleAddressRegex.FindStringSubmatch(sender); len(matches) > 1 {
+		return strings.ToLower(strings.TrimSpace(matches[1]))
+	}
+	return strings.ToLower(strings.TrimSpace(sender))
+}
+
+// SenderDomain returns the domain part of an address, or "" if it has none
+func SenderDomain(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at == -1 {
+		return ""
+	}
+	return address[at+1:]
+}
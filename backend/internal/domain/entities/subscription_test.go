@@ -0,0 +1,28 @@
+package entities
+
+import "testing"
+
+func TestSenderAddress(t *testing.T) {
+	tests := map[string]string{
+		"Jane Doe <Jane@Example.com>":  "jane@example.com",
+		`"Shop, Inc" <news@shop.com>`:  "news@shop.com",
+		" deals@Store.example ":        "deals@store.example",
+		"<no-reply@lists.example.org>": "no-reply@lists.example.org",
+	}
+
+	for sender, expected := range tests {
+		if got := SenderAddress(sender); got != expected {
+			t.Errorf("Expected SenderAddress(%q) to be '%s', got '%s'", sender, expected, got)
+		}
+	}
+}
+
+func TestSenderDomain(t *testing.T) {
+	if got := SenderDomain("news@shop.com"); got != "shop.com" {
+		t.Errorf("Expected domain to be 'shop.com', got '%s'", got)
+	}
+
+	if got := SenderDomain("not-an-address"); got != "" {
+		t.Errorf("Expected empty domain, got '%s'", got)
+	}
+}
@@ -0,0 +1,16 @@
+package entities
+
+import "time"
+
+type Thread struct {
+	ID            int64      `json:"id"`
+	AccountID     int64      `json:"account_id"`
+	GmailThreadID string     `json:"gmail_thread_id"`
+	Subject       string     `json:"subject"`
+	MessageCount  int        `json:"message_count"`
+	LastMessageAt *time.Time `json:"last_message_at"`
+	AISummary     *string    `json:"ai_summary"`
+	Emails        []Email    `json:"emails,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -15,4 +15,5 @@ type AccountRepository interface {
 	Update(ctx context.Context, account *entities.Account) error
 	Delete(ctx context.Context, id int64) error
 	UpdateLastSyncHistoryID(ctx context.Context, accountID int64, historyID string) error
+	UpdateAutoSummarize(ctx context.Context, accountID int64, enabled bool) error
 }
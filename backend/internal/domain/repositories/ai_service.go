@@ -25,7 +25,12 @@ type UnsubscribePageAnalysis struct {
 
 type AIService interface {
 	SummarizeEmail(ctx context.Context, email *entities.Email) (string, error)
+	// SummarizeThread updates the thread's existing summary (if any) with the given new messages
+	SummarizeThread(ctx context.Context, thread *entities.Thread, newMessages []entities.Email) (string, error)
 	CategorizeEmail(ctx context.Context, email *entities.Email, categories []entities.Category) ([]int64, error)
 	AnalyzeUnsubscribePage(ctx context.Context, pageContent, pageURL string) (*UnsubscribePageAnalysis, error)
 	ExtractUnsubscribeLink(ctx context.Context, headers, body string) (string, error)
+	ExtractEmailData(ctx context.Context, email *entities.Email) (*entities.EmailExtraction, error)
+	DraftReply(ctx context.Context, email *entities.Email, instruction, tone string) (string, error)
+	AssessPhishingRisk(ctx context.Context, email *entities.Email, signals []string) (*entities.RiskJudgement, error)
 }
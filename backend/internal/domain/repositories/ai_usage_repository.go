@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+type AIUsageRepository interface {
+	GetDailyUsage(ctx context.Context, day time.Time, feature string) (int, error)
+	IncrementDailyUsage(ctx context.Context, day time.Time, feature string, amount int) error
+	// ReserveDailyUsage counts one use unless the day's count has reached limit, and
+	// reports whether it did
+	ReserveDailyUsage(ctx context.Context, day time.Time, feature string, limit int) (bool, error)
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"io"
+)
+
+// ArtifactStore keeps evidence captured during automated unsubscribes, such as
+// screenshots and page snapshots. Keys are slash-separated relative paths.
+type ArtifactStore interface {
+	// Save stores data under key, replacing any existing artifact
+	Save(ctx context.Context, key string, data []byte) error
+	// Open returns the artifact's contents; the caller must close the reader
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+)
+
+type AttachmentRepository interface {
+	// Create stores attachments of emails and sets their IDs
+	Create(ctx context.Context, attachments []entities.Attachment) error
+	GetByID(ctx context.Context, id int64) (*entities.Attachment, error)
+	GetByEmailID(ctx context.Context, emailID int64) ([]entities.Attachment, error)
+	// GetPendingExtractions returns attachments whose text is still to be extracted,
+	// leaving out those that failed maxAttempts times
+	GetPendingExtractions(ctx context.Context, limit, maxAttempts int) ([]entities.Attachment, error)
+	// UpdateExtractedText stores the text of a document, nil when it has none
+	UpdateExtractedText(ctx context.Context, id int64, text *string) error
+	RecordExtractionFailure(ctx context.Context, id int64) error
+}
@@ -12,4 +12,5 @@ type CategoryRepository interface {
 	Create(ctx context.Context, category *entities.Category) (*entities.Category, error)
 	Delete(ctx context.Context, categoryID int64) error
 	GetOrCreate(ctx context.Context, accountID int64, name string) (*entities.Category, error)
+	UpdateAutoSummarize(ctx context.Context, categoryID int64, enabled bool) error
 }
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/email-sorting-app/internal/domain/entities"
 )
@@ -19,20 +20,90 @@ type PaginatedEmails struct {
 	TotalPages int              `json:"total_pages"`
 }
 
+// EmailCursor is a position in an email listing, which is ordered newest first with
+// the ID breaking ties between emails received at the same time
+type EmailCursor struct {
+	ReceivedAt time.Time
+	ID         int64
+}
+
+// CursorParams asks for up to Limit emails after the cursor, or from the start when After is nil
+type CursorParams struct {
+	After *EmailCursor
+	Limit int
+}
+
+type EmailCursorPage struct {
+	Emails []entities.Email
+	// Next is the cursor for the following page, or nil on the last page
+	Next *EmailCursor
+}
+
+// EmailSearchQuery is a parsed search. Text is matched against the full-text index
+// and may be empty when only filters are given; all filters must match.
+type EmailSearchQuery struct {
+	Text           string
+	From           []string
+	Subject        []string
+	Categories     []string
+	Before         *time.Time
+	After          *time.Time
+	HasUnsubscribe bool
+}
+
+type EmailSearchResult struct {
+	Email entities.Email `json:"email"`
+	Rank  float64        `json:"rank"`
+	// Snippet is HTML-escaped body text with matched terms wrapped in <mark>
+	Snippet string `json:"snippet"`
+}
+
+type EmailSearchResults struct {
+	Results    []EmailSearchResult `json:"results"`
+	TotalCount int64               `json:"total_count"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	TotalPages int                 `json:"total_pages"`
+}
+
 type EmailRepository interface {
 	GetByAccountID(ctx context.Context, accountID int64) ([]entities.Email, error)
 	GetByAccountIDPaginated(ctx context.Context, accountID int64, params PaginationParams) (*PaginatedEmails, error)
+	GetByAccountIDCursor(ctx context.Context, accountID int64, params CursorParams) (*EmailCursorPage, error)
+	Search(ctx context.Context, accountID int64, query *EmailSearchQuery, params PaginationParams) (*EmailSearchResults, error)
 	GetByID(ctx context.Context, id int64) (*entities.Email, error)
 	Create(ctx context.Context, email *entities.Email) (*entities.Email, error)
 	Update(ctx context.Context, email *entities.Email) error
 	Delete(ctx context.Context, id int64) error
 	DeleteByAccountID(ctx context.Context, accountID int64) error
 	ExistsByGmailMessageID(ctx context.Context, accountID int64, gmailMessageID string) (bool, error)
-	BulkCreate(ctx context.Context, emails []entities.Email) error
+	// BulkCreate inserts emails, skipping ones already stored, sets the IDs of the
+	// new ones and returns them
+	BulkCreate(ctx context.Context, emails []entities.Email) ([]int64, error)
 	UpdateCategoriesByGmailMessageID(ctx context.Context, accountID int64, gmailMessageID string, categoryIDs []int64) error
 	GetByCategoryIDPaginated(ctx context.Context, accountID, categoryID int64, params PaginationParams) (*PaginatedEmails, error)
+	GetByCategoryIDCursor(ctx context.Context, accountID, categoryID int64, params CursorParams) (*EmailCursorPage, error)
+	GetBySenderID(ctx context.Context, senderID int64) ([]entities.Email, error)
+	GetBySenderIDPaginated(ctx context.Context, senderID int64, params PaginationParams) (*PaginatedEmails, error)
 	AddEmailToCategories(ctx context.Context, emailID int64, categoryIDs []int64) error
+	// AddSenderEmailsToCategory returns how many emails the sender has and how many were added
+	AddSenderEmailsToCategory(ctx context.Context, senderID, categoryID int64) (total, added int, err error)
 	RemoveEmailFromCategories(ctx context.Context, emailID int64, categoryIDs []int64) error
 	GetEmailCategories(ctx context.Context, emailID int64) ([]int64, error)
 	UpdateAISummary(ctx context.Context, emailID int64, summary string) error
+	UpdateAttachmentText(ctx context.Context, emailID int64, text string) error
+	UpdateTrackerCount(ctx context.Context, emailID int64, count int) error
+	// GetPendingSummaries skips emails whose summary failed maxAttempts times, and
+	// emails whose last failure is too recent to retry
+	GetPendingSummaries(ctx context.Context, limit, maxAttempts int) ([]entities.Email, error)
+	RecordSummaryFailure(ctx context.Context, emailID int64) error
+	GetByThreadID(ctx context.Context, threadID int64) ([]entities.Email, error)
+	UpdateRiskAssessment(ctx context.Context, emailID int64, score float64, reasons []string) error
+	// GetPendingRiskAssessments skips emails whose scoring failed maxAttempts times, and
+	// emails whose last failure is too recent to retry
+	GetPendingRiskAssessments(ctx context.Context, limit, maxAttempts int) ([]entities.Email, error)
+	RecordRiskFailure(ctx context.Context, emailID int64) error
+	MarkMailedAfterUnsubscribe(ctx context.Context, emailIDs []int64) error
+	UpdateArchivedInGmail(ctx context.Context, emailID int64, archived bool) error
+	UpdateFlagsByGmailMessageID(ctx context.Context, accountID int64, gmailMessageID string, isRead, isStarred bool) error
 }
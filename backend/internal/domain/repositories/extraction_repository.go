@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+)
+
+type ExtractionRepository interface {
+	// SaveExtraction replaces the entities stored for an email and merges its action
+	// items with those already stored, keeping their status. The email is no longer pending.
+	SaveExtraction(ctx context.Context, accountID int64, extraction *entities.EmailExtraction) error
+	GetByEmailID(ctx context.Context, emailID int64) (*entities.EmailExtraction, error)
+	// GetPendingExtractions skips emails whose extraction failed maxAttempts times, and
+	// emails whose last failure is too recent to retry
+	GetPendingExtractions(ctx context.Context, limit, maxAttempts int) ([]int64, error)
+	RecordExtractionFailure(ctx context.Context, emailID int64) error
+	GetOpenActionItems(ctx context.Context) ([]entities.ActionItem, error)
+	GetActionItemByID(ctx context.Context, id int64) (*entities.ActionItem, error)
+	UpdateActionItemStatus(ctx context.Context, id int64, status string) error
+}
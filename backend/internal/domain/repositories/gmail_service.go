@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"io"
 
 	"github.com/email-sorting-app/internal/domain/entities"
 	"golang.org/x/oauth2"
@@ -11,11 +12,16 @@ type GmailService interface {
 	ListMessages(ctx context.Context, token *oauth2.Token, maxResults int64) ([]entities.GmailMessage, error)
 	ListAllMessages(ctx context.Context, token *oauth2.Token) ([]entities.GmailMessage, error)
 	GetMessage(ctx context.Context, token *oauth2.Token, messageID string) (*entities.GmailMessage, error)
+	// GetAttachment streams the decoded content of an attachment of a message
+	GetAttachment(ctx context.Context, token *oauth2.Token, messageID string, attachment *entities.Attachment) (io.ReadCloser, error)
 	ArchiveMessage(ctx context.Context, token *oauth2.Token, messageID string) error
+	TrashMessage(ctx context.Context, token *oauth2.Token, messageID string) error
 	GetCurrentHistoryId(ctx context.Context, token *oauth2.Token) (string, error)
 	ListHistory(ctx context.Context, token *oauth2.Token, startHistoryId string) ([]entities.GmailMessage, string, error)
 	GetLabelNames(ctx context.Context, token *oauth2.Token, labelIds []string) (map[string]string, error)
 	DeleteLabel(ctx context.Context, token *oauth2.Token, labelName string) error
 	CreateLabel(ctx context.Context, token *oauth2.Token, labelName string) error
 	GetAllLabels(ctx context.Context, token *oauth2.Token) ([]entities.GmailLabel, error)
+	CreateDraft(ctx context.Context, token *oauth2.Token, msg *entities.OutgoingMessage) (string, error)
+	SendMessage(ctx context.Context, token *oauth2.Token, msg *entities.OutgoingMessage) (string, error)
 }
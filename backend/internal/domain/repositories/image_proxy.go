@@ -0,0 +1,19 @@
+package repositories
+
+import "context"
+
+// ProxiedImage is a remote image fetched on behalf of a client
+type ProxiedImage struct {
+	ContentType string
+	Data        []byte
+}
+
+// ImageProxy loads remote images of emails so that senders never see the
+// client's address, and only for URLs the server itself handed out
+type ImageProxy interface {
+	// Sign returns the signature a client must present to load src
+	Sign(src string) string
+	// Verify reports whether signature was issued by Sign for src
+	Verify(src, signature string) bool
+	Fetch(ctx context.Context, src string) (*ProxiedImage, error)
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+)
+
+type JobRepository interface {
+	// Create stores a job together with its items
+	Create(ctx context.Context, job *entities.Job) error
+	// GetByID returns the job with its items and progress counters
+	GetByID(ctx context.Context, id int64) (*entities.Job, error)
+	GetUnfinishedIDs(ctx context.Context) ([]int64, error)
+	UpdateStatus(ctx context.Context, id int64, status string) error
+	UpdateItem(ctx context.Context, item *entities.JobItem) error
+	CancelPendingItems(ctx context.Context, jobID int64) error
+}
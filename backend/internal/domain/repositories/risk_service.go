@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+)
+
+type RiskService interface {
+	// AssessEmail scores how likely an email is phishing or a scam, from 0 (safe) to 1.
+	// It relies on email.Headers, so the email must come straight from a Gmail sync.
+	// An AI second opinion is only asked for while budget lasts; a nil budget, for
+	// assessments the user asks for, doesn't limit it.
+	AssessEmail(ctx context.Context, email *entities.Email, budget AIBudget) (*entities.RiskAssessment, error)
+}
+
+// AIBudget caps the AI calls made in the background
+type AIBudget interface {
+	// Reserve takes one call out of the budget, reporting false once it is spent
+	Reserve(ctx context.Context) (bool, error)
+}
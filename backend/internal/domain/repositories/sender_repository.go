@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+)
+
+type PaginatedSenders struct {
+	Senders    []entities.Sender `json:"senders"`
+	TotalCount int64             `json:"total_count"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+}
+
+type SenderRepository interface {
+	// RecordEmails creates or updates the senders of newly stored emails, links the
+	// emails to them and refreshes the statistics of those senders
+	RecordEmails(ctx context.Context, accountID int64, emails []entities.Email) error
+	GetByID(ctx context.Context, id int64) (*entities.Sender, error)
+	// GetByAccountIDPaginated lists senders with stored emails, most emails first
+	GetByAccountIDPaginated(ctx context.Context, accountID int64, params PaginationParams) (*PaginatedSenders, error)
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+)
+
+type SubscriptionRepository interface {
+	CreateAttempt(ctx context.Context, attempt *entities.UnsubscribeAttempt) error
+	GetAttemptByID(ctx context.Context, id int64) (*entities.UnsubscribeAttempt, error)
+	GetAttemptsBySender(ctx context.Context, accountID int64, senderAddress string) ([]entities.UnsubscribeAttempt, error)
+	// GetEffectiveAttempt returns the latest successful attempt not yet marked ineffective, or nil
+	GetEffectiveAttempt(ctx context.Context, accountID int64, senderAddress string) (*entities.UnsubscribeAttempt, error)
+	MarkAttemptIneffective(ctx context.Context, attemptID int64) error
+	// GetByAccountID lists the account's subscriptions, optionally filtered by status
+	GetByAccountID(ctx context.Context, accountID int64, status string) ([]entities.Subscription, error)
+	// GetBySender returns nil when the sender has no unsubscribable emails
+	GetBySender(ctx context.Context, accountID int64, senderAddress string) (*entities.Subscription, error)
+}
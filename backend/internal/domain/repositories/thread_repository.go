@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+)
+
+type ThreadRepository interface {
+	GetByID(ctx context.Context, id int64) (*entities.Thread, error)
+	GetOrCreate(ctx context.Context, accountID int64, gmailThreadID, subject string) (*entities.Thread, error)
+	// AttachEmails links emails to a thread and refreshes its message count and last message time
+	AttachEmails(ctx context.Context, threadID int64, emailIDs []int64) error
+	// UpdateSummary stores the thread summary and marks the given emails as covered by it
+	UpdateSummary(ctx context.Context, threadID int64, summary string, emailIDs []int64) error
+	DeleteByAccountID(ctx context.Context, accountID int64) error
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+type UnsubscribePlanRepository interface {
+	Create(ctx context.Context, plan *UnsubscribePlan) error
+	GetByID(ctx context.Context, id int64) (*UnsubscribePlan, error)
+	// Claim marks the plan as running; it fails if the plan is running or was already executed
+	Claim(ctx context.Context, id int64) error
+	// Finish ends a claimed run. A successful plan is marked executed; a failed one is
+	// released so it can be approved again.
+	Finish(ctx context.Context, id int64, success bool) error
+	// DeleteExpiredBefore removes plans that expired before the given time
+	DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// UnsubscribeRecipe is an action sequence that unsubscribed successfully on a page,
+// replayed on pages with the same host and path pattern instead of asking the AI
+type UnsubscribeRecipe struct {
+	ID           int64               `json:"id"`
+	Host         string              `json:"host"`
+	PathPattern  string              `json:"path_pattern"`
+	Actions      []UnsubscribeAction `json:"actions"`
+	SuccessCount int                 `json:"success_count"`
+	MissCount    int                 `json:"miss_count"`
+	LastUsedAt   time.Time           `json:"last_used_at"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+type UnsubscribeRecipeRepository interface {
+	// GetByKey returns nil when no recipe has been learned for the page
+	GetByKey(ctx context.Context, host, pathPattern string) (*UnsubscribeRecipe, error)
+	// Save stores a recipe, replacing the actions of an existing one for the same page
+	Save(ctx context.Context, recipe *UnsubscribeRecipe) error
+	// RecordSuccess counts a confirmed replay and clears the misses of the recipe
+	RecordSuccess(ctx context.Context, id int64) error
+	// RecordMiss counts a replay that wasn't confirmed, deleting the recipe once it
+	// has missed maxMisses times in a row. dropped reports whether it was deleted.
+	RecordMiss(ctx context.Context, id int64, maxMisses int) (dropped bool, err error)
+	Delete(ctx context.Context, id int64) error
+}
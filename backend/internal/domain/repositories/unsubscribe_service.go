@@ -2,23 +2,68 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/email-sorting-app/internal/domain/entities"
 )
 
+// Unsubscribe strategies, in the order they are tried
+const (
+	UnsubscribeStrategyOneClick = "one_click"
+	UnsubscribeStrategyMailto   = "mailto"
+	UnsubscribeStrategyBrowser  = "browser"
+)
+
 type UnsubscribeResult struct {
 	Success      bool   `json:"success"`
 	Message      string `json:"message"`
 	ErrorType    string `json:"error_type,omitempty"`
 	RequiresAuth bool   `json:"requires_auth,omitempty"`
+	Strategy     string `json:"strategy,omitempty"`
+	// SentMessageID is the Gmail ID of the request email sent by the mailto strategy
+	SentMessageID string `json:"sent_message_id,omitempty"`
+	// Artifacts are artifact store keys of evidence captured by the browser strategy
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
+// UnsubscribePlan is what an unsubscribe would do, worked out by a dry run and kept
+// until the user approves it or it expires
+type UnsubscribePlan struct {
+	ID        int64  `json:"id"`
+	AccountID int64  `json:"account_id"`
+	EmailID   int64  `json:"email_id"`
+	Strategy  string `json:"strategy"`
+	Message   string `json:"message"`
+	// PageURL is the page the actions apply to, for the browser strategy
+	PageURL string              `json:"page_url,omitempty"`
+	Actions []UnsubscribeAction `json:"actions"`
+	// Screenshots holds an artifact key per action showing its target element
+	// highlighted, or an empty string when there is nothing to show
+	Screenshots []string  `json:"screenshots"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	// StartedAt is set while the plan runs, ExecutedAt once it succeeded and
+	// FailedAt when the last run failed
+	StartedAt  *time.Time `json:"started_at"`
+	ExecutedAt *time.Time `json:"executed_at"`
+	FailedAt   *time.Time `json:"failed_at"`
 }
 
 type UnsubscribeService interface {
 	// UnsubscribeFromEmail attempts to unsubscribe from a single email using its unsubscribe link
 	UnsubscribeFromEmail(ctx context.Context, email *entities.Email) (*UnsubscribeResult, error)
 
-	// BulkUnsubscribe attempts to unsubscribe from multiple emails
-	BulkUnsubscribe(ctx context.Context, emails []*entities.Email) (map[int64]*UnsubscribeResult, error)
+	// UnsubscribeExcluding works like UnsubscribeFromEmail but skips the given strategies,
+	// e.g. ones that already proved ineffective for the sender
+	UnsubscribeExcluding(ctx context.Context, email *entities.Email, excludedStrategies []string) (*UnsubscribeResult, error)
+
+	// PreviewUnsubscribe works out what UnsubscribeExcluding would do without doing
+	// it. When nothing can be done the plan is nil and the result says why.
+	PreviewUnsubscribe(ctx context.Context, email *entities.Email, excludedStrategies []string) (*UnsubscribePlan, *UnsubscribeResult, error)
+
+	// ExecuteUnsubscribePlan carries out a previewed plan as it was approved, without
+	// asking the AI again
+	ExecuteUnsubscribePlan(ctx context.Context, email *entities.Email, plan *UnsubscribePlan) (*UnsubscribeResult, error)
 
 	// ValidateUnsubscribeLink checks if an unsubscribe link is valid and accessible
 	ValidateUnsubscribeLink(ctx context.Context, link string) (bool, error)
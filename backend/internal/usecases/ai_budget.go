@@ -0,0 +1,45 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+// aiBudgetFeature is the usage counter shared by the AI work done in the background
+const aiBudgetFeature = "background"
+
+// AIBudget caps the AI calls made in the background each day, so that syncing a large
+// mailbox can't run up the AI bill. Calls the user asks for directly aren't counted.
+type AIBudget struct {
+	usageRepo  repositories.AIUsageRepository
+	dailyLimit int
+}
+
+func NewAIBudget(usageRepo repositories.AIUsageRepository, dailyLimit int) *AIBudget {
+	return &AIBudget{
+		usageRepo:  usageRepo,
+		dailyLimit: dailyLimit,
+	}
+}
+
+// Reserve takes one call out of today's budget before it is made, so failed calls
+// count too. It reports false once the budget is spent.
+func (b *AIBudget) Reserve(ctx context.Context) (bool, error) {
+	reserved, err := b.usageRepo.ReserveDailyUsage(ctx, time.Now().UTC(), aiBudgetFeature, b.dailyLimit)
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve AI budget: %w", err)
+	}
+	return reserved, nil
+}
+
+// Remaining returns how many calls are left in today's budget
+func (b *AIBudget) Remaining(ctx context.Context) (int, error) {
+	used, err := b.usageRepo.GetDailyUsage(ctx, time.Now().UTC(), aiBudgetFeature)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get daily usage: %w", err)
+	}
+	return max(b.dailyLimit-used, 0), nil
+}
@@ -0,0 +1,341 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	"github.com/email-sorting-app/pkg/doctext"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+)
+
+var ErrAttachmentNotFound = apperrors.NewNotFoundError("attachment not found")
+
+// Extracted text kept per attachment and per email, so one long document can't
+// swamp the search index or AI prompts
+const (
+	maxAttachmentTextLength      = 100000
+	maxEmailAttachmentTextLength = 200000
+)
+
+const (
+	attachmentQueueSize    = 1000
+	attachmentBacklogBatch = 50
+	// Attachments that failed to download this many times are left without text
+	maxAttachmentAttempts = 3
+)
+
+// AttachmentUsecase indexes the attachments of synced emails and fetches their
+// content from Gmail on demand. The text of documents is extracted in the
+// background: new attachments are queued as they are indexed, and a backlog
+// processor picks up the ones that didn't fit or failed.
+type AttachmentUsecase struct {
+	attachmentRepo repositories.AttachmentRepository
+	emailRepo      repositories.EmailRepository
+	accountRepo    repositories.AccountRepository
+	gmailService   repositories.GmailService
+	// Text extraction downloads documents; larger ones are left out
+	extractText     bool
+	maxExtractSize  int64
+	backlogInterval time.Duration
+	queue           chan int64
+}
+
+func NewAttachmentUsecase(
+	attachmentRepo repositories.AttachmentRepository,
+	emailRepo repositories.EmailRepository,
+	accountRepo repositories.AccountRepository,
+	gmailService repositories.GmailService,
+	extractText bool,
+	maxExtractSize int64,
+	backlogInterval time.Duration,
+) *AttachmentUsecase {
+	return &AttachmentUsecase{
+		attachmentRepo:  attachmentRepo,
+		emailRepo:       emailRepo,
+		accountRepo:     accountRepo,
+		gmailService:    gmailService,
+		extractText:     extractText,
+		maxExtractSize:  maxExtractSize,
+		backlogInterval: backlogInterval,
+		queue:           make(chan int64, attachmentQueueSize),
+	}
+}
+
+// Start launches the extraction worker and the backlog processor when text
+// extraction is enabled. Both stop when ctx is cancelled.
+func (u *AttachmentUsecase) Start(ctx context.Context) {
+	if !u.extractText {
+		return
+	}
+	go u.runWorker(ctx)
+	go u.runBacklog(ctx)
+}
+
+// IndexEmails stores the attachments of newly synced emails and queues their
+// documents for text extraction, which sets the emails' attachment text once done
+func (u *AttachmentUsecase) IndexEmails(ctx context.Context, account *entities.Account, emails []entities.Email) error {
+	var attachments []entities.Attachment
+	for i := range emails {
+		for _, attachment := range emails[i].Attachments {
+			attachment.EmailID = emails[i].ID
+			attachment.TextExtracted = !u.extractable(&attachment)
+			// Content is fetched on demand, never kept
+			attachment.Data = nil
+			attachments = append(attachments, attachment)
+		}
+	}
+
+	err := u.attachmentRepo.Create(ctx, attachments)
+	if err != nil {
+		return fmt.Errorf("failed to store attachments: %w", err)
+	}
+
+	if u.extractText {
+		u.enqueueAttachments(attachments)
+	}
+
+	return nil
+}
+
+// extractable reports whether an attachment is a document small enough to read
+func (u *AttachmentUsecase) extractable(attachment *entities.Attachment) bool {
+	return !attachment.Inline && doctext.Supported(attachment.MimeType, attachment.Filename) &&
+		attachment.Size <= u.maxExtractSize
+}
+
+// enqueueAttachments schedules text extraction without blocking the sync.
+// Attachments that don't fit in the queue are picked up later by the backlog processor.
+func (u *AttachmentUsecase) enqueueAttachments(attachments []entities.Attachment) {
+	for _, attachment := range attachments {
+		if attachment.TextExtracted {
+			continue
+		}
+
+		select {
+		case u.queue <- attachment.ID:
+		default:
+			fmt.Printf("Warning: attachment extraction queue is full, leaving remaining attachments to the backlog processor\n")
+			return
+		}
+	}
+}
+
+// ProcessBacklog extracts the text of attachments still waiting for it. It returns
+// the number of attachments processed.
+func (u *AttachmentUsecase) ProcessBacklog(ctx context.Context) (int, error) {
+	attachments, err := u.attachmentRepo.GetPendingExtractions(ctx, attachmentBacklogBatch, maxAttachmentAttempts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get attachments pending extraction: %w", err)
+	}
+
+	processed := 0
+	for i := range attachments {
+		if ctx.Err() != nil {
+			break
+		}
+		if extractErr := u.extract(ctx, &attachments[i]); extractErr != nil {
+			fmt.Printf("Warning: failed to extract text of attachment %d: %v\n", attachments[i].ID, extractErr)
+			continue
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+func (u *AttachmentUsecase) runWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case attachmentID := <-u.queue:
+			if err := u.extractNewAttachment(ctx, attachmentID); err != nil {
+				fmt.Printf("Warning: failed to extract text of attachment %d: %v\n", attachmentID, err)
+			}
+		}
+	}
+}
+
+func (u *AttachmentUsecase) runBacklog(ctx context.Context) {
+	ticker := time.NewTicker(u.backlogInterval)
+	defer ticker.Stop()
+
+	for {
+		processed, err := u.ProcessBacklog(ctx)
+		if err != nil {
+			fmt.Printf("Warning: attachment extraction backlog failed: %v\n", err)
+		} else if processed > 0 {
+			fmt.Printf("Attachment extraction backlog processed %d attachments\n", processed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *AttachmentUsecase) extractNewAttachment(ctx context.Context, attachmentID int64) error {
+	attachment, err := u.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		return fmt.Errorf("failed to get attachment: %w", err)
+	}
+	if attachment.TextExtracted {
+		return nil // The backlog processor got to it first
+	}
+
+	return u.extract(ctx, attachment)
+}
+
+// extract stores the text of an attachment and refreshes the attachment text of its
+// email. A failed download is counted so the attachment is retried later.
+func (u *AttachmentUsecase) extract(ctx context.Context, attachment *entities.Attachment) error {
+	email, err := u.emailRepo.GetByID(ctx, attachment.EmailID)
+	if err != nil {
+		return fmt.Errorf("failed to get email: %w", err)
+	}
+
+	account, err := u.accountRepo.GetByID(ctx, email.AccountID)
+	if err != nil {
+		return fmt.Errorf("account not found: %w", err)
+	}
+
+	text, err := u.documentText(ctx, account, email, attachment)
+	if err != nil {
+		if recordErr := u.attachmentRepo.RecordExtractionFailure(ctx, attachment.ID); recordErr != nil {
+			fmt.Printf("Warning: failed to record extraction failure for attachment %d: %v\n", attachment.ID, recordErr)
+		}
+		return err
+	}
+
+	var extracted *string
+	if text != "" {
+		extracted = &text
+	}
+	err = u.attachmentRepo.UpdateExtractedText(ctx, attachment.ID, extracted)
+	if err != nil {
+		return fmt.Errorf("failed to store extracted text: %w", err)
+	}
+
+	if text == "" {
+		return nil
+	}
+	return u.updateEmailText(ctx, email.ID)
+}
+
+// updateEmailText sets the attachment text of an email, which search indexes and
+// AI categorization reads, from the text of all its attachments
+func (u *AttachmentUsecase) updateEmailText(ctx context.Context, emailID int64) error {
+	attachments, err := u.attachmentRepo.GetByEmailID(ctx, emailID)
+	if err != nil {
+		return fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	var texts []string
+	for _, attachment := range attachments {
+		if attachment.ExtractedText != nil && *attachment.ExtractedText != "" {
+			texts = append(texts, attachment.Filename+":\n"+*attachment.ExtractedText)
+		}
+	}
+
+	err = u.emailRepo.UpdateAttachmentText(ctx, emailID, truncateText(strings.Join(texts, "\n\n"), maxEmailAttachmentTextLength))
+	if err != nil {
+		return fmt.Errorf("failed to store attachment text: %w", err)
+	}
+
+	return nil
+}
+
+// documentText downloads an attachment and extracts its text. It returns "" when
+// the attachment isn't a document, is too large or can't be read, and an error
+// only when the download failed.
+func (u *AttachmentUsecase) documentText(ctx context.Context, account *entities.Account, email *entities.Email, attachment *entities.Attachment) (string, error) {
+	if !u.extractable(attachment) {
+		return "", nil
+	}
+
+	reader, err := u.gmailService.GetAttachment(ctx, account.ToOAuth2Token(), email.GmailMessageID, attachment)
+	if err != nil {
+		return "", fmt.Errorf("failed to download attachment %q of message %s: %w", attachment.Filename, email.GmailMessageID, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, u.maxExtractSize+1))
+	reader.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to download attachment %q of message %s: %w", attachment.Filename, email.GmailMessageID, err)
+	}
+	if int64(len(data)) > u.maxExtractSize {
+		return "", nil
+	}
+
+	text, err := doctext.Extract(attachment.MimeType, attachment.Filename, data)
+	if err != nil {
+		if !errors.Is(err, doctext.ErrUnsupported) {
+			fmt.Printf("Warning: failed to extract text from attachment %q of message %s: %v\n", attachment.Filename, email.GmailMessageID, err)
+		}
+		return "", nil
+	}
+
+	return truncateText(text, maxAttachmentTextLength), nil
+}
+
+// truncateText cuts text to at most limit bytes without splitting a character
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return strings.ToValidUTF8(text[:limit], "")
+}
+
+func (u *AttachmentUsecase) GetEmailAttachments(ctx context.Context, emailID int64) ([]entities.Attachment, error) {
+	_, err := u.emailRepo.GetByID(ctx, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	attachments, err := u.attachmentRepo.GetByEmailID(ctx, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// GetAttachment returns an attachment of an email
+func (u *AttachmentUsecase) GetAttachment(ctx context.Context, emailID, attachmentID int64) (*entities.Attachment, error) {
+	attachment, err := u.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	if attachment.EmailID != emailID {
+		return nil, ErrAttachmentNotFound
+	}
+
+	return attachment, nil
+}
+
+// OpenAttachment streams the content of an attachment from Gmail. The caller closes the reader.
+func (u *AttachmentUsecase) OpenAttachment(ctx context.Context, attachment *entities.Attachment) (io.ReadCloser, error) {
+	email, err := u.emailRepo.GetByID(ctx, attachment.EmailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	account, err := u.accountRepo.GetByID(ctx, email.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("account not found: %w", err)
+	}
+
+	reader, err := u.gmailService.GetAttachment(ctx, account.ToOAuth2Token(), email.GmailMessageID, attachment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download attachment from Gmail: %w", err)
+	}
+
+	return reader, nil
+}
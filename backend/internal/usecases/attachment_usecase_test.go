@@ -0,0 +1,276 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	"golang.org/x/oauth2"
+)
+
+type fakeAttachmentRepository struct {
+	repositories.AttachmentRepository
+	created     []entities.Attachment
+	attachments map[int64]*entities.Attachment
+	failures    map[int64]int
+}
+
+func (r *fakeAttachmentRepository) Create(ctx context.Context, attachments []entities.Attachment) error {
+	if r.attachments == nil {
+		r.attachments = map[int64]*entities.Attachment{}
+	}
+	for i := range attachments {
+		attachments[i].ID = int64(len(r.created) + 1)
+		r.created = append(r.created, attachments[i])
+		stored := attachments[i]
+		r.attachments[stored.ID] = &stored
+	}
+	return nil
+}
+
+func (r *fakeAttachmentRepository) GetByID(ctx context.Context, id int64) (*entities.Attachment, error) {
+	attachment, ok := r.attachments[id]
+	if !ok {
+		return nil, fmt.Errorf("attachment not found")
+	}
+	return attachment, nil
+}
+
+func (r *fakeAttachmentRepository) GetByEmailID(ctx context.Context, emailID int64) ([]entities.Attachment, error) {
+	var attachments []entities.Attachment
+	for _, attachment := range r.attachments {
+		if attachment.EmailID == emailID {
+			attachments = append(attachments, *attachment)
+		}
+	}
+	slices.SortFunc(attachments, func(a, b entities.Attachment) int { return int(a.ID - b.ID) })
+	return attachments, nil
+}
+
+func (r *fakeAttachmentRepository) GetPendingExtractions(ctx context.Context, limit, maxAttempts int) ([]entities.Attachment, error) {
+	var pending []entities.Attachment
+	for _, attachment := range r.attachments {
+		if !attachment.TextExtracted && r.failures[attachment.ID] < maxAttempts && len(pending) < limit {
+			pending = append(pending, *attachment)
+		}
+	}
+	return pending, nil
+}
+
+func (r *fakeAttachmentRepository) UpdateExtractedText(ctx context.Context, id int64, text *string) error {
+	r.attachments[id].ExtractedText = text
+	r.attachments[id].TextExtracted = true
+	return nil
+}
+
+func (r *fakeAttachmentRepository) RecordExtractionFailure(ctx context.Context, id int64) error {
+	r.failures[id]++
+	return nil
+}
+
+type fakeAttachmentEmailRepository struct {
+	repositories.EmailRepository
+	emails         []entities.Email
+	attachmentText map[int64]string
+}
+
+func (r *fakeAttachmentEmailRepository) GetByID(ctx context.Context, id int64) (*entities.Email, error) {
+	for i := range r.emails {
+		if r.emails[i].ID == id {
+			return &r.emails[i], nil
+		}
+	}
+	return nil, fmt.Errorf("email %d not found", id)
+}
+
+func (r *fakeAttachmentEmailRepository) UpdateAttachmentText(ctx context.Context, emailID int64, text string) error {
+	r.attachmentText[emailID] = text
+	return nil
+}
+
+type fakeAttachmentGmailService struct {
+	repositories.GmailService
+	contents  map[string][]byte
+	downloads []string
+}
+
+func (s *fakeAttachmentGmailService) GetAttachment(ctx context.Context, token *oauth2.Token, messageID string, attachment *entities.Attachment) (io.ReadCloser, error) {
+	// Small parts have no attachment ID and are found by their part ID
+	id := attachment.GmailAttachmentID
+	if id == "" {
+		id = "part-" + attachment.GmailPartID
+	}
+	s.downloads = append(s.downloads, id)
+	content, ok := s.contents[id]
+	if !ok {
+		return nil, fmt.Errorf("attachment %s not found", id)
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func newTestAttachmentEmails() []entities.Email {
+	return []entities.Email{
+		{ID: 1, AccountID: 1, GmailMessageID: "m1", Attachments: []entities.Attachment{
+			// Small parts come with their content
+			{Filename: "notes.txt", MimeType: "text/plain", Size: 11, GmailPartID: "1", Data: []byte("Bring snacks")},
+			{Filename: "prices.csv", MimeType: "text/csv", Size: 20, GmailAttachmentID: "att-csv"},
+			{Filename: "photo.jpg", MimeType: "image/jpeg", Size: 2048, GmailAttachmentID: "att-jpg"},
+			{Filename: "huge.txt", MimeType: "text/plain", Size: 5000, GmailAttachmentID: "att-huge"},
+			{Filename: "", MimeType: "text/plain", Size: 10, ContentID: "part1", Inline: true, GmailAttachmentID: "att-inline"},
+		}},
+		{ID: 2, AccountID: 1, GmailMessageID: "m2"},
+	}
+}
+
+func newTestAttachmentUsecase(extractText bool) (*AttachmentUsecase, *fakeAttachmentRepository, *fakeAttachmentEmailRepository, *fakeAttachmentGmailService) {
+	attachmentRepo := &fakeAttachmentRepository{failures: map[int64]int{}}
+	emailRepo := &fakeAttachmentEmailRepository{emails: newTestAttachmentEmails(), attachmentText: map[int64]string{}}
+	gmailService := &fakeAttachmentGmailService{contents: map[string][]byte{
+		"part-1":  []byte("Bring snacks"),
+		"att-csv": []byte("item,price\ntea,3"),
+	}}
+	usecase := NewAttachmentUsecase(attachmentRepo, emailRepo, &fakeAccountRepository{}, gmailService, extractText, 1024, time.Minute)
+	return usecase, attachmentRepo, emailRepo, gmailService
+}
+
+// drainAttachmentQueue runs the queued extractions, as the worker would
+func drainAttachmentQueue(t *testing.T, usecase *AttachmentUsecase) {
+	t.Helper()
+	for len(usecase.queue) > 0 {
+		if err := usecase.extractNewAttachment(context.Background(), <-usecase.queue); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	}
+}
+
+func TestAttachmentUsecase_IndexEmails(t *testing.T) {
+	usecase, attachmentRepo, emailRepo, gmailService := newTestAttachmentUsecase(true)
+
+	emails := newTestAttachmentEmails()
+	err := usecase.IndexEmails(context.Background(), &entities.Account{ID: 1}, emails)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(attachmentRepo.created) != 5 {
+		t.Fatalf("Expected 5 attachments to be stored, got %d", len(attachmentRepo.created))
+	}
+	for _, attachment := range attachmentRepo.created {
+		if attachment.EmailID != 1 {
+			t.Errorf("Expected attachment %q to belong to email 1, got %d", attachment.Filename, attachment.EmailID)
+		}
+		if attachment.Data != nil {
+			t.Errorf("Expected the content of %q not to be kept", attachment.Filename)
+		}
+	}
+
+	// The sync only stores metadata; documents are read in the background
+	if len(gmailService.downloads) != 0 || len(emailRepo.attachmentText) != 0 {
+		t.Fatalf("Expected nothing to be downloaded during sync, got %v", gmailService.downloads)
+	}
+	drainAttachmentQueue(t, usecase)
+
+	// Only documents under the size limit are downloaded
+	if !slices.Equal(gmailService.downloads, []string{"part-1", "att-csv"}) {
+		t.Errorf("Expected only the text documents to be downloaded, got %v", gmailService.downloads)
+	}
+
+	texts := map[string]string{}
+	for _, attachment := range attachmentRepo.attachments {
+		if !attachment.TextExtracted {
+			t.Errorf("Expected %q to be done with", attachment.Filename)
+		}
+		if attachment.ExtractedText != nil {
+			texts[attachment.Filename] = *attachment.ExtractedText
+		}
+	}
+	if len(texts) != 2 || texts["notes.txt"] != "Bring snacks" || texts["prices.csv"] != "item,price\ntea,3" {
+		t.Errorf("Expected text from notes.txt and prices.csv, got %v", texts)
+	}
+
+	expected := "notes.txt:\nBring snacks\n\nprices.csv:\nitem,price\ntea,3"
+	if emailRepo.attachmentText[1] != expected {
+		t.Errorf("Expected the attachment text to be stored, got %q", emailRepo.attachmentText[1])
+	}
+	if _, stored := emailRepo.attachmentText[2]; stored {
+		t.Error("Expected no attachment text for an email without attachments")
+	}
+}
+
+func TestAttachmentUsecase_IndexEmailsWithoutExtraction(t *testing.T) {
+	usecase, attachmentRepo, emailRepo, gmailService := newTestAttachmentUsecase(false)
+
+	emails := newTestAttachmentEmails()
+	err := usecase.IndexEmails(context.Background(), &entities.Account{ID: 1}, emails)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(attachmentRepo.created) != 5 {
+		t.Errorf("Expected the metadata to be stored anyway, got %d attachments", len(attachmentRepo.created))
+	}
+	if len(usecase.queue) != 0 || len(gmailService.downloads) != 0 || len(emailRepo.attachmentText) != 0 {
+		t.Errorf("Expected nothing to be queued or downloaded, got %v and %v", gmailService.downloads, emailRepo.attachmentText)
+	}
+}
+
+func TestAttachmentUsecase_ProcessBacklogRetriesFailedDownloads(t *testing.T) {
+	usecase, attachmentRepo, emailRepo, gmailService := newTestAttachmentUsecase(true)
+	delete(gmailService.contents, "att-csv")
+
+	emails := newTestAttachmentEmails()
+	if err := usecase.IndexEmails(context.Background(), &entities.Account{ID: 1}, emails); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Queued attachments lost in a restart are picked up by the backlog processor
+	<-usecase.queue
+	<-usecase.queue
+
+	for range maxAttachmentAttempts + 2 {
+		if _, err := usecase.ProcessBacklog(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	// The failing download is tried until it runs out of attempts, and isn't picked again
+	attempts := 0
+	for _, id := range gmailService.downloads {
+		if id == "att-csv" {
+			attempts++
+		}
+	}
+	if attempts != maxAttachmentAttempts || attachmentRepo.failures[2] != maxAttachmentAttempts {
+		t.Errorf("Expected %d attempts at the failing download, got %d downloads and %d failures", maxAttachmentAttempts, attempts, attachmentRepo.failures[2])
+	}
+	if emailRepo.attachmentText[1] != "notes.txt:\nBring snacks" {
+		t.Errorf("Expected the other document to be read, got %q", emailRepo.attachmentText[1])
+	}
+}
+
+func TestAttachmentUsecase_GetAttachment(t *testing.T) {
+	attachmentRepo := &fakeAttachmentRepository{attachments: map[int64]*entities.Attachment{
+		7: {ID: 7, EmailID: 1, Filename: "invoice.pdf"},
+	}}
+	usecase := NewAttachmentUsecase(attachmentRepo, nil, nil, nil, true, 1024, time.Minute)
+
+	attachment, err := usecase.GetAttachment(context.Background(), 1, 7)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if attachment.Filename != "invoice.pdf" {
+		t.Errorf("Expected invoice.pdf, got %q", attachment.Filename)
+	}
+
+	// Attachments are only reachable through their own email
+	_, err = usecase.GetAttachment(context.Background(), 2, 7)
+	if !errors.Is(err, ErrAttachmentNotFound) {
+		t.Errorf("Expected ErrAttachmentNotFound, got %v", err)
+	}
+}
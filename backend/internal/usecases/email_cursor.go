@@ -0,0 +1,90 @@
+package usecases
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+// EmailCursorPage is a page of emails with the opaque token for the next one
+type EmailCursorPage struct {
+	Emails     []entities.Email `json:"emails"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	HasMore    bool             `json:"has_more"`
+}
+
+type cursorToken struct {
+	ReceivedAt time.Time `json:"t"`
+	ID         int64     `json:"id"`
+}
+
+// EncodeEmailCursor turns a cursor into an opaque URL-safe token
+func EncodeEmailCursor(cursor *repositories.EmailCursor) string {
+	data, _ := json.Marshal(cursorToken{ReceivedAt: cursor.ReceivedAt, ID: cursor.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeEmailCursor parses a token from EncodeEmailCursor. An empty token means the first page.
+func DecodeEmailCursor(token string) (*repositories.EmailCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var decoded cursorToken
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.ID <= 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &repositories.EmailCursor{ReceivedAt: decoded.ReceivedAt, ID: decoded.ID}, nil
+}
+
+func newEmailCursorPage(page *repositories.EmailCursorPage) *EmailCursorPage {
+	sanitizeBodies(page.Emails)
+	result := &EmailCursorPage{Emails: page.Emails}
+	if page.Next != nil {
+		result.NextCursor = EncodeEmailCursor(page.Next)
+		result.HasMore = true
+	}
+	return result
+}
+
+// GetAccountEmailsCursor lists an account's emails newest first, a page at a time by cursor
+func (u *EmailUsecase) GetAccountEmailsCursor(ctx context.Context, accountID int64, params repositories.CursorParams) (*EmailCursorPage, error) {
+	// First, check if account exists
+	_, err := u.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("account not found: %w", err)
+	}
+
+	page, err := u.emailRepo.GetByAccountIDCursor(ctx, accountID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get emails: %w", err)
+	}
+
+	return newEmailCursorPage(page), nil
+}
+
+// GetEmailsByCategoryCursor works like GetAccountEmailsCursor for the emails in one category
+func (u *EmailUsecase) GetEmailsByCategoryCursor(ctx context.Context, accountID, categoryID int64, params repositories.CursorParams) (*EmailCursorPage, error) {
+	// First, check if account exists
+	_, err := u.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("account not found: %w", err)
+	}
+
+	page, err := u.emailRepo.GetByCategoryIDCursor(ctx, accountID, categoryID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get emails by category: %w", err)
+	}
+
+	return newEmailCursorPage(page), nil
+}
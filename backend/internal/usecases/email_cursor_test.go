@@ -0,0 +1,53 @@
+package usecases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+func TestEmailCursorRoundTrip(t *testing.T) {
+	cursor := &repositories.EmailCursor{
+		ReceivedAt: time.Date(2024, 3, 5, 10, 30, 15, 123456000, time.UTC),
+		ID:         42,
+	}
+
+	token := EncodeEmailCursor(cursor)
+	decoded, err := DecodeEmailCursor(token)
+	if err != nil {
+		t.Fatalf("Expected token to decode, got %v", err)
+	}
+
+	if !decoded.ReceivedAt.Equal(cursor.ReceivedAt) || decoded.ID != cursor.ID {
+		t.Errorf("Expected %+v, got %+v", cursor, decoded)
+	}
+}
+
+func TestDecodeEmailCursor_FirstPage(t *testing.T) {
+	cursor, err := DecodeEmailCursor("")
+	if err != nil || cursor != nil {
+		t.Errorf("Expected an empty token to start from the first page, got %+v, %v", cursor, err)
+	}
+}
+
+func TestDecodeEmailCursor_Invalid(t *testing.T) {
+	for _, token := range []string{"not base64!", "bm90IGpzb24", "e30"} {
+		if _, err := DecodeEmailCursor(token); err == nil {
+			t.Errorf("Expected token '%s' to be rejected", token)
+		}
+	}
+}
+
+func TestNewEmailCursorPage(t *testing.T) {
+	last := newEmailCursorPage(&repositories.EmailCursorPage{})
+	if last.HasMore || last.NextCursor != "" {
+		t.Errorf("Expected the last page to have no next cursor, got %+v", last)
+	}
+
+	next := &repositories.EmailCursor{ReceivedAt: time.Now(), ID: 7}
+	page := newEmailCursorPage(&repositories.EmailCursorPage{Next: next})
+	if !page.HasMore || page.NextCursor != EncodeEmailCursor(next) {
+		t.Errorf("Expected a next cursor, got %+v", page)
+	}
+}
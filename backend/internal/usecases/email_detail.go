@@ -0,0 +1,197 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	"github.com/email-sorting-app/pkg/mimeparse"
+)
+
+var ErrInvalidImageSignature = errors.New("image URL was not issued by this server")
+
+// EmailDetail is an email as shown to a client. It leaves out the stored body, which
+// is the email as sent: only SafeHTML is fit to render.
+type EmailDetail struct {
+	ID                     int64                 `json:"id"`
+	AccountID              int64                 `json:"account_id"`
+	CategoryIDs            []int64               `json:"category_ids"`
+	Categories             []entities.Category   `json:"categories,omitempty"`
+	GmailMessageID         string                `json:"gmail_message_id"`
+	GmailThreadID          string                `json:"gmail_thread_id"`
+	ThreadID               *int64                `json:"thread_id"`
+	SenderID               *int64                `json:"sender_id"`
+	Sender                 string                `json:"sender"`
+	Subject                string                `json:"subject"`
+	BodyText               string                `json:"body_text"`
+	AISummary              *string               `json:"ai_summary"`
+	ReceivedAt             time.Time             `json:"received_at"`
+	IsArchivedInGmail      bool                  `json:"is_archived_in_gmail"`
+	UnsubscribeLink        *string               `json:"unsubscribe_link"`
+	ListUnsubscribe        string                `json:"list_unsubscribe,omitempty"`
+	ListUnsubscribePost    string                `json:"list_unsubscribe_post,omitempty"`
+	RiskScore              *float64              `json:"risk_score"`
+	RiskReasons            []string              `json:"risk_reasons,omitempty"`
+	MailedAfterUnsubscribe bool                  `json:"mailed_after_unsubscribe"`
+	To                     []string              `json:"to"`
+	Cc                     []string              `json:"cc"`
+	ReplyTo                string                `json:"reply_to,omitempty"`
+	MessageID              string                `json:"message_id,omitempty"`
+	InReplyTo              string                `json:"in_reply_to,omitempty"`
+	References             []string              `json:"references,omitempty"`
+	ListID                 string                `json:"list_id,omitempty"`
+	Snippet                string                `json:"snippet"`
+	SizeEstimate           int64                 `json:"size_estimate"`
+	IsRead                 bool                  `json:"is_read"`
+	IsStarred              bool                  `json:"is_starred"`
+	TrackerCount           *int                  `json:"tracker_count"`
+	Attachments            []entities.Attachment `json:"attachments"`
+	CreatedAt              time.Time             `json:"created_at"`
+	UpdatedAt              time.Time             `json:"updated_at"`
+	// SafeHTML is the sanitized body without tracking pixels, with images pointing at
+	// the image proxy or the email's attachments
+	SafeHTML string `json:"safe_html"`
+	// BlockedImages counts the remote images left out because they weren't proxied
+	BlockedImages int `json:"blocked_images"`
+}
+
+func newEmailDetail(email *entities.Email) *EmailDetail {
+	return &EmailDetail{
+		ID:                     email.ID,
+		AccountID:              email.AccountID,
+		CategoryIDs:            email.CategoryIDs,
+		Categories:             email.Categories,
+		GmailMessageID:         email.GmailMessageID,
+		GmailThreadID:          email.GmailThreadID,
+		ThreadID:               email.ThreadID,
+		SenderID:               email.SenderID,
+		Sender:                 email.Sender,
+		Subject:                email.Subject,
+		BodyText:               email.BodyText,
+		AISummary:              email.AISummary,
+		ReceivedAt:             email.ReceivedAt,
+		IsArchivedInGmail:      email.IsArchivedInGmail,
+		UnsubscribeLink:        email.UnsubscribeLink,
+		ListUnsubscribe:        email.ListUnsubscribe,
+		ListUnsubscribePost:    email.ListUnsubscribePost,
+		RiskScore:              email.RiskScore,
+		RiskReasons:            email.RiskReasons,
+		MailedAfterUnsubscribe: email.MailedAfterUnsubscribe,
+		To:                     email.To,
+		Cc:                     email.Cc,
+		ReplyTo:                email.ReplyTo,
+		MessageID:              email.MessageID,
+		InReplyTo:              email.InReplyTo,
+		References:             email.References,
+		ListID:                 email.ListID,
+		Snippet:                email.Snippet,
+		SizeEstimate:           email.SizeEstimate,
+		IsRead:                 email.IsRead,
+		IsStarred:              email.IsStarred,
+		TrackerCount:           email.TrackerCount,
+		Attachments:            email.Attachments,
+		CreatedAt:              email.CreatedAt,
+		UpdatedAt:              email.UpdatedAt,
+	}
+}
+
+type EmailDetailOptions struct {
+	// BaseURL is the address clients reach this server at, for image URLs
+	BaseURL string
+	// ProxyImages loads remote images through the image proxy instead of blocking them
+	ProxyImages bool
+}
+
+// GetEmailDetail returns an email with its attachments and a body prepared for display.
+// Emails stored before trackers were counted have their count stored on first view.
+func (u *EmailUsecase) GetEmailDetail(ctx context.Context, emailID int64, opts EmailDetailOptions) (*EmailDetail, error) {
+	email, err := u.emailRepo.GetByID(ctx, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	attachments, err := u.attachmentUsecase.GetEmailAttachments(ctx, emailID)
+	if err != nil {
+		return nil, err
+	}
+	email.Attachments = attachments
+
+	detail := newEmailDetail(email)
+
+	if isPlainText(email) {
+		detail.SafeHTML = plainTextHTML(email.BodyText)
+		return detail, nil
+	}
+
+	displayOptions := mimeparse.DisplayOptions{
+		InlineImage: func(contentID string) string {
+			for _, attachment := range attachments {
+				if attachment.ContentID != "" && strings.EqualFold(attachment.ContentID, contentID) {
+					return fmt.Sprintf("%s/emails/%d/attachments/%d", opts.BaseURL, emailID, attachment.ID)
+				}
+			}
+			return ""
+		},
+	}
+	if opts.ProxyImages && u.imageProxy != nil {
+		displayOptions.RemoteImage = func(src string) string {
+			return opts.BaseURL + "/image-proxy?url=" + url.QueryEscape(src) + "&sig=" + url.QueryEscape(u.imageProxy.Sign(src))
+		}
+	}
+
+	prepared := mimeparse.PrepareHTML(email.Body, displayOptions)
+	detail.SafeHTML = prepared.HTML
+	detail.BlockedImages = prepared.BlockedImages
+
+	if email.TrackerCount == nil {
+		trackers := prepared.Trackers
+		detail.TrackerCount = &trackers
+		if err := u.emailRepo.UpdateTrackerCount(ctx, emailID, trackers); err != nil {
+			fmt.Printf("Warning: failed to store tracker count for email %d: %v\n", emailID, err)
+		}
+	}
+
+	return detail, nil
+}
+
+// sanitizeBody makes the stored body of an email safe to render. Bodies are stored as
+// sent, so tracker detection and risk scoring see the original markup.
+func sanitizeBody(email *entities.Email) {
+	if isPlainText(email) {
+		email.Body = plainTextHTML(email.BodyText)
+		return
+	}
+	email.Body = mimeparse.Sanitize(email.Body)
+}
+
+// isPlainText reports whether the email was sent without an HTML part. Text-only
+// emails keep their plain text as the body.
+func isPlainText(email *entities.Email) bool {
+	return email.BodyText != "" && email.Body == email.BodyText
+}
+
+// plainTextHTML renders plain text as HTML, keeping its line breaks
+func plainTextHTML(text string) string {
+	return `<div style="white-space: pre-wrap">` + html.EscapeString(text) + `</div>`
+}
+
+func sanitizeBodies(emails []entities.Email) {
+	for i := range emails {
+		sanitizeBody(&emails[i])
+	}
+}
+
+// FetchRemoteImage loads an image of an email through the image proxy, provided the
+// URL was signed by GetEmailDetail
+func (u *EmailUsecase) FetchRemoteImage(ctx context.Context, src, signature string) (*repositories.ProxiedImage, error) {
+	if u.imageProxy == nil || !u.imageProxy.Verify(src, signature) {
+		return nil, ErrInvalidImageSignature
+	}
+	return u.imageProxy.Fetch(ctx, src)
+}
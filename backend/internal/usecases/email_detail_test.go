@@ -0,0 +1,159 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+type fakeDetailEmailRepository struct {
+	fakeEmailRepository
+	trackerCounts map[int64]int
+}
+
+func (r *fakeDetailEmailRepository) UpdateTrackerCount(ctx context.Context, emailID int64, count int) error {
+	r.trackerCounts[emailID] = count
+	return nil
+}
+
+type fakeImageProxy struct {
+	repositories.ImageProxy
+}
+
+func (p *fakeImageProxy) Sign(src string) string {
+	return "signed"
+}
+
+func (p *fakeImageProxy) Verify(src, signature string) bool {
+	return signature == "signed"
+}
+
+func (p *fakeImageProxy) Fetch(ctx context.Context, src string) (*repositories.ProxiedImage, error) {
+	return &repositories.ProxiedImage{ContentType: "image/png", Data: []byte(src)}, nil
+}
+
+func newTestDetailUsecase(email *entities.Email) (*EmailUsecase, *fakeDetailEmailRepository) {
+	emailRepo := &fakeDetailEmailRepository{
+		fakeEmailRepository: fakeEmailRepository{emails: map[int64]*entities.Email{email.ID: email}},
+		trackerCounts:       map[int64]int{},
+	}
+	attachmentRepo := &fakeAttachmentRepository{attachments: map[int64]*entities.Attachment{
+		3: {ID: 3, EmailID: email.ID, Filename: "logo.png", ContentID: "Logo@Shop", Inline: true},
+		4: {ID: 4, EmailID: email.ID, Filename: "invoice.pdf"},
+	}}
+	attachmentUsecase := NewAttachmentUsecase(attachmentRepo, emailRepo, nil, nil, true, 1024, time.Minute)
+	usecase := NewEmailUsecase(emailRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, attachmentUsecase, &fakeImageProxy{})
+	return usecase, emailRepo
+}
+
+const testDetailBody = `<p>Sale</p><img src="cid:logo@shop"><img src="https://cdn.shop.example/a.png"><img src="https://mail.shop.example/o/1" width="1" height="1">`
+
+func TestEmailUsecase_GetEmailDetail(t *testing.T) {
+	usecase, emailRepo := newTestDetailUsecase(&entities.Email{ID: 1, Body: testDetailBody})
+
+	detail, err := usecase.GetEmailDetail(context.Background(), 1, EmailDetailOptions{BaseURL: "https://app.example"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := `<p>Sale</p><img src="https://app.example/emails/1/attachments/3"/>`
+	if detail.SafeHTML != expected {
+		t.Errorf("Expected %q, got %q", expected, detail.SafeHTML)
+	}
+	if detail.BlockedImages != 1 {
+		t.Errorf("Expected 1 blocked image, got %d", detail.BlockedImages)
+	}
+	if len(detail.Attachments) != 2 {
+		t.Errorf("Expected the email's attachments, got %d", len(detail.Attachments))
+	}
+
+	// The count is stored the first time an email stored before counting is viewed
+	if detail.TrackerCount == nil || *detail.TrackerCount != 1 || emailRepo.trackerCounts[1] != 1 {
+		t.Errorf("Expected 1 tracker to be counted and stored, got %v and %v", detail.TrackerCount, emailRepo.trackerCounts)
+	}
+
+	// Only the prepared body reaches clients
+	encoded, err := json.Marshal(detail)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := fields["body"]; ok || fields["safe_html"] != expected {
+		t.Errorf("Expected only safe_html in the JSON, got %s", encoded)
+	}
+}
+
+func TestEmailUsecase_GetEmailDetailProxiesImages(t *testing.T) {
+	trackers := 1
+	usecase, emailRepo := newTestDetailUsecase(&entities.Email{ID: 1, Body: testDetailBody, TrackerCount: &trackers})
+
+	detail, err := usecase.GetEmailDetail(context.Background(), 1, EmailDetailOptions{BaseURL: "https://app.example", ProxyImages: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := `<p>Sale</p><img src="https://app.example/emails/1/attachments/3"/>` +
+		`<img src="https://app.example/image-proxy?url=https%3A%2F%2Fcdn.shop.example%2Fa.png&amp;sig=signed"/>`
+	if detail.SafeHTML != expected {
+		t.Errorf("Expected %q, got %q", expected, detail.SafeHTML)
+	}
+	if detail.BlockedImages != 0 {
+		t.Errorf("Expected no blocked images, got %d", detail.BlockedImages)
+	}
+	if len(emailRepo.trackerCounts) != 0 {
+		t.Errorf("Expected a known tracker count not to be stored again, got %v", emailRepo.trackerCounts)
+	}
+}
+
+func TestEmailUsecase_GetEmailDetailPlainText(t *testing.T) {
+	usecase, _ := newTestDetailUsecase(&entities.Email{ID: 1, Body: "1 < 2\nbye", BodyText: "1 < 2\nbye"})
+
+	detail, err := usecase.GetEmailDetail(context.Background(), 1, EmailDetailOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := `<div style="white-space: pre-wrap">1 &lt; 2` + "\n" + `bye</div>`
+	if detail.SafeHTML != expected {
+		t.Errorf("Expected %q, got %q", expected, detail.SafeHTML)
+	}
+}
+
+func TestEmailUsecase_FetchRemoteImage(t *testing.T) {
+	usecase, _ := newTestDetailUsecase(&entities.Email{ID: 1})
+
+	if _, err := usecase.FetchRemoteImage(context.Background(), "https://cdn.shop.example/a.png", "forged"); !errors.Is(err, ErrInvalidImageSignature) {
+		t.Errorf("Expected ErrInvalidImageSignature, got %v", err)
+	}
+
+	image, err := usecase.FetchRemoteImage(context.Background(), "https://cdn.shop.example/a.png", "signed")
+	if err != nil || string(image.Data) != "https://cdn.shop.example/a.png" {
+		t.Errorf("Expected the image to be fetched, got %v, %v", image, err)
+	}
+}
+
+func TestSanitizeBodies(t *testing.T) {
+	emails := []entities.Email{
+		{ID: 1, Body: `<p onclick="steal()">Hi</p><script>steal()</script>`, BodyText: "Hi"},
+		{ID: 2, Body: `<img src=x onerror="steal()">`, BodyText: `<img src=x onerror="steal()">`},
+	}
+
+	sanitizeBodies(emails)
+
+	if emails[0].Body != "<p>Hi</p>" {
+		t.Errorf("Expected the HTML body to be sanitized, got %q", emails[0].Body)
+	}
+	// Markup in a text-only email is text, not HTML
+	expected := `<div style="white-space: pre-wrap">&lt;img src=x onerror=&#34;steal()&#34;&gt;</div>`
+	if emails[1].Body != expected {
+		t.Errorf("Expected %q, got %q", expected, emails[1].Body)
+	}
+}
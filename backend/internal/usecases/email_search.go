@@ -0,0 +1,136 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+// Date formats accepted by before: and after:
+var searchDateFormats = []string{"2006-01-02", "2006/01/02"}
+
+// ParseSearchQuery parses the search language: free text with web search syntax
+// ("exact phrase", or, -excluded) plus the operators from:, subject:, category:,
+// before: and after: (dates as YYYY-MM-DD, after: including the day and before:
+// excluding it) and has:unsubscribe. Operator values with spaces are quoted, as in
+// from:"Jane Doe". Words with an unknown operator are searched as text.
+func ParseSearchQuery(input string) (*repositories.EmailSearchQuery, error) {
+	query := &repositories.EmailSearchQuery{}
+	var text []string
+
+	for _, token := range splitSearchTokens(input) {
+		name, value, found := strings.Cut(token, ":")
+		operator := strings.ToLower(name)
+		if !found || !isSearchOperator(operator) {
+			text = append(text, token)
+			continue
+		}
+
+		value = strings.Trim(value, `"`)
+		if value == "" {
+			return nil, fmt.Errorf("missing value for %s:", operator)
+		}
+
+		switch operator {
+		case "from":
+			query.From = append(query.From, value)
+		case "subject":
+			query.Subject = append(query.Subject, value)
+		case "category":
+			query.Categories = append(query.Categories, value)
+		case "before", "after":
+			date, err := parseSearchDate(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid date for %s: %q", operator, value)
+			}
+			if operator == "before" {
+				query.Before = &date
+			} else {
+				query.After = &date
+			}
+		case "has":
+			if strings.ToLower(value) != "unsubscribe" {
+				return nil, fmt.Errorf("unsupported has: value %q", value)
+			}
+			query.HasUnsubscribe = true
+		}
+	}
+
+	query.Text = strings.Join(text, " ")
+	if query.Text == "" && len(query.From) == 0 && len(query.Subject) == 0 && len(query.Categories) == 0 &&
+		query.Before == nil && query.After == nil && !query.HasUnsubscribe {
+		return nil, fmt.Errorf("search query is empty")
+	}
+
+	return query, nil
+}
+
+func isSearchOperator(name string) bool {
+	switch name {
+	case "from", "subject", "category", "before", "after", "has":
+		return true
+	}
+	return false
+}
+
+// splitSearchTokens splits on whitespace outside double quotes, keeping the quotes
+func splitSearchTokens(input string) []string {
+	var tokens []string
+	var current strings.Builder
+	quoted := false
+
+	for _, r := range input {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens
+}
+
+func parseSearchDate(value string) (time.Time, error) {
+	var err error
+	for _, format := range searchDateFormats {
+		var date time.Time
+		date, err = time.Parse(format, value)
+		if err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// SearchEmails runs a parsed search over an account's emails, best matches first
+func (u *EmailUsecase) SearchEmails(ctx context.Context, accountID int64, query *repositories.EmailSearchQuery, params repositories.PaginationParams) (*repositories.EmailSearchResults, error) {
+	// First, check if account exists
+	_, err := u.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("account not found: %w", err)
+	}
+
+	results, err := u.emailRepo.Search(ctx, accountID, query, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search emails: %w", err)
+	}
+	for i := range results.Results {
+		sanitizeBody(&results.Results[i].Email)
+	}
+
+	return results, nil
+}
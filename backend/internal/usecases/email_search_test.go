@@ -0,0 +1,57 @@
+package usecases
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	query, err := ParseSearchQuery(`invoice "due soon" -draft from:"Jane Doe" subject:Q3 category:Work after:2024-01-01 before:2024/02/01 has:unsubscribe note:x`)
+	if err != nil {
+		t.Fatalf("Expected query to parse, got %v", err)
+	}
+
+	if query.Text != `invoice "due soon" -draft note:x` {
+		t.Errorf("Expected text to keep web search syntax and unknown operators, got '%s'", query.Text)
+	}
+	if !reflect.DeepEqual(query.From, []string{"Jane Doe"}) {
+		t.Errorf("Expected quoted from: value, got %v", query.From)
+	}
+	if !reflect.DeepEqual(query.Subject, []string{"Q3"}) {
+		t.Errorf("Expected subject: value, got %v", query.Subject)
+	}
+	if !reflect.DeepEqual(query.Categories, []string{"Work"}) {
+		t.Errorf("Expected category: value, got %v", query.Categories)
+	}
+	if query.After == nil || !query.After.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected after: 2024-01-01, got %v", query.After)
+	}
+	if query.Before == nil || !query.Before.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected before: 2024-02-01, got %v", query.Before)
+	}
+	if !query.HasUnsubscribe {
+		t.Error("Expected has:unsubscribe to be set")
+	}
+}
+
+func TestParseSearchQuery_FiltersOnly(t *testing.T) {
+	query, err := ParseSearchQuery("FROM:news@example.com")
+	if err != nil {
+		t.Fatalf("Expected query to parse, got %v", err)
+	}
+	if query.Text != "" {
+		t.Errorf("Expected no search text, got '%s'", query.Text)
+	}
+	if !reflect.DeepEqual(query.From, []string{"news@example.com"}) {
+		t.Errorf("Expected operators to be case-insensitive, got %v", query.From)
+	}
+}
+
+func TestParseSearchQuery_Invalid(t *testing.T) {
+	for _, input := range []string{"", "   ", "from:", "before:yesterday", "has:attachment"} {
+		if _, err := ParseSearchQuery(input); err == nil {
+			t.Errorf("Expected '%s' to be rejected", input)
+		}
+	}
+}
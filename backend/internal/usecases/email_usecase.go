@@ -3,18 +3,26 @@ package usecases
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/email-sorting-app/internal/domain/entities"
 	"github.com/email-sorting-app/internal/domain/repositories"
 )
 
 type EmailUsecase struct {
-	emailRepo          repositories.EmailRepository
-	accountRepo        repositories.AccountRepository
-	categoryRepo       repositories.CategoryRepository
-	gmailService       repositories.GmailService
-	aiService          repositories.AIService
-	unsubscribeService repositories.UnsubscribeService
+	emailRepo           repositories.EmailRepository
+	accountRepo         repositories.AccountRepository
+	categoryRepo        repositories.CategoryRepository
+	gmailService        repositories.GmailService
+	aiService           repositories.AIService
+	summaryUsecase      *SummaryUsecase
+	extractionUsecase   *ExtractionUsecase
+	threadUsecase       *ThreadUsecase
+	riskUsecase         *RiskUsecase
+	subscriptionUsecase *SubscriptionUsecase
+	senderUsecase       *SenderUsecase
+	attachmentUsecase   *AttachmentUsecase
+	imageProxy          repositories.ImageProxy
 }
 
 func NewEmailUsecase(
@@ -23,15 +31,29 @@ func NewEmailUsecase(
 	categoryRepo repositories.CategoryRepository,
 	gmailService repositories.GmailService,
 	aiService repositories.AIService,
-	unsubscribeService repositories.UnsubscribeService,
+	summaryUsecase *SummaryUsecase,
+	extractionUsecase *ExtractionUsecase,
+	threadUsecase *ThreadUsecase,
+	riskUsecase *RiskUsecase,
+	subscriptionUsecase *SubscriptionUsecase,
+	senderUsecase *SenderUsecase,
+	attachmentUsecase *AttachmentUsecase,
+	imageProxy repositories.ImageProxy,
 ) *EmailUsecase {
 	return &EmailUsecase{
-		emailRepo:          emailRepo,
-		accountRepo:        accountRepo,
-		categoryRepo:       categoryRepo,
-		gmailService:       gmailService,
-		aiService:          aiService,
-		unsubscribeService: unsubscribeService,
+		emailRepo:           emailRepo,
+		accountRepo:         accountRepo,
+		categoryRepo:        categoryRepo,
+		gmailService:        gmailService,
+		aiService:           aiService,
+		summaryUsecase:      summaryUsecase,
+		extractionUsecase:   extractionUsecase,
+		threadUsecase:       threadUsecase,
+		riskUsecase:         riskUsecase,
+		subscriptionUsecase: subscriptionUsecase,
+		senderUsecase:       senderUsecase,
+		attachmentUsecase:   attachmentUsecase,
+		imageProxy:          imageProxy,
 	}
 }
 
@@ -77,6 +99,7 @@ func (u *EmailUsecase) GetAccountEmailsPaginated(ctx context.Context, accountID
 	if err != nil {
 		return nil, fmt.Errorf("failed to get paginated emails: %w", err)
 	}
+	sanitizeBodies(paginatedEmails.Emails)
 
 	return paginatedEmails, nil
 }
@@ -124,24 +147,22 @@ func (u *EmailUsecase) syncEmailsFromGmail(ctx context.Context, account *entitie
 		}
 
 		if !exists {
-			email := entities.Email{
-				AccountID:       account.ID,
-				GmailMessageID:  gmailMsg.ID,
-				Sender:          gmailMsg.Sender,
-				Subject:         gmailMsg.Subject,
-				Body:            gmailMsg.Body,
-				UnsubscribeLink: gmailMsg.UnsubscribeLink,
-				ReceivedAt:      gmailMsg.ReceivedAt,
-			}
-			emailsToCreate = append(emailsToCreate, email)
+			emailsToCreate = append(emailsToCreate, newEmailFromGmail(account.ID, &gmailMsg, nil))
 		}
 	}
 
 	if len(emailsToCreate) > 0 {
-		err = u.emailRepo.BulkCreate(ctx, emailsToCreate)
+		newIDs, err := u.emailRepo.BulkCreate(ctx, emailsToCreate)
 		if err != nil {
 			return fmt.Errorf("failed to bulk create emails: %w", err)
 		}
+
+		// Run the post-sync stages on the emails that weren't already stored
+		if len(newIDs) > 0 {
+			newEmails := insertedEmails(emailsToCreate)
+			u.indexAttachments(ctx, account, newEmails)
+			u.processNewEmails(ctx, account.ID, newEmails)
+		}
 	}
 
 	return nil
@@ -156,6 +177,11 @@ func (u *EmailUsecase) initialSyncAccountEmails(ctx context.Context, account *en
 		return fmt.Errorf("failed to delete existing emails: %w", err)
 	}
 
+	err = u.threadUsecase.ResetAccount(ctx, account.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete existing threads: %w", err)
+	}
+
 	// Get all messages from Gmail using pagination
 	gmailMessages, err := u.gmailService.ListAllMessages(ctx, token)
 	if err != nil {
@@ -215,29 +241,30 @@ func (u *EmailUsecase) initialSyncAccountEmails(ctx context.Context, account *en
 			fmt.Printf("Message %s has no categories assigned\n", gmailMsg.ID)
 		}
 
-		email := entities.Email{
-			AccountID:       account.ID,
-			CategoryIDs:     categoryIDs,
-			GmailMessageID:  gmailMsg.ID,
-			Sender:          gmailMsg.Sender,
-			Subject:         gmailMsg.Subject,
-			Body:            gmailMsg.Body,
-			UnsubscribeLink: gmailMsg.UnsubscribeLink,
-			ReceivedAt:      gmailMsg.ReceivedAt,
-		}
-		emailsToCreate = append(emailsToCreate, email)
+		emailsToCreate = append(emailsToCreate, newEmailFromGmail(account.ID, &gmailMsg, categoryIDs))
 	}
 
 	if len(emailsToCreate) > 0 {
-		err = u.emailRepo.BulkCreate(ctx, emailsToCreate)
+		newIDs, err := u.emailRepo.BulkCreate(ctx, emailsToCreate)
 		if err != nil {
 			return fmt.Errorf("failed to bulk create emails: %w", err)
 		}
 
-		// Apply AI categorization to newly created emails
-		err = u.applyAICategorization(ctx, account.ID, emailsToCreate)
-		if err != nil {
-			fmt.Printf("Warning: failed to apply AI categorization to new emails: %v\n", err)
+		// Only emails that weren't already stored need AI work
+		if len(newIDs) > 0 {
+			newEmails := insertedEmails(emailsToCreate)
+
+			// Index attachments first, so categorization sees the text of documents
+			u.indexAttachments(ctx, account, newEmails)
+
+			// Apply AI categorization to newly created emails
+			err = u.applyAICategorization(ctx, account.ID, newEmails)
+			if err != nil {
+				fmt.Printf("Warning: failed to apply AI categorization to new emails: %v\n", err)
+			}
+
+			// Run the post-sync stages on the new emails
+			u.processNewEmails(ctx, account.ID, newEmails)
 		}
 	}
 
@@ -315,17 +342,7 @@ func (u *EmailUsecase) incrementalSyncAccountEmails(ctx context.Context, account
 
 		if !exists {
 			// Create new email
-			email := entities.Email{
-				AccountID:       account.ID,
-				CategoryIDs:     categoryIDs,
-				GmailMessageID:  gmailMsg.ID,
-				Sender:          gmailMsg.Sender,
-				Subject:         gmailMsg.Subject,
-				Body:            gmailMsg.Body,
-				UnsubscribeLink: gmailMsg.UnsubscribeLink,
-				ReceivedAt:      gmailMsg.ReceivedAt,
-			}
-			emailsToCreate = append(emailsToCreate, email)
+			emailsToCreate = append(emailsToCreate, newEmailFromGmail(account.ID, &gmailMsg, categoryIDs))
 		} else {
 			// Update existing email's categories (labels changed)
 			fmt.Printf("Updating categories for existing email %s\n", gmailMsg.ID)
@@ -333,20 +350,38 @@ func (u *EmailUsecase) incrementalSyncAccountEmails(ctx context.Context, account
 			if err != nil {
 				fmt.Printf("Warning: failed to update categories for message %s: %v\n", gmailMsg.ID, err)
 			}
+
+			// Labels also carry the read and starred state
+			err = u.emailRepo.UpdateFlagsByGmailMessageID(ctx, account.ID, gmailMsg.ID,
+				!gmailMsg.HasLabel(entities.GmailLabelUnread), gmailMsg.HasLabel(entities.GmailLabelStarred))
+			if err != nil {
+				fmt.Printf("Warning: failed to update flags for message %s: %v\n", gmailMsg.ID, err)
+			}
 		}
 	}
 
 	// Bulk create new emails
 	if len(emailsToCreate) > 0 {
-		err = u.emailRepo.BulkCreate(ctx, emailsToCreate)
+		newIDs, err := u.emailRepo.BulkCreate(ctx, emailsToCreate)
 		if err != nil {
 			return fmt.Errorf("failed to bulk create new emails: %w", err)
 		}
 
-		// Apply AI categorization to newly created emails
-		err = u.applyAICategorization(ctx, account.ID, emailsToCreate)
-		if err != nil {
-			fmt.Printf("Warning: failed to apply AI categorization to new emails: %v\n", err)
+		// Only emails that weren't already stored need AI work
+		if len(newIDs) > 0 {
+			newEmails := insertedEmails(emailsToCreate)
+
+			// Index attachments first, so categorization sees the text of documents
+			u.indexAttachments(ctx, account, newEmails)
+
+			// Apply AI categorization to newly created emails
+			err = u.applyAICategorization(ctx, account.ID, newEmails)
+			if err != nil {
+				fmt.Printf("Warning: failed to apply AI categorization to new emails: %v\n", err)
+			}
+
+			// Run the post-sync stages on the new emails
+			u.processNewEmails(ctx, account.ID, newEmails)
 		}
 	}
 
@@ -375,6 +410,7 @@ func (u *EmailUsecase) GetEmailsByCategory(ctx context.Context, accountID, categ
 	if err != nil {
 		return nil, fmt.Errorf("failed to get emails by category: %w", err)
 	}
+	sanitizeBodies(paginatedEmails.Emails)
 
 	return paginatedEmails, nil
 }
@@ -417,6 +453,10 @@ func (u *EmailUsecase) getCategoriesFromLabels(ctx context.Context, accountID in
 			categoryName = "Important"
 		case "INBOX":
 			categoryName = "Inbox"
+		// Gmail's tabs keep their label names, which the clients already treat as
+		// system categories; as categories they can be excluded from summaries
+		case "CATEGORY_PERSONAL", "CATEGORY_SOCIAL", "CATEGORY_PROMOTIONS", "CATEGORY_UPDATES", "CATEGORY_FORUMS":
+			categoryName = label
 		}
 		if categoryName != "" {
 			categoryNames[categoryName] = true
@@ -606,6 +646,8 @@ func (u *EmailUsecase) CategorizeEmailWithAI(ctx context.Context, emailID int64)
 func (u *EmailUsecase) isSystemCategoryName(name string) bool {
 	systemCategories := []string{
 		"Inbox", "Sent", "Drafts", "Spam", "Trash", "Starred", "Important",
+		"CATEGORY_PERSONAL", "CATEGORY_SOCIAL", "CATEGORY_PROMOTIONS", "CATEGORY_UPDATES", "CATEGORY_FORUMS",
+		entities.SuspiciousCategoryName,
 	}
 
 	for _, sysCategory := range systemCategories {
@@ -617,49 +659,151 @@ func (u *EmailUsecase) isSystemCategoryName(name string) bool {
 	return false
 }
 
-func (u *EmailUsecase) UnsubscribeFromEmail(ctx context.Context, emailID int64) (*repositories.UnsubscribeResult, error) {
-	// Get the email
+// DraftReply has the AI write a reply following the user's instruction and saves it
+// as a Gmail draft in the same thread
+func (u *EmailUsecase) DraftReply(ctx context.Context, emailID int64, instruction, tone string) (*entities.DraftReply, error) {
 	email, err := u.emailRepo.GetByID(ctx, emailID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get email: %w", err)
 	}
 
-	// Use the unsubscribe service
-	result, err := u.unsubscribeService.UnsubscribeFromEmail(ctx, email)
+	account, err := u.accountRepo.GetByID(ctx, email.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("account not found: %w", err)
+	}
+
+	token := account.ToOAuth2Token()
+
+	// Fetch the original message for its threading headers
+	original, err := u.gmailService.GetMessage(ctx, token, email.GmailMessageID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unsubscribe: %w", err)
+		return nil, fmt.Errorf("failed to get original message: %w", err)
 	}
 
-	return result, nil
+	body, err := u.aiService.DraftReply(ctx, email, instruction, tone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to draft reply with AI: %w", err)
+	}
+
+	reply := buildReply(original, body)
+	draftID, err := u.gmailService.CreateDraft(ctx, token, reply)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gmail draft: %w", err)
+	}
+
+	return &entities.DraftReply{
+		DraftID:  draftID,
+		ThreadID: reply.ThreadID,
+		To:       reply.To,
+		Subject:  reply.Subject,
+		Body:     reply.Body,
+	}, nil
 }
 
-func (u *EmailUsecase) BulkUnsubscribe(ctx context.Context, emailIDs []int64) (map[int64]*repositories.UnsubscribeResult, error) {
-	if len(emailIDs) == 0 {
-		return make(map[int64]*repositories.UnsubscribeResult), nil
+// buildReply addresses a reply to the original sender and threads it with In-Reply-To/References
+func buildReply(original *entities.GmailMessage, body string) *entities.OutgoingMessage {
+	to := original.Header("Reply-To")
+	if to == "" {
+		to = original.Sender
 	}
 
-	// Get all emails
-	var emails []*entities.Email
-	for _, emailID := range emailIDs {
-		email, err := u.emailRepo.GetByID(ctx, emailID)
-		if err != nil {
-			// Skip emails that can't be found but continue with others
-			continue
+	subject := original.Subject
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+
+	messageID := original.Header("Message-ID")
+	references := strings.TrimSpace(original.Header("References") + " " + messageID)
+
+	return &entities.OutgoingMessage{
+		To:         to,
+		Subject:    subject,
+		Body:       body,
+		ThreadID:   original.ThreadID,
+		InReplyTo:  messageID,
+		References: references,
+	}
+}
+
+// newEmailFromGmail builds the email to store for a synced Gmail message
+func newEmailFromGmail(accountID int64, gmailMsg *entities.GmailMessage, categoryIDs []int64) entities.Email {
+	trackerCount := gmailMsg.TrackerCount
+	return entities.Email{
+		AccountID:           accountID,
+		CategoryIDs:         categoryIDs,
+		GmailMessageID:      gmailMsg.ID,
+		GmailThreadID:       gmailMsg.ThreadID,
+		Sender:              gmailMsg.Sender,
+		Subject:             gmailMsg.Subject,
+		Body:                gmailMsg.Body,
+		BodyText:            gmailMsg.TextBody,
+		Headers:             gmailMsg.Headers,
+		UnsubscribeLink:     gmailMsg.UnsubscribeLink,
+		ListUnsubscribe:     gmailMsg.Header("List-Unsubscribe"),
+		ListUnsubscribePost: gmailMsg.Header("List-Unsubscribe-Post"),
+		To:                  gmailMsg.AddressList("To"),
+		Cc:                  gmailMsg.AddressList("Cc"),
+		ReplyTo:             gmailMsg.Header("Reply-To"),
+		MessageID:           gmailMsg.Header("Message-ID"),
+		InReplyTo:           gmailMsg.Header("In-Reply-To"),
+		References:          gmailMsg.MessageIDList("References"),
+		ListID:              gmailMsg.ListID(),
+		Snippet:             gmailMsg.Snippet,
+		SizeEstimate:        gmailMsg.SizeEstimate,
+		IsRead:              !gmailMsg.HasLabel(entities.GmailLabelUnread),
+		IsStarred:           gmailMsg.HasLabel(entities.GmailLabelStarred),
+		ReceivedAt:          gmailMsg.ReceivedAt,
+		TrackerCount:        &trackerCount,
+		Attachments:         gmailMsg.Attachments,
+	}
+}
+
+// insertedEmails returns the emails BulkCreate inserted, which are the ones it gave an ID
+func insertedEmails(emails []entities.Email) []entities.Email {
+	var inserted []entities.Email
+	for _, email := range emails {
+		if email.ID != 0 {
+			inserted = append(inserted, email)
 		}
-		emails = append(emails, email)
 	}
+	return inserted
+}
 
-	if len(emails) == 0 {
-		return make(map[int64]*repositories.UnsubscribeResult), fmt.Errorf("no valid emails found")
+// indexAttachments stores the attachments of new emails. Failures are logged so
+// they never fail the sync itself.
+func (u *EmailUsecase) indexAttachments(ctx context.Context, account *entities.Account, emails []entities.Email) {
+	err := u.attachmentUsecase.IndexEmails(ctx, account, emails)
+	if err != nil {
+		fmt.Printf("Warning: failed to index attachments of new emails: %v\n", err)
+	}
+}
+
+// processNewEmails runs the stages that follow storing newly synced emails.
+// Failures are logged so they never fail the sync itself.
+func (u *EmailUsecase) processNewEmails(ctx context.Context, accountID int64, emails []entities.Email) {
+	// Deal with senders that ignored an unsubscribe first; trashed emails need no further work
+	emails, err := u.subscriptionUsecase.CheckNewEmails(ctx, accountID, emails)
+	if err != nil {
+		fmt.Printf("Warning: failed to check new emails against unsubscribed senders: %v\n", err)
+	}
+
+	// Link the emails that are kept to their senders and refresh sender statistics
+	err = u.senderUsecase.RecordEmails(ctx, accountID, emails)
+	if err != nil {
+		fmt.Printf("Warning: failed to record senders of new emails: %v\n", err)
 	}
 
-	// Use the unsubscribe service for bulk operation
-	results, err := u.unsubscribeService.BulkUnsubscribe(ctx, emails)
+	// Group new emails into conversations
+	err = u.threadUsecase.AssignThreads(ctx, accountID, emails)
 	if err != nil {
-		return nil, fmt.Errorf("failed to bulk unsubscribe: %w", err)
+		fmt.Printf("Warning: failed to assign threads to new emails: %v\n", err)
 	}
 
-	return results, nil
+	// Score phishing risk, summarize and extract data from new emails in the background.
+	// Risk scoring is queued with the emails so it sees the message headers.
+	u.riskUsecase.EnqueueEmails(accountID, emails)
+	u.summaryUsecase.EnqueueEmails(emails)
+	u.extractionUsecase.EnqueueEmails(emails)
 }
 
 // applyAICategorization applies AI categorization to newly created emails
@@ -0,0 +1,121 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+)
+
+func TestBuildReply(t *testing.T) {
+	original := &entities.GmailMessage{
+		ThreadID: "thread-1",
+		Sender:   "Jane Doe <jane@example.com>",
+		Subject:  "Lunch on Friday?",
+		Headers: map[string]string{
+			"Message-ID": "<orig@example.com>",
+			"References": "<first@example.com>",
+		},
+	}
+
+	reply := buildReply(original, "Friday works for me.")
+
+	if reply.To != "Jane Doe <jane@example.com>" {
+		t.Errorf("Expected To to be the original sender, got '%s'", reply.To)
+	}
+
+	if reply.Subject != "Re: Lunch on Friday?" {
+		t.Errorf("Expected Subject to be 'Re: Lunch on Friday?', got '%s'", reply.Subject)
+	}
+
+	if reply.InReplyTo != "<orig@example.com>" {
+		t.Errorf("Expected InReplyTo to be '<orig@example.com>', got '%s'", reply.InReplyTo)
+	}
+
+	if reply.References != "<first@example.com> <orig@example.com>" {
+		t.Errorf("Expected References to chain the original, got '%s'", reply.References)
+	}
+
+	if reply.ThreadID != "thread-1" {
+		t.Errorf("Expected ThreadID to be 'thread-1', got '%s'", reply.ThreadID)
+	}
+}
+
+func TestBuildReply_UsesReplyToAndKeepsPrefix(t *testing.T) {
+	original := &entities.GmailMessage{
+		Sender:  "News <news@example.com>",
+		Subject: "RE: Your order",
+		Headers: map[string]string{"Reply-To": "support@example.com"},
+	}
+
+	reply := buildReply(original, "Thanks")
+
+	if reply.To != "support@example.com" {
+		t.Errorf("Expected To to be the Reply-To address, got '%s'", reply.To)
+	}
+
+	if reply.Subject != "RE: Your order" {
+		t.Errorf("Expected Subject to keep the existing prefix, got '%s'", reply.Subject)
+	}
+
+	if reply.References != "" {
+		t.Errorf("Expected References to be empty, got '%s'", reply.References)
+	}
+}
+
+func TestInsertedEmails(t *testing.T) {
+	emails := []entities.Email{
+		{ID: 1, GmailMessageID: "new-1"},
+		{GmailMessageID: "already-stored"},
+		{ID: 2, GmailMessageID: "new-2"},
+	}
+
+	inserted := insertedEmails(emails)
+
+	if len(inserted) != 2 || inserted[0].ID != 1 || inserted[1].ID != 2 {
+		t.Errorf("Expected only the emails given IDs by BulkCreate, got %+v", inserted)
+	}
+}
+
+func TestNewEmailFromGmail(t *testing.T) {
+	msg := &entities.GmailMessage{
+		ID:           "msg-1",
+		ThreadID:     "thread-1",
+		Sender:       "News <news@example.com>",
+		Snippet:      "This week's top stories",
+		SizeEstimate: 2048,
+		TrackerCount: 2,
+		Labels:       []string{"INBOX", entities.GmailLabelStarred},
+		Headers: map[string]string{
+			"To":          "Me <me@example.com>",
+			"Reply-To":    "replies@example.com",
+			"Message-Id":  "<weekly@example.com>",
+			"In-Reply-To": "<previous@example.com>",
+			"References":  "<first@example.com> <previous@example.com>",
+			"List-Id":     "Example News <news.example.com>",
+		},
+	}
+
+	email := newEmailFromGmail(7, msg, []int64{3})
+
+	if email.AccountID != 7 || email.GmailMessageID != "msg-1" || len(email.CategoryIDs) != 1 {
+		t.Errorf("Expected the account, message and categories to be set, got %+v", email)
+	}
+	if len(email.To) != 1 || email.To[0] != "me@example.com" || len(email.Cc) != 0 {
+		t.Errorf("Expected recipients from the headers, got To %v Cc %v", email.To, email.Cc)
+	}
+	if email.MessageID != "<weekly@example.com>" || email.InReplyTo != "<previous@example.com>" || len(email.References) != 2 {
+		t.Errorf("Expected threading headers, got %q %q %v", email.MessageID, email.InReplyTo, email.References)
+	}
+	if email.ReplyTo != "replies@example.com" || email.ListID != "news.example.com" {
+		t.Errorf("Expected Reply-To and List-Id, got %q %q", email.ReplyTo, email.ListID)
+	}
+	if email.Snippet != "This week's top stories" || email.SizeEstimate != 2048 {
+		t.Errorf("Expected the Gmail snippet and size, got %q %d", email.Snippet, email.SizeEstimate)
+	}
+	if !email.IsRead || !email.IsStarred {
+		t.Errorf("Expected a starred message without the UNREAD label to be read and starred, got read %v starred %v", email.IsRead, email.IsStarred)
+	}
+	if email.TrackerCount == nil || *email.TrackerCount != 2 {
+		t.Errorf("Expected the tracker count to be kept, got %v", email.TrackerCount)
+	}
+}
@@ -0,0 +1,230 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+)
+
+const (
+	extractionQueueSize    = 1000
+	extractionBacklogBatch = 50
+	// Emails whose extraction failed this many times are left unextracted
+	maxExtractionAttempts = 3
+)
+
+// ExtractionUsecase pulls action items, deadlines and other entities out of emails.
+// New emails are processed in the background within the daily AI budget: they are
+// queued as they arrive, and a backlog processor picks up the ones that didn't fit
+// in the queue or the budget. Older emails are extracted on demand.
+type ExtractionUsecase struct {
+	emailRepo       repositories.EmailRepository
+	extractionRepo  repositories.ExtractionRepository
+	aiService       repositories.AIService
+	budget          *AIBudget
+	backlogInterval time.Duration
+	queue           chan int64
+}
+
+func NewExtractionUsecase(
+	emailRepo repositories.EmailRepository,
+	extractionRepo repositories.ExtractionRepository,
+	aiService repositories.AIService,
+	budget *AIBudget,
+	backlogInterval time.Duration,
+) *ExtractionUsecase {
+	return &ExtractionUsecase{
+		emailRepo:       emailRepo,
+		extractionRepo:  extractionRepo,
+		aiService:       aiService,
+		budget:          budget,
+		backlogInterval: backlogInterval,
+		queue:           make(chan int64, extractionQueueSize),
+	}
+}
+
+// Start launches the extraction worker and the backlog processor. Both stop when ctx is cancelled.
+func (u *ExtractionUsecase) Start(ctx context.Context) {
+	go u.runWorker(ctx)
+	go u.runBacklog(ctx)
+}
+
+// EnqueueEmails schedules newly created emails for extraction without blocking the sync.
+// Emails that don't fit in the queue are picked up later by the backlog processor.
+func (u *ExtractionUsecase) EnqueueEmails(emails []entities.Email) {
+	for _, email := range emails {
+		if email.ID == 0 {
+			continue
+		}
+
+		select {
+		case u.queue <- email.ID:
+		default:
+			fmt.Printf("Warning: extraction queue is full, leaving remaining emails to the backlog processor\n")
+			return
+		}
+	}
+}
+
+// ProcessBacklog extracts new emails left pending until today's budget is spent.
+// It returns the number of emails extracted.
+func (u *ExtractionUsecase) ProcessBacklog(ctx context.Context) (int, error) {
+	remaining, err := u.budget.Remaining(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if remaining == 0 {
+		return 0, nil
+	}
+
+	emailIDs, err := u.extractionRepo.GetPendingExtractions(ctx, min(remaining, extractionBacklogBatch), maxExtractionAttempts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get emails pending extraction: %w", err)
+	}
+
+	processed := 0
+	for _, emailID := range emailIDs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		// New emails may have spent the budget since it was checked
+		reserved, err := u.budget.Reserve(ctx)
+		if err != nil {
+			return processed, err
+		}
+		if !reserved {
+			break
+		}
+
+		if extractErr := u.extract(ctx, emailID); extractErr != nil {
+			fmt.Printf("Warning: failed to extract data from email %d: %v\n", emailID, extractErr)
+			continue
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+func (u *ExtractionUsecase) runWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case emailID := <-u.queue:
+			if err := u.extractNewEmail(ctx, emailID); err != nil {
+				fmt.Printf("Warning: failed to extract data from email %d: %v\n", emailID, err)
+			}
+		}
+	}
+}
+
+func (u *ExtractionUsecase) runBacklog(ctx context.Context) {
+	ticker := time.NewTicker(u.backlogInterval)
+	defer ticker.Stop()
+
+	for {
+		processed, err := u.ProcessBacklog(ctx)
+		if err != nil {
+			fmt.Printf("Warning: extraction backlog processing failed: %v\n", err)
+		} else if processed > 0 {
+			fmt.Printf("Extraction backlog processed %d emails\n", processed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// extractNewEmail extracts a synced email if the daily AI budget allows it. Over
+// budget, the email waits for the backlog processor on a later day.
+func (u *ExtractionUsecase) extractNewEmail(ctx context.Context, emailID int64) error {
+	reserved, err := u.budget.Reserve(ctx)
+	if err != nil || !reserved {
+		return err
+	}
+
+	return u.extract(ctx, emailID)
+}
+
+// extract runs a background extraction, counting failures so the backlog processor
+// backs off and eventually gives up
+func (u *ExtractionUsecase) extract(ctx context.Context, emailID int64) error {
+	_, err := u.ExtractFromEmail(ctx, emailID)
+	if err != nil && ctx.Err() == nil {
+		if recordErr := u.extractionRepo.RecordExtractionFailure(ctx, emailID); recordErr != nil {
+			fmt.Printf("Warning: failed to record extraction failure for email %d: %v\n", emailID, recordErr)
+		}
+	}
+	return err
+}
+
+func (u *ExtractionUsecase) ExtractFromEmail(ctx context.Context, emailID int64) (*entities.EmailExtraction, error) {
+	email, err := u.emailRepo.GetByID(ctx, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	extraction, err := u.aiService.ExtractEmailData(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract email data with AI: %w", err)
+	}
+	extraction.EmailID = email.ID
+
+	err = u.extractionRepo.SaveExtraction(ctx, email.AccountID, extraction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save extraction: %w", err)
+	}
+
+	return extraction, nil
+}
+
+func (u *ExtractionUsecase) GetEmailExtraction(ctx context.Context, emailID int64) (*entities.EmailExtraction, error) {
+	_, err := u.emailRepo.GetByID(ctx, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	extraction, err := u.extractionRepo.GetByEmailID(ctx, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get extraction: %w", err)
+	}
+
+	return extraction, nil
+}
+
+// GetOpenActionItems lists open action items across all accounts, soonest deadline first
+func (u *ExtractionUsecase) GetOpenActionItems(ctx context.Context) ([]entities.ActionItem, error) {
+	items, err := u.extractionRepo.GetOpenActionItems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open action items: %w", err)
+	}
+
+	return items, nil
+}
+
+func (u *ExtractionUsecase) UpdateActionItemStatus(ctx context.Context, actionItemID int64, status string) error {
+	if status != entities.ActionItemStatusOpen && status != entities.ActionItemStatusDone {
+		return apperrors.NewInvalidInputError(fmt.Sprintf("invalid action item status: %s", status))
+	}
+
+	_, err := u.extractionRepo.GetActionItemByID(ctx, actionItemID)
+	if err != nil {
+		return err
+	}
+
+	err = u.extractionRepo.UpdateActionItemStatus(ctx, actionItemID, status)
+	if err != nil {
+		return fmt.Errorf("failed to update action item: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+)
+
+type fakeExtractionRepository struct {
+	repositories.ExtractionRepository
+	saved    []int64
+	pending  []int64
+	failures map[int64]int
+}
+
+func (r *fakeExtractionRepository) SaveExtraction(ctx context.Context, accountID int64, extraction *entities.EmailExtraction) error {
+	r.saved = append(r.saved, extraction.EmailID)
+	return nil
+}
+
+func (r *fakeExtractionRepository) GetPendingExtractions(ctx context.Context, limit, maxAttempts int) ([]int64, error) {
+	var pending []int64
+	for _, emailID := range r.pending {
+		if !slices.Contains(r.saved, emailID) && r.failures[emailID] < maxAttempts && len(pending) < limit {
+			pending = append(pending, emailID)
+		}
+	}
+	return pending, nil
+}
+
+func (r *fakeExtractionRepository) RecordExtractionFailure(ctx context.Context, emailID int64) error {
+	r.failures[emailID]++
+	return nil
+}
+
+func (r *fakeExtractionRepository) GetActionItemByID(ctx context.Context, id int64) (*entities.ActionItem, error) {
+	return nil, apperrors.NewNotFoundError("action item not found")
+}
+
+type fakeExtractionAIService struct {
+	repositories.AIService
+	failing int64
+}
+
+func (s *fakeExtractionAIService) ExtractEmailData(ctx context.Context, email *entities.Email) (*entities.EmailExtraction, error) {
+	if email.ID == s.failing {
+		return nil, fmt.Errorf("AI unavailable")
+	}
+	return &entities.EmailExtraction{}, nil
+}
+
+func TestExtractionUsecase_NewEmailsStayWithinBudget(t *testing.T) {
+	emailRepo := &fakeEmailRepository{emails: map[int64]*entities.Email{1: {ID: 1}, 2: {ID: 2}}}
+	extractionRepo := &fakeExtractionRepository{failures: map[int64]int{}}
+	budget := NewAIBudget(&fakeAIUsageRepository{usage: map[string]int{}}, 1)
+	usecase := NewExtractionUsecase(emailRepo, extractionRepo, &fakeExtractionAIService{}, budget, time.Minute)
+
+	for _, id := range []int64{1, 2} {
+		if err := usecase.extractNewEmail(context.Background(), id); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if len(extractionRepo.saved) != 1 {
+		t.Errorf("Expected one extraction within the budget, got %v", extractionRepo.saved)
+	}
+
+	// Extractions the user asks for aren't limited
+	if _, err := usecase.ExtractFromEmail(context.Background(), 2); err != nil || len(extractionRepo.saved) != 2 {
+		t.Errorf("Expected an on-demand extraction, got %v and %v", err, extractionRepo.saved)
+	}
+}
+
+func TestExtractionUsecase_ProcessBacklog(t *testing.T) {
+	emailRepo := &fakeEmailRepository{emails: map[int64]*entities.Email{1: {ID: 1}, 2: {ID: 2}, 3: {ID: 3}}}
+	extractionRepo := &fakeExtractionRepository{pending: []int64{1, 2, 3}, failures: map[int64]int{}}
+	budget := NewAIBudget(&fakeAIUsageRepository{usage: map[string]int{}}, 10)
+	usecase := NewExtractionUsecase(emailRepo, extractionRepo, &fakeExtractionAIService{failing: 2}, budget, time.Minute)
+
+	// Emails overflowing the queue are left to the backlog
+	for range extractionQueueSize {
+		usecase.queue <- 0
+	}
+	usecase.EnqueueEmails([]entities.Email{{ID: 1}, {ID: 2}, {ID: 3}})
+
+	processed, err := usecase.ProcessBacklog(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if processed != 2 || !slices.Equal(extractionRepo.saved, []int64{1, 3}) {
+		t.Errorf("Expected the pending emails to be extracted, got %d and %v", processed, extractionRepo.saved)
+	}
+
+	// Failures are retried a few times within the budget, then given up
+	for range maxExtractionAttempts {
+		if _, err := usecase.ProcessBacklog(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	remaining, _ := budget.Remaining(context.Background())
+	if extractionRepo.failures[2] != maxExtractionAttempts || remaining != 10-2-maxExtractionAttempts {
+		t.Errorf("Expected %d failed attempts, got %d with %d calls left", maxExtractionAttempts, extractionRepo.failures[2], remaining)
+	}
+}
+
+func TestExtractionUsecase_UpdateActionItemStatusErrors(t *testing.T) {
+	usecase := NewExtractionUsecase(nil, &fakeExtractionRepository{}, nil, nil, time.Minute)
+
+	err := usecase.UpdateActionItemStatus(context.Background(), 1, "archived")
+	if !errors.Is(err, apperrors.ErrInvalidInput) {
+		t.Errorf("Expected an invalid input error, got %v", err)
+	}
+
+	err = usecase.UpdateActionItemStatus(context.Background(), 1, entities.ActionItemStatusDone)
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,463 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+)
+
+// JobUsecase runs bulk operations on emails, such as unsubscribing or archiving, as
+// background jobs. Items of all jobs share a bounded number of workers, and
+// unsubscribe requests to the same host are spaced out.
+//
+// Cancelling a job, or the context the usecase was started with, stops new items
+// from starting. Items already running are allowed to finish: on shutdown, Shutdown
+// waits for them.
+type JobUsecase struct {
+	jobRepo             repositories.JobRepository
+	emailRepo           repositories.EmailRepository
+	accountRepo         repositories.AccountRepository
+	gmailService        repositories.GmailService
+	subscriptionUsecase *SubscriptionUsecase
+	workers             chan struct{}
+	limiter             *hostLimiter
+
+	mu      sync.Mutex
+	baseCtx context.Context
+	running map[int64]context.CancelFunc
+
+	// Items run on itemCtx, which is only cancelled when Shutdown gives up waiting
+	itemCtx   context.Context
+	stopItems context.CancelFunc
+	jobs      sync.WaitGroup
+}
+
+func NewJobUsecase(
+	jobRepo repositories.JobRepository,
+	emailRepo repositories.EmailRepository,
+	accountRepo repositories.AccountRepository,
+	gmailService repositories.GmailService,
+	subscriptionUsecase *SubscriptionUsecase,
+	concurrency int,
+	hostDelay time.Duration,
+) *JobUsecase {
+	itemCtx, stopItems := context.WithCancel(context.Background())
+	return &JobUsecase{
+		jobRepo:             jobRepo,
+		emailRepo:           emailRepo,
+		accountRepo:         accountRepo,
+		gmailService:        gmailService,
+		subscriptionUsecase: subscriptionUsecase,
+		workers:             make(chan struct{}, max(concurrency, 1)),
+		limiter:             newHostLimiter(hostDelay),
+		baseCtx:             context.Background(),
+		running:             make(map[int64]context.CancelFunc),
+		itemCtx:             itemCtx,
+		stopItems:           stopItems,
+	}
+}
+
+// Start resumes jobs left unfinished by a previous run. Jobs stop starting items
+// when ctx is cancelled, and are resumed on the next start.
+func (u *JobUsecase) Start(ctx context.Context) {
+	u.mu.Lock()
+	u.baseCtx = ctx
+	u.mu.Unlock()
+
+	jobIDs, err := u.jobRepo.GetUnfinishedIDs(ctx)
+	if err != nil {
+		fmt.Printf("Warning: failed to resume jobs: %v\n", err)
+		return
+	}
+
+	for _, jobID := range jobIDs {
+		u.launch(jobID)
+	}
+}
+
+// SubmitBulkUnsubscribe creates a job for the given emails and starts it in the
+// background. Only the first email of each sender is unsubscribed; the rest are skipped.
+func (u *JobUsecase) SubmitBulkUnsubscribe(ctx context.Context, emailIDs []int64) (*entities.Job, error) {
+	job := &entities.Job{}
+	senders := make(map[string]bool)
+	for _, emailID := range emailIDs {
+		email, err := u.emailRepo.GetByID(ctx, emailID)
+		if err != nil {
+			// Skip emails that can't be found but continue with others
+			continue
+		}
+
+		item := entities.JobItem{EmailID: email.ID}
+		sender := entities.SenderAddress(email.Sender)
+		if senders[sender] {
+			item.Status = entities.JobItemStatusSkipped
+			item.Message = fmt.Sprintf("Another email from %s is already in this job", sender)
+		}
+		senders[sender] = true
+
+		job.Items = append(job.Items, item)
+	}
+
+	if len(job.Items) == 0 {
+		return nil, fmt.Errorf("no valid emails found")
+	}
+
+	err := u.jobRepo.Create(ctx, job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create unsubscribe job: %w", err)
+	}
+
+	u.launch(job.ID)
+
+	return job, nil
+}
+
+// SubmitArchive creates a job archiving the given emails in Gmail and starts it in the
+// background. Emails that are already archived are skipped.
+func (u *JobUsecase) SubmitArchive(ctx context.Context, emails []entities.Email) (*entities.Job, error) {
+	if len(emails) == 0 {
+		return nil, apperrors.NewInvalidInputError("no emails to archive")
+	}
+
+	job := &entities.Job{Kind: entities.JobKindArchive}
+	for _, email := range emails {
+		item := entities.JobItem{EmailID: email.ID}
+		if email.IsArchivedInGmail {
+			item.Status = entities.JobItemStatusSkipped
+			item.Message = "Already archived"
+		}
+		job.Items = append(job.Items, item)
+	}
+
+	err := u.jobRepo.Create(ctx, job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive job: %w", err)
+	}
+
+	u.launch(job.ID)
+
+	return job, nil
+}
+
+func (u *JobUsecase) GetJob(ctx context.Context, jobID int64) (*entities.Job, error) {
+	return u.jobRepo.GetByID(ctx, jobID)
+}
+
+// CancelJob stops a job; items already being processed are allowed to finish
+func (u *JobUsecase) CancelJob(ctx context.Context, jobID int64) (*entities.Job, error) {
+	job, err := u.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.IsFinished() {
+		return nil, apperrors.NewInvalidInputError(fmt.Sprintf("job is already %s", job.Status))
+	}
+
+	u.mu.Lock()
+	cancel, running := u.running[jobID]
+	u.mu.Unlock()
+
+	if running {
+		// The job goroutine records the cancellation once in-flight items are done
+		cancel()
+	} else {
+		err = u.finishCancelled(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return u.jobRepo.GetByID(ctx, jobID)
+}
+
+// Shutdown waits for running items to finish, or ctx to expire, after the context
+// the usecase was started with is cancelled. Items still running then are aborted
+// and stay pending, to be resumed on the next start.
+func (u *JobUsecase) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		u.jobs.Wait()
+		close(done)
+	}()
+
+	defer u.stopItems()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("job items still running at shutdown: %w", ctx.Err())
+	}
+}
+
+func (u *JobUsecase) launch(jobID int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, running := u.running[jobID]; running {
+		return
+	}
+
+	jobCtx, cancel := context.WithCancel(u.baseCtx)
+	u.running[jobID] = cancel
+	u.jobs.Add(1)
+
+	go func() {
+		defer u.jobs.Done()
+		defer func() {
+			u.mu.Lock()
+			delete(u.running, jobID)
+			u.mu.Unlock()
+			cancel()
+		}()
+
+		if err := u.runJob(jobCtx, jobID); err != nil {
+			fmt.Printf("Warning: job %d failed: %v\n", jobID, err)
+		}
+	}()
+}
+
+func (u *JobUsecase) runJob(ctx context.Context, jobID int64) error {
+	job, err := u.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return err
+	}
+
+	err = u.jobRepo.UpdateStatus(ctx, jobID, entities.JobStatusRunning)
+	if err != nil {
+		return err
+	}
+
+	// Interleave hosts so workers aren't all waiting on the same one
+	pending := make(map[string][]pendingItem)
+	var hosts []string
+	for _, item := range job.Items {
+		if item.Status != entities.JobItemStatusPending {
+			continue
+		}
+
+		email, err := u.emailRepo.GetByID(ctx, item.EmailID)
+		if err != nil {
+			u.recordItem(ctx, item, entities.JobItemStatusFailed, "Email no longer exists", "not_found")
+			continue
+		}
+
+		// Archiving only calls the Gmail API, so its items aren't spaced out by host
+		host := ""
+		if job.Kind != entities.JobKindArchive {
+			host = unsubscribeHost(email)
+		}
+		if _, exists := pending[host]; !exists {
+			hosts = append(hosts, host)
+		}
+		pending[host] = append(pending[host], pendingItem{item: item, kind: job.Kind, host: host})
+	}
+
+	var wg sync.WaitGroup
+	for _, next := range interleave(hosts, pending) {
+		select {
+		case <-ctx.Done():
+		case u.workers <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-u.workers }()
+				u.processItem(ctx, next)
+			}()
+			continue
+		}
+		break
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		u.mu.Lock()
+		shuttingDown := u.baseCtx.Err() != nil
+		u.mu.Unlock()
+
+		// On shutdown the job stays running and is resumed on the next start
+		if shuttingDown {
+			return nil
+		}
+		return u.finishCancelled(context.Background(), jobID)
+	}
+
+	return u.jobRepo.UpdateStatus(ctx, jobID, entities.JobStatusCompleted)
+}
+
+type pendingItem struct {
+	item entities.JobItem
+	kind string
+	host string
+}
+
+// processItem runs an item once the job's ctx lets it start. The item itself runs
+// on itemCtx, so cancelling the job doesn't abort it halfway.
+func (u *JobUsecase) processItem(ctx context.Context, next pendingItem) {
+	// A worker may free up just as the job is cancelled
+	if ctx.Err() != nil {
+		return
+	}
+
+	if next.kind == entities.JobKindArchive {
+		u.archiveItem(u.itemCtx, next.item)
+		return
+	}
+
+	if err := u.limiter.Wait(ctx, next.host); err != nil {
+		return // Cancelled; the item stays pending
+	}
+
+	result, err := u.subscriptionUsecase.UnsubscribeFromEmail(u.itemCtx, next.item.EmailID)
+	if err != nil {
+		if u.itemCtx.Err() != nil {
+			return // Abandoned at shutdown; the item stays pending
+		}
+		u.recordItem(u.itemCtx, next.item, entities.JobItemStatusFailed, fmt.Sprintf("Error: %v", err), "processing_error")
+		return
+	}
+
+	status := entities.JobItemStatusFailed
+	if result.Success {
+		status = entities.JobItemStatusSucceeded
+	}
+	next.item.Strategy = result.Strategy
+	u.recordItem(u.itemCtx, next.item, status, result.Message, result.ErrorType)
+}
+
+// archiveItem archives the email of an item in Gmail
+func (u *JobUsecase) archiveItem(ctx context.Context, item entities.JobItem) {
+	err := u.archiveEmail(ctx, item.EmailID)
+	if err != nil {
+		if ctx.Err() != nil {
+			return // Abandoned at shutdown; the item stays pending
+		}
+		u.recordItem(ctx, item, entities.JobItemStatusFailed, fmt.Sprintf("Error: %v", err), "archive_error")
+		return
+	}
+
+	u.recordItem(ctx, item, entities.JobItemStatusSucceeded, "Archived", "")
+}
+
+func (u *JobUsecase) archiveEmail(ctx context.Context, emailID int64) error {
+	email, err := u.emailRepo.GetByID(ctx, emailID)
+	if err != nil {
+		return fmt.Errorf("failed to get email: %w", err)
+	}
+
+	account, err := u.accountRepo.GetByID(ctx, email.AccountID)
+	if err != nil {
+		return fmt.Errorf("account not found: %w", err)
+	}
+
+	err = u.gmailService.ArchiveMessage(ctx, account.ToOAuth2Token(), email.GmailMessageID)
+	if err != nil {
+		return err
+	}
+
+	if err := u.emailRepo.UpdateArchivedInGmail(ctx, email.ID, true); err != nil {
+		fmt.Printf("Warning: failed to update archived state of email %d: %v\n", email.ID, err)
+	}
+	return nil
+}
+
+func (u *JobUsecase) recordItem(ctx context.Context, item entities.JobItem, status, message, errorType string) {
+	item.Status = status
+	item.Message = message
+	item.ErrorType = errorType
+
+	// Record the outcome even if the job was cancelled meanwhile
+	if err := u.jobRepo.UpdateItem(context.WithoutCancel(ctx), &item); err != nil {
+		fmt.Printf("Warning: failed to update job item %d: %v\n", item.ID, err)
+	}
+}
+
+func (u *JobUsecase) finishCancelled(ctx context.Context, jobID int64) error {
+	err := u.jobRepo.CancelPendingItems(ctx, jobID)
+	if err != nil {
+		return err
+	}
+
+	return u.jobRepo.UpdateStatus(ctx, jobID, entities.JobStatusCancelled)
+}
+
+// interleave orders items round-robin across hosts
+func interleave(hosts []string, pending map[string][]pendingItem) []pendingItem {
+	var ordered []pendingItem
+	for round := 0; ; round++ {
+		added := false
+		for _, host := range hosts {
+			if round < len(pending[host]) {
+				ordered = append(ordered, pending[host][round])
+				added = true
+			}
+		}
+		if !added {
+			return ordered
+		}
+	}
+}
+
+// unsubscribeHost is the host an unsubscribe request for the email will most likely go to
+func unsubscribeHost(email *entities.Email) string {
+	if email.UnsubscribeLink != nil {
+		if parsed, err := url.Parse(*email.UnsubscribeLink); err == nil && parsed.Hostname() != "" {
+			return strings.ToLower(parsed.Hostname())
+		}
+	}
+	return entities.SenderDomain(entities.SenderAddress(email.Sender))
+}
+
+// hostLimiter spaces out requests to the same host by handing out time slots
+type hostLimiter struct {
+	delay time.Duration
+	mu    sync.Mutex
+	next  map[string]time.Time
+}
+
+func newHostLimiter(delay time.Duration) *hostLimiter {
+	return &hostLimiter{
+		delay: delay,
+		next:  make(map[string]time.Time),
+	}
+}
+
+// Wait blocks until the host's next free slot, or returns an error if ctx is cancelled first
+func (l *hostLimiter) Wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	now := time.Now()
+	// Hosts whose slot has passed would start now anyway, so they're forgotten
+	for other, slot := range l.next {
+		if slot.Before(now) {
+			delete(l.next, other)
+		}
+	}
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(l.delay)
+	l.mu.Unlock()
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
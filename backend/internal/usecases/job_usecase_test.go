@@ -0,0 +1,244 @@
+package usecases
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+type fakeJobRepository struct {
+	repositories.JobRepository
+	mu       sync.Mutex
+	job      *entities.Job
+	statuses []string
+}
+
+func (r *fakeJobRepository) Create(ctx context.Context, job *entities.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.ID = 1
+	for i := range job.Items {
+		job.Items[i].ID = int64(i + 1)
+		job.Items[i].JobID = job.ID
+		if job.Items[i].Status == "" {
+			job.Items[i].Status = entities.JobItemStatusPending
+		}
+	}
+	job.Status = entities.JobStatusQueued
+	job.Tally()
+	r.job = job
+	return nil
+}
+
+func (r *fakeJobRepository) GetByID(ctx context.Context, id int64) (*entities.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := *r.job
+	job.Items = append([]entities.JobItem(nil), r.job.Items...)
+	job.Tally()
+	return &job, nil
+}
+
+func (r *fakeJobRepository) GetUnfinishedIDs(ctx context.Context) ([]int64, error) {
+	return nil, nil
+}
+
+func (r *fakeJobRepository) UpdateStatus(ctx context.Context, id int64, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.job.Status = status
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+
+func (r *fakeJobRepository) UpdateItem(ctx context.Context, item *entities.JobItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.job.Items {
+		if r.job.Items[i].ID == item.ID {
+			r.job.Items[i] = *item
+		}
+	}
+	return nil
+}
+
+func TestJobUsecase_RunJob(t *testing.T) {
+	emailRepo := &fakeEmailRepository{emails: map[int64]*entities.Email{
+		1: {ID: 1, AccountID: 1, Sender: "Shop <news@shop.com>"},
+		2: {ID: 2, AccountID: 1, Sender: "news@shop.com"},
+		3: {ID: 3, AccountID: 1, Sender: "digest@blog.org"},
+	}}
+	service := &fakeUnsubscribeService{}
+	subscriptionUsecase := NewSubscriptionUsecase(&fakeSubscriptionRepository{}, nil, emailRepo, nil, nil, service, nil,
+		time.Hour, entities.UnsubscribeViolationActionNone, false)
+	jobRepo := &fakeJobRepository{}
+	usecase := NewJobUsecase(jobRepo, emailRepo, nil, nil, subscriptionUsecase, 1, 0)
+
+	job := &entities.Job{}
+	for _, emailID := range []int64{1, 2, 3} {
+		job.Items = append(job.Items, entities.JobItem{EmailID: emailID})
+	}
+	job.Items[1].Status = entities.JobItemStatusSkipped
+	if err := jobRepo.Create(context.Background(), job); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := usecase.runJob(context.Background(), job.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	finished, _ := jobRepo.GetByID(context.Background(), job.ID)
+	if finished.Status != entities.JobStatusCompleted {
+		t.Errorf("Expected job to be completed, got %s", finished.Status)
+	}
+	if finished.Processed != 3 || finished.Succeeded != 2 || finished.Failed != 0 {
+		t.Errorf("Unexpected progress: %+v", finished)
+	}
+	if service.calls != 2 {
+		t.Errorf("Expected the skipped item not to be unsubscribed, got %d calls", service.calls)
+	}
+	if finished.Items[0].Strategy != repositories.UnsubscribeStrategyOneClick {
+		t.Errorf("Expected the strategy to be recorded, got %+v", finished.Items[0])
+	}
+}
+
+// blockingUnsubscribeService holds each unsubscribe until released
+type blockingUnsubscribeService struct {
+	repositories.UnsubscribeService
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingUnsubscribeService) UnsubscribeExcluding(ctx context.Context, email *entities.Email, excludedStrategies []string) (*repositories.UnsubscribeResult, error) {
+	s.started <- struct{}{}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.release:
+		return &repositories.UnsubscribeResult{Success: true, Strategy: repositories.UnsubscribeStrategyOneClick}, nil
+	}
+}
+
+func TestJobUsecase_ShutdownDrainsRunningItems(t *testing.T) {
+	emailRepo := &fakeEmailRepository{emails: map[int64]*entities.Email{
+		1: {ID: 1, AccountID: 1, Sender: "news@shop.com"},
+		2: {ID: 2, AccountID: 1, Sender: "digest@blog.org"},
+	}}
+	service := &blockingUnsubscribeService{started: make(chan struct{}, 2), release: make(chan struct{})}
+	subscriptionUsecase := NewSubscriptionUsecase(&fakeSubscriptionRepository{}, nil, emailRepo, nil, nil, service, nil,
+		time.Hour, entities.UnsubscribeViolationActionNone, false)
+	jobRepo := &fakeJobRepository{}
+	usecase := NewJobUsecase(jobRepo, emailRepo, nil, nil, subscriptionUsecase, 1, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	usecase.Start(ctx)
+	job, err := usecase.SubmitBulkUnsubscribe(context.Background(), []int64{1, 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	<-service.started
+
+	// The running item finishes after the signal; the other one isn't started
+	cancel()
+	go close(service.release)
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	if err := usecase.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Expected running items to finish, got %v", err)
+	}
+
+	stopped, _ := jobRepo.GetByID(context.Background(), job.ID)
+	if stopped.Items[0].Status != entities.JobItemStatusSucceeded || stopped.Items[1].Status != entities.JobItemStatusPending {
+		t.Errorf("Expected the running item to succeed and the other to stay pending, got %+v", stopped.Items)
+	}
+	if stopped.Status != entities.JobStatusRunning {
+		t.Errorf("Expected the job to be resumed on the next start, got %s", stopped.Status)
+	}
+}
+
+func TestJobUsecase_SubmitSkipsDuplicateSenders(t *testing.T) {
+	emailRepo := &fakeEmailRepository{emails: map[int64]*entities.Email{
+		1: {ID: 1, Sender: "Shop <news@shop.com>"},
+		2: {ID: 2, Sender: "NEWS@shop.com"},
+	}}
+	usecase := NewJobUsecase(&fakeJobRepository{}, emailRepo, nil, nil, nil, 1, 0)
+
+	// Mark the base context done so the job isn't started
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	usecase.baseCtx = ctx
+
+	job, err := usecase.SubmitBulkUnsubscribe(context.Background(), []int64{1, 2, 4})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(job.Items) != 2 {
+		t.Fatalf("Expected missing emails to be left out, got %d items", len(job.Items))
+	}
+	if job.Items[0].Status != entities.JobItemStatusPending || job.Items[1].Status != entities.JobItemStatusSkipped {
+		t.Errorf("Expected the second email from the same sender to be skipped, got %+v", job.Items)
+	}
+}
+
+func TestInterleave(t *testing.T) {
+	pending := map[string][]pendingItem{
+		"a.com": {{host: "a.com", item: entities.JobItem{ID: 1}}, {host: "a.com", item: entities.JobItem{ID: 2}}},
+		"b.com": {{host: "b.com", item: entities.JobItem{ID: 3}}},
+	}
+
+	ordered := interleave([]string{"a.com", "b.com"}, pending)
+
+	var ids []int64
+	for _, next := range ordered {
+		ids = append(ids, next.item.ID)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 3 || ids[2] != 2 {
+		t.Errorf("Expected hosts to alternate, got %v", ids)
+	}
+}
+
+func TestHostLimiter_SpacesRequestsToSameHost(t *testing.T) {
+	limiter := newHostLimiter(50 * time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx, "shop.com"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected requests to the same host to be spaced out, took %v", elapsed)
+	}
+
+	// Other hosts aren't held up
+	start = time.Now()
+	if err := limiter.Wait(ctx, "blog.org"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("Expected no wait for a different host, took %v", elapsed)
+	}
+}
+
+func TestHostLimiter_ForgetsIdleHosts(t *testing.T) {
+	limiter := newHostLimiter(10 * time.Millisecond)
+	ctx := context.Background()
+
+	if err := limiter.Wait(ctx, "shop.com"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := limiter.Wait(ctx, "blog.org"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, ok := limiter.next["shop.com"]; ok || len(limiter.next) != 1 {
+		t.Errorf("Expected only the host with an upcoming slot to be kept, got %v", limiter.next)
+	}
+}
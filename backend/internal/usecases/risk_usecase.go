@@ -0,0 +1,220 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+const (
+	riskQueueSize    = 1000
+	riskBacklogBatch = 50
+	// Emails whose scoring failed this many times are left unscored
+	maxRiskAttempts = 3
+)
+
+// riskJob is a newly synced email waiting to be scored, carrying the headers it was synced with
+type riskJob struct {
+	accountID int64
+	email     entities.Email
+}
+
+// RiskUsecase scores emails for phishing and scam risk and tags risky ones
+// into the "Suspicious" category. Newly synced emails are queued as they arrive,
+// and a backlog processor scores the ones that didn't fit in the queue.
+type RiskUsecase struct {
+	emailRepo       repositories.EmailRepository
+	accountRepo     repositories.AccountRepository
+	categoryRepo    repositories.CategoryRepository
+	gmailService    repositories.GmailService
+	riskService     repositories.RiskService
+	budget          *AIBudget
+	threshold       float64
+	backlogInterval time.Duration
+	queue           chan riskJob
+}
+
+func NewRiskUsecase(
+	emailRepo repositories.EmailRepository,
+	accountRepo repositories.AccountRepository,
+	categoryRepo repositories.CategoryRepository,
+	gmailService repositories.GmailService,
+	riskService repositories.RiskService,
+	budget *AIBudget,
+	threshold float64,
+	backlogInterval time.Duration,
+) *RiskUsecase {
+	return &RiskUsecase{
+		emailRepo:       emailRepo,
+		accountRepo:     accountRepo,
+		categoryRepo:    categoryRepo,
+		gmailService:    gmailService,
+		riskService:     riskService,
+		budget:          budget,
+		threshold:       threshold,
+		backlogInterval: backlogInterval,
+		queue:           make(chan riskJob, riskQueueSize),
+	}
+}
+
+// Start launches the risk scoring worker and the backlog processor. Both stop when ctx is cancelled.
+func (u *RiskUsecase) Start(ctx context.Context) {
+	go u.runWorker(ctx)
+	go u.runBacklog(ctx)
+}
+
+// EnqueueEmails queues newly synced emails to be scored in the background. The
+// emails carry their headers, so no Gmail request is needed to score them.
+// Emails that don't fit in the queue are picked up later by the backlog processor.
+func (u *RiskUsecase) EnqueueEmails(accountID int64, emails []entities.Email) {
+	for _, email := range emails {
+		if email.ID == 0 {
+			continue
+		}
+
+		select {
+		case u.queue <- riskJob{accountID: accountID, email: email}:
+		default:
+			fmt.Printf("Warning: risk queue is full, leaving remaining emails to the backlog processor\n")
+			return
+		}
+	}
+}
+
+// ProcessBacklog scores emails that were left unscored, fetching their headers from
+// Gmail. It returns the number of emails scored.
+func (u *RiskUsecase) ProcessBacklog(ctx context.Context) (int, error) {
+	emails, err := u.emailRepo.GetPendingRiskAssessments(ctx, riskBacklogBatch, maxRiskAttempts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get emails pending risk assessment: %w", err)
+	}
+
+	processed := 0
+	for i := range emails {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if _, assessErr := u.assessStored(ctx, &emails[i], u.budget); assessErr != nil {
+			fmt.Printf("Warning: failed to assess risk of email %d: %v\n", emails[i].ID, assessErr)
+			if err := u.emailRepo.RecordRiskFailure(ctx, emails[i].ID); err != nil {
+				fmt.Printf("Warning: failed to record risk assessment failure of email %d: %v\n", emails[i].ID, err)
+			}
+			continue
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+// AssessEmail re-scores a stored email, fetching its headers from Gmail
+func (u *RiskUsecase) AssessEmail(ctx context.Context, emailID int64) (*entities.RiskAssessment, error) {
+	email, err := u.emailRepo.GetByID(ctx, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	// Scores the user asks for don't count against the AI budget
+	return u.assessStored(ctx, email, nil)
+}
+
+func (u *RiskUsecase) runWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-u.queue:
+			if _, err := u.assess(ctx, job.accountID, &job.email, u.budget); err != nil {
+				fmt.Printf("Warning: failed to assess risk of email %d: %v\n", job.email.ID, err)
+			}
+		}
+	}
+}
+
+func (u *RiskUsecase) runBacklog(ctx context.Context) {
+	ticker := time.NewTicker(u.backlogInterval)
+	defer ticker.Stop()
+
+	for {
+		processed, err := u.ProcessBacklog(ctx)
+		if err != nil {
+			fmt.Printf("Warning: risk backlog processing failed: %v\n", err)
+		} else if processed > 0 {
+			fmt.Printf("Risk backlog processed %d emails\n", processed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// assessStored scores an email that is no longer carrying the headers it was synced with
+func (u *RiskUsecase) assessStored(ctx context.Context, email *entities.Email, budget repositories.AIBudget) (*entities.RiskAssessment, error) {
+	account, err := u.accountRepo.GetByID(ctx, email.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("account not found: %w", err)
+	}
+
+	gmailMsg, err := u.gmailService.GetMessage(ctx, account.ToOAuth2Token(), email.GmailMessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message from Gmail: %w", err)
+	}
+	email.Headers = gmailMsg.Headers
+
+	return u.assess(ctx, account.ID, email, budget)
+}
+
+func (u *RiskUsecase) assess(ctx context.Context, accountID int64, email *entities.Email, budget repositories.AIBudget) (*entities.RiskAssessment, error) {
+	assessment, err := u.riskService.AssessEmail(ctx, email, budget)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assess email risk: %w", err)
+	}
+
+	err = u.emailRepo.UpdateRiskAssessment(ctx, email.ID, assessment.Score, assessment.Reasons)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save risk assessment: %w", err)
+	}
+	email.RiskScore = &assessment.Score
+	email.RiskReasons = assessment.Reasons
+
+	if assessment.Score < u.threshold {
+		return assessment, u.clearSuspicious(ctx, accountID, email.ID)
+	}
+
+	category, err := u.categoryRepo.GetOrCreate(ctx, accountID, entities.SuspiciousCategoryName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suspicious category: %w", err)
+	}
+
+	err = u.emailRepo.AddEmailToCategories(ctx, email.ID, []int64{category.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to tag email as suspicious: %w", err)
+	}
+
+	return assessment, nil
+}
+
+// clearSuspicious takes an email out of the "Suspicious" category once its score is below the threshold
+func (u *RiskUsecase) clearSuspicious(ctx context.Context, accountID, emailID int64) error {
+	category, err := u.categoryRepo.GetByName(ctx, accountID, entities.SuspiciousCategoryName)
+	if err != nil {
+		return fmt.Errorf("failed to get suspicious category: %w", err)
+	}
+	if category == nil {
+		return nil // No email was ever tagged
+	}
+
+	err = u.emailRepo.RemoveEmailFromCategories(ctx, emailID, []int64{category.ID})
+	if err != nil {
+		return fmt.Errorf("failed to untag email as suspicious: %w", err)
+	}
+
+	return nil
+}
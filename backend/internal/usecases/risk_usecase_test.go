@@ -0,0 +1,172 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	"golang.org/x/oauth2"
+)
+
+type fakeRiskEmailRepository struct {
+	repositories.EmailRepository
+	scores     map[int64]float64
+	categories map[int64][]int64
+	pending    []entities.Email
+	failures   map[int64]int
+}
+
+func (r *fakeRiskEmailRepository) GetPendingRiskAssessments(ctx context.Context, limit, maxAttempts int) ([]entities.Email, error) {
+	var pending []entities.Email
+	for _, email := range r.pending {
+		if _, scored := r.scores[email.ID]; !scored && r.failures[email.ID] < maxAttempts {
+			pending = append(pending, email)
+		}
+	}
+	return pending, nil
+}
+
+func (r *fakeRiskEmailRepository) RecordRiskFailure(ctx context.Context, emailID int64) error {
+	r.failures[emailID]++
+	return nil
+}
+
+func (r *fakeRiskEmailRepository) UpdateRiskAssessment(ctx context.Context, emailID int64, score float64, reasons []string) error {
+	r.scores[emailID] = score
+	return nil
+}
+
+func (r *fakeRiskEmailRepository) AddEmailToCategories(ctx context.Context, emailID int64, categoryIDs []int64) error {
+	r.categories[emailID] = append(r.categories[emailID], categoryIDs...)
+	return nil
+}
+
+func (r *fakeRiskEmailRepository) RemoveEmailFromCategories(ctx context.Context, emailID int64, categoryIDs []int64) error {
+	r.categories[emailID] = slices.DeleteFunc(r.categories[emailID], func(id int64) bool {
+		return slices.Contains(categoryIDs, id)
+	})
+	return nil
+}
+
+type fakeRiskCategoryRepository struct {
+	repositories.CategoryRepository
+	suspicious *entities.Category
+}
+
+func (r *fakeRiskCategoryRepository) GetByName(ctx context.Context, accountID int64, name string) (*entities.Category, error) {
+	return r.suspicious, nil
+}
+
+func (r *fakeRiskCategoryRepository) GetOrCreate(ctx context.Context, accountID int64, name string) (*entities.Category, error) {
+	if r.suspicious == nil {
+		r.suspicious = &entities.Category{ID: 9, AccountID: accountID, Name: name}
+	}
+	return r.suspicious, nil
+}
+
+// fakeRiskService scores emails by the presence of a header
+type fakeRiskService struct {
+	score float64
+}
+
+func (s *fakeRiskService) AssessEmail(ctx context.Context, email *entities.Email, budget repositories.AIBudget) (*entities.RiskAssessment, error) {
+	if email.Headers["X-Spoofed"] == "" {
+		return &entities.RiskAssessment{Score: 0}, nil
+	}
+	return &entities.RiskAssessment{Score: s.score, Reasons: []string{"spoofed"}}, nil
+}
+
+// fakeRiskGmailService serves the headers of stored emails
+type fakeRiskGmailService struct {
+	repositories.GmailService
+	headers map[string]map[string]string
+}
+
+func (s *fakeRiskGmailService) GetMessage(ctx context.Context, token *oauth2.Token, messageID string) (*entities.GmailMessage, error) {
+	headers, ok := s.headers[messageID]
+	if !ok {
+		return nil, fmt.Errorf("message %s not found", messageID)
+	}
+	return &entities.GmailMessage{ID: messageID, Headers: headers}, nil
+}
+
+func TestRiskUsecase_ScoresQueuedEmails(t *testing.T) {
+	emailRepo := &fakeRiskEmailRepository{scores: map[int64]float64{}, categories: map[int64][]int64{}, failures: map[int64]int{}}
+	categoryRepo := &fakeRiskCategoryRepository{}
+	usecase := NewRiskUsecase(emailRepo, nil, categoryRepo, nil, &fakeRiskService{score: 0.9}, nil, 0.7, time.Minute)
+
+	usecase.EnqueueEmails(1, []entities.Email{
+		{ID: 1, Headers: map[string]string{"X-Spoofed": "yes"}},
+		{GmailMessageID: "already-stored"},
+		{ID: 2},
+	})
+	if len(usecase.queue) != 2 {
+		t.Fatalf("Expected the two stored emails to be queued, got %d", len(usecase.queue))
+	}
+
+	// Run the queued jobs as the worker would
+	for len(usecase.queue) > 0 {
+		job := <-usecase.queue
+		if _, err := usecase.assess(context.Background(), job.accountID, &job.email, nil); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if !slices.Equal(emailRepo.categories[1], []int64{9}) || len(emailRepo.categories[2]) != 0 {
+		t.Errorf("Expected only the spoofed email to be tagged, got %v", emailRepo.categories)
+	}
+}
+
+func TestRiskUsecase_UntagsEmailsBelowThreshold(t *testing.T) {
+	emailRepo := &fakeRiskEmailRepository{scores: map[int64]float64{}, categories: map[int64][]int64{1: {4, 9}}}
+	categoryRepo := &fakeRiskCategoryRepository{suspicious: &entities.Category{ID: 9, Name: entities.SuspiciousCategoryName}}
+	usecase := NewRiskUsecase(emailRepo, nil, categoryRepo, nil, &fakeRiskService{score: 0.9}, nil, 0.7, time.Minute)
+
+	email := &entities.Email{ID: 1, Headers: map[string]string{}}
+	assessment, err := usecase.assess(context.Background(), 1, email, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if assessment.Score != 0 || !slices.Equal(emailRepo.categories[1], []int64{4}) {
+		t.Errorf("Expected the email to leave the Suspicious category only, got %v", emailRepo.categories[1])
+	}
+}
+
+func TestRiskUsecase_ProcessBacklogScoresOverflowedEmails(t *testing.T) {
+	emailRepo := &fakeRiskEmailRepository{scores: map[int64]float64{}, categories: map[int64][]int64{}, failures: map[int64]int{}}
+	gmailService := &fakeRiskGmailService{headers: map[string]map[string]string{"m1": {"X-Spoofed": "yes"}}}
+	usecase := NewRiskUsecase(emailRepo, &fakeAccountRepository{}, &fakeRiskCategoryRepository{}, gmailService, &fakeRiskService{score: 0.9}, nil, 0.7, time.Minute)
+
+	// Fill the queue so the next emails overflow
+	for i := range riskQueueSize {
+		usecase.queue <- riskJob{email: entities.Email{ID: int64(1000 + i)}}
+	}
+	emailRepo.pending = []entities.Email{
+		{ID: 1, AccountID: 1, GmailMessageID: "m1"},
+		{ID: 2, AccountID: 1, GmailMessageID: "deleted"},
+	}
+	usecase.EnqueueEmails(1, emailRepo.pending)
+
+	processed, err := usecase.ProcessBacklog(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if processed != 1 || emailRepo.scores[1] != 0.9 || !slices.Equal(emailRepo.categories[1], []int64{9}) {
+		t.Errorf("Expected the overflowed email to be scored and tagged, got %d, %v and %v", processed, emailRepo.scores, emailRepo.categories)
+	}
+
+	// Emails that can't be fetched are given up after a few attempts
+	for range maxRiskAttempts {
+		if _, err := usecase.ProcessBacklog(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if emailRepo.failures[2] != maxRiskAttempts {
+		t.Errorf("Expected %d failed attempts, got %d", maxRiskAttempts, emailRepo.failures[2])
+	}
+}
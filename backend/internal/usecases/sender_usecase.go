@@ -0,0 +1,146 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+var (
+	ErrCategoryNotFound  = errors.New("category not found in the sender's account")
+	ErrNoUnsubscribeLink = errors.New("none of the sender's emails offers a way to unsubscribe")
+)
+
+// SenderActionResult counts the outcome of a bulk action over a sender's emails
+type SenderActionResult struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	// Skipped emails needed no change, e.g. ones already archived
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// SenderUsecase keeps the per-sender statistics up to date during sync and runs
+// actions over all the emails of a sender. Actions that call Gmail per email run
+// as background jobs.
+type SenderUsecase struct {
+	senderRepo   repositories.SenderRepository
+	emailRepo    repositories.EmailRepository
+	accountRepo  repositories.AccountRepository
+	categoryRepo repositories.CategoryRepository
+	jobUsecase   *JobUsecase
+}
+
+func NewSenderUsecase(
+	senderRepo repositories.SenderRepository,
+	emailRepo repositories.EmailRepository,
+	accountRepo repositories.AccountRepository,
+	categoryRepo repositories.CategoryRepository,
+	jobUsecase *JobUsecase,
+) *SenderUsecase {
+	return &SenderUsecase{
+		senderRepo:   senderRepo,
+		emailRepo:    emailRepo,
+		accountRepo:  accountRepo,
+		categoryRepo: categoryRepo,
+		jobUsecase:   jobUsecase,
+	}
+}
+
+// RecordEmails links newly stored emails to their senders and refreshes the statistics
+func (u *SenderUsecase) RecordEmails(ctx context.Context, accountID int64, emails []entities.Email) error {
+	return u.senderRepo.RecordEmails(ctx, accountID, emails)
+}
+
+func (u *SenderUsecase) GetAccountSenders(ctx context.Context, accountID int64, params repositories.PaginationParams) (*repositories.PaginatedSenders, error) {
+	_, err := u.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("account not found: %w", err)
+	}
+
+	return u.senderRepo.GetByAccountIDPaginated(ctx, accountID, params)
+}
+
+func (u *SenderUsecase) GetSender(ctx context.Context, senderID int64) (*entities.Sender, error) {
+	return u.senderRepo.GetByID(ctx, senderID)
+}
+
+func (u *SenderUsecase) GetSenderEmails(ctx context.Context, senderID int64, params repositories.PaginationParams) (*repositories.PaginatedEmails, error) {
+	sender, err := u.senderRepo.GetByID(ctx, senderID)
+	if err != nil {
+		return nil, err
+	}
+
+	emails, err := u.emailRepo.GetBySenderIDPaginated(ctx, sender.ID, params)
+	if err != nil {
+		return nil, err
+	}
+	sanitizeBodies(emails.Emails)
+
+	return emails, nil
+}
+
+// ArchiveAll starts a background job archiving every email of the sender in Gmail
+func (u *SenderUsecase) ArchiveAll(ctx context.Context, senderID int64) (*entities.Job, error) {
+	sender, err := u.senderRepo.GetByID(ctx, senderID)
+	if err != nil {
+		return nil, err
+	}
+
+	emails, err := u.emailRepo.GetBySenderID(ctx, sender.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.jobUsecase.SubmitArchive(ctx, emails)
+}
+
+// CategorizeAll adds every email of the sender to a category of the sender's account
+func (u *SenderUsecase) CategorizeAll(ctx context.Context, senderID, categoryID int64) (*SenderActionResult, error) {
+	sender, err := u.senderRepo.GetByID(ctx, senderID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := u.categoryRepo.GetByAccountID(ctx, sender.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	if !slices.ContainsFunc(categories, func(category entities.Category) bool { return category.ID == categoryID }) {
+		return nil, ErrCategoryNotFound
+	}
+
+	total, added, err := u.emailRepo.AddSenderEmailsToCategory(ctx, sender.ID, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SenderActionResult{Total: total, Succeeded: added, Skipped: total - added}, nil
+}
+
+// Unsubscribe starts a background unsubscribe job for the sender, using its latest
+// email that offers a way to unsubscribe
+func (u *SenderUsecase) Unsubscribe(ctx context.Context, senderID int64) (*entities.Job, error) {
+	sender, err := u.senderRepo.GetByID(ctx, senderID)
+	if err != nil {
+		return nil, err
+	}
+
+	emails, err := u.emailRepo.GetBySenderID(ctx, sender.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emails are newest first
+	for _, email := range emails {
+		if email.UnsubscribeLink != nil || email.ListUnsubscribe != "" {
+			return u.jobUsecase.SubmitBulkUnsubscribe(ctx, []int64{email.ID})
+		}
+	}
+
+	return nil, ErrNoUnsubscribeLink
+}
@@ -0,0 +1,175 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+type fakeSenderRepository struct {
+	repositories.SenderRepository
+	senders map[int64]*entities.Sender
+}
+
+func (r *fakeSenderRepository) GetByID(ctx context.Context, id int64) (*entities.Sender, error) {
+	sender, ok := r.senders[id]
+	if !ok {
+		return nil, apperrors.NewNotFoundError("sender not found")
+	}
+	return sender, nil
+}
+
+type fakeSenderEmailRepository struct {
+	repositories.EmailRepository
+	emails      []entities.Email
+	archived    []int64
+	categorized []int64
+}
+
+func (r *fakeSenderEmailRepository) GetByID(ctx context.Context, id int64) (*entities.Email, error) {
+	for i := range r.emails {
+		if r.emails[i].ID == id {
+			return &r.emails[i], nil
+		}
+	}
+	return nil, fmt.Errorf("email %d not found", id)
+}
+
+func (r *fakeSenderEmailRepository) GetBySenderID(ctx context.Context, senderID int64) ([]entities.Email, error) {
+	return r.emails, nil
+}
+
+func (r *fakeSenderEmailRepository) UpdateArchivedInGmail(ctx context.Context, emailID int64, archived bool) error {
+	r.archived = append(r.archived, emailID)
+	return nil
+}
+
+func (r *fakeSenderEmailRepository) AddSenderEmailsToCategory(ctx context.Context, senderID, categoryID int64) (int, int, error) {
+	for _, email := range r.emails {
+		if !slices.Contains(email.CategoryIDs, categoryID) {
+			r.categorized = append(r.categorized, email.ID)
+		}
+	}
+	return len(r.emails), len(r.categorized), nil
+}
+
+type fakeCategoryRepository struct {
+	repositories.CategoryRepository
+	categories []entities.Category
+}
+
+func (r *fakeCategoryRepository) GetByAccountID(ctx context.Context, accountID int64) ([]entities.Category, error) {
+	return r.categories, nil
+}
+
+type fakeArchivingGmailService struct {
+	repositories.GmailService
+	failing string
+}
+
+func (s *fakeArchivingGmailService) ArchiveMessage(ctx context.Context, token *oauth2.Token, messageID string) error {
+	if messageID == s.failing {
+		return fmt.Errorf("gmail unavailable")
+	}
+	return nil
+}
+
+func newTestSenderUsecase(emailRepo *fakeSenderEmailRepository, jobUsecase *JobUsecase) *SenderUsecase {
+	senderRepo := &fakeSenderRepository{senders: map[int64]*entities.Sender{
+		5: {ID: 5, AccountID: 1, Address: "news@shop.com"},
+	}}
+	categoryRepo := &fakeCategoryRepository{categories: []entities.Category{{ID: 3, AccountID: 1, Name: "Shopping"}}}
+	return NewSenderUsecase(senderRepo, emailRepo, &fakeAccountRepository{}, categoryRepo, jobUsecase)
+}
+
+// waitForJobs waits until the jobs the usecase launched have stopped
+func waitForJobs(u *JobUsecase) {
+	for {
+		u.mu.Lock()
+		running := len(u.running)
+		u.mu.Unlock()
+		if running == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSenderUsecase_ArchiveAll(t *testing.T) {
+	emailRepo := &fakeSenderEmailRepository{emails: []entities.Email{
+		{ID: 1, AccountID: 1, GmailMessageID: "m1"},
+		{ID: 2, AccountID: 1, GmailMessageID: "m2", IsArchivedInGmail: true},
+		{ID: 3, AccountID: 1, GmailMessageID: "m3"},
+	}}
+	jobRepo := &fakeJobRepository{}
+	jobUsecase := NewJobUsecase(jobRepo, emailRepo, &fakeAccountRepository{}, &fakeArchivingGmailService{failing: "m3"}, nil, 1, time.Hour)
+	usecase := newTestSenderUsecase(emailRepo, jobUsecase)
+
+	job, err := usecase.ArchiveAll(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if job.Kind != entities.JobKindArchive {
+		t.Errorf("Expected an archive job, got %q", job.Kind)
+	}
+	waitForJobs(jobUsecase)
+
+	// Archiving isn't spaced out like unsubscribes, so the hour between requests doesn't apply
+	finished, _ := jobRepo.GetByID(context.Background(), job.ID)
+	if finished.Status != entities.JobStatusCompleted || finished.Succeeded != 1 || finished.Failed != 1 {
+		t.Errorf("Expected one archived and one failed email, got %+v", finished)
+	}
+	if finished.Items[1].Status != entities.JobItemStatusSkipped {
+		t.Errorf("Expected the archived email to be skipped, got %+v", finished.Items[1])
+	}
+	if !slices.Equal(emailRepo.archived, []int64{1}) {
+		t.Errorf("Expected only email 1 to be marked archived, got %v", emailRepo.archived)
+	}
+}
+
+func TestSenderUsecase_GetSenderEmailsOfUnknownSender(t *testing.T) {
+	usecase := newTestSenderUsecase(&fakeSenderEmailRepository{}, nil)
+
+	_, err := usecase.GetSenderEmails(context.Background(), 6, repositories.PaginationParams{Page: 1, PageSize: 20})
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestSenderUsecase_CategorizeAll(t *testing.T) {
+	emailRepo := &fakeSenderEmailRepository{emails: []entities.Email{
+		{ID: 1, CategoryIDs: []int64{3}},
+		{ID: 2},
+	}}
+	usecase := newTestSenderUsecase(emailRepo, nil)
+
+	result, err := usecase.CategorizeAll(context.Background(), 5, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Succeeded != 1 || result.Skipped != 1 || !slices.Equal(emailRepo.categorized, []int64{2}) {
+		t.Errorf("Expected only email 2 to be categorized, got %+v and %v", *result, emailRepo.categorized)
+	}
+
+	// Categories of other accounts are refused
+	if _, err := usecase.CategorizeAll(context.Background(), 5, 4); !errors.Is(err, ErrCategoryNotFound) {
+		t.Errorf("Expected ErrCategoryNotFound, got %v", err)
+	}
+}
+
+func TestSenderUsecase_UnsubscribeWithoutLink(t *testing.T) {
+	emailRepo := &fakeSenderEmailRepository{emails: []entities.Email{{ID: 1}}}
+	usecase := newTestSenderUsecase(emailRepo, nil)
+
+	if _, err := usecase.Unsubscribe(context.Background(), 5); !errors.Is(err, ErrNoUnsubscribeLink) {
+		t.Errorf("Expected ErrNoUnsubscribeLink, got %v", err)
+	}
+}
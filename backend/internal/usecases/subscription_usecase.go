@@ -0,0 +1,559 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+)
+
+const (
+	unsubscribeRetryQueueSize = 100
+	// unsubscribePlanTTL is how long a dry-run plan can be approved; pages change
+	unsubscribePlanTTL = time.Hour
+	// Expired plans are kept this long so their outcome can still be looked up
+	unsubscribePlanRetention     = 24 * time.Hour
+	unsubscribePlanPurgeInterval = time.Hour
+)
+
+// SubscriptionUsecase runs unsubscribes, records every attempt and reports the
+// subscription state of each mailing-list sender. It also watches for senders
+// that keep mailing after a successful unsubscribe.
+type SubscriptionUsecase struct {
+	subscriptionRepo   repositories.SubscriptionRepository
+	planRepo           repositories.UnsubscribePlanRepository
+	emailRepo          repositories.EmailRepository
+	accountRepo        repositories.AccountRepository
+	gmailService       repositories.GmailService
+	unsubscribeService repositories.UnsubscribeService
+	artifactStore      repositories.ArtifactStore
+	gracePeriod        time.Duration
+	violationAction    string
+	retryEnabled       bool
+	retryQueue         chan entities.Email
+	senderLocks        *keyedMutex
+}
+
+func NewSubscriptionUsecase(
+	subscriptionRepo repositories.SubscriptionRepository,
+	planRepo repositories.UnsubscribePlanRepository,
+	emailRepo repositories.EmailRepository,
+	accountRepo repositories.AccountRepository,
+	gmailService repositories.GmailService,
+	unsubscribeService repositories.UnsubscribeService,
+	artifactStore repositories.ArtifactStore,
+	gracePeriod time.Duration,
+	violationAction string,
+	retryEnabled bool,
+) *SubscriptionUsecase {
+	return &SubscriptionUsecase{
+		subscriptionRepo:   subscriptionRepo,
+		planRepo:           planRepo,
+		emailRepo:          emailRepo,
+		accountRepo:        accountRepo,
+		gmailService:       gmailService,
+		unsubscribeService: unsubscribeService,
+		artifactStore:      artifactStore,
+		gracePeriod:        gracePeriod,
+		violationAction:    violationAction,
+		retryEnabled:       retryEnabled,
+		retryQueue:         make(chan entities.Email, unsubscribeRetryQueueSize),
+		senderLocks:        newKeyedMutex(),
+	}
+}
+
+// Start launches the worker that retries ineffective unsubscribes and purges expired
+// plans. It stops when ctx is cancelled.
+func (u *SubscriptionUsecase) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(unsubscribePlanPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case email := <-u.retryQueue:
+				u.retryUnsubscribe(ctx, email)
+			case <-ticker.C:
+				if _, err := u.PurgeExpiredPlans(ctx); err != nil {
+					fmt.Printf("Warning: failed to purge expired unsubscribe plans: %v\n", err)
+				}
+			}
+		}
+	}()
+}
+
+// retryUnsubscribe unsubscribes again from the sender of an email that arrived after
+// an unsubscribe. The email may no longer be stored, so its copy from the sync is used.
+func (u *SubscriptionUsecase) retryUnsubscribe(ctx context.Context, email entities.Email) {
+	if _, _, err := u.unsubscribe(ctx, &email); err != nil {
+		fmt.Printf("Warning: failed to retry unsubscribe from %s: %v\n", email.Sender, err)
+	}
+}
+
+func (u *SubscriptionUsecase) UnsubscribeFromEmail(ctx context.Context, emailID int64) (*repositories.UnsubscribeResult, error) {
+	// Get the email
+	email, err := u.emailRepo.GetByID(ctx, emailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	result, _, err := u.unsubscribe(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetSubscriptions lists the mailing-list senders of an account with their
+// subscription state. An empty status returns all of them.
+func (u *SubscriptionUsecase) GetSubscriptions(ctx context.Context, accountID int64, status string) ([]entities.Subscription, error) {
+	switch status {
+	case "", entities.SubscriptionStatusSubscribed, entities.SubscriptionStatusUnsubscribed,
+		entities.SubscriptionStatusFailed, entities.SubscriptionStatusRequiresAuth, entities.SubscriptionStatusIneffective,
+		entities.SubscriptionStatusUnverified:
+	default:
+		return nil, apperrors.NewInvalidInputError(fmt.Sprintf("invalid subscription status: %s", status))
+	}
+
+	_, err := u.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	subscriptions, err := u.subscriptionRepo.GetByAccountID(ctx, accountID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// GetSenderAttempts returns the unsubscribe history of one sender of the account, newest first
+func (u *SubscriptionUsecase) GetSenderAttempts(ctx context.Context, accountID int64, sender string) ([]entities.UnsubscribeAttempt, error) {
+	senderAddress := entities.SenderAddress(sender)
+	subscription, err := u.subscriptionRepo.GetBySender(ctx, accountID, senderAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	if subscription == nil {
+		return nil, apperrors.NewNotFoundError("sender not found")
+	}
+
+	attempts, err := u.subscriptionRepo.GetAttemptsBySender(ctx, accountID, senderAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unsubscribe attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// GetAttemptArtifact opens the index-th piece of evidence captured during an
+// unsubscribe attempt and returns its key along with the contents
+func (u *SubscriptionUsecase) GetAttemptArtifact(ctx context.Context, attemptID int64, index int) (string, io.ReadCloser, error) {
+	attempt, err := u.subscriptionRepo.GetAttemptByID(ctx, attemptID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return u.openArtifact(ctx, attempt.Artifacts, index)
+}
+
+// openArtifact opens keys[index], so only artifacts linked from a record can be read
+func (u *SubscriptionUsecase) openArtifact(ctx context.Context, keys []string, index int) (string, io.ReadCloser, error) {
+	if u.artifactStore == nil {
+		return "", nil, fmt.Errorf("artifact storage is not configured")
+	}
+
+	if index < 0 || index >= len(keys) || keys[index] == "" {
+		return "", nil, apperrors.NewNotFoundError(fmt.Sprintf("artifact %d not found", index))
+	}
+	key := keys[index]
+
+	reader, err := u.artifactStore.Open(ctx, key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return key, reader, nil
+}
+
+// PreviewUnsubscribe works out what unsubscribing from the email's sender would do
+// and stores it as a plan the user can approve. When nothing would be done the plan
+// is nil and the result says why. Previewing a browser unsubscribe opens the sender's
+// page, which may unsubscribe by itself; such a result is recorded as an attempt.
+func (u *SubscriptionUsecase) PreviewUnsubscribe(ctx context.Context, emailID int64) (*repositories.UnsubscribePlan, *repositories.UnsubscribeResult, error) {
+	email, err := u.emailRepo.GetByID(ctx, emailID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	unlock := u.senderLocks.Lock(senderLockKey(email))
+	defer unlock()
+
+	subscription, excludedStrategies, err := u.subscriptionState(ctx, email)
+	if err != nil {
+		return nil, nil, err
+	}
+	if result := alreadyUnsubscribedResult(subscription); result != nil {
+		return nil, result, nil
+	}
+
+	startedAt := time.Now()
+	plan, result, err := u.unsubscribeService.PreviewUnsubscribe(ctx, email, excludedStrategies)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to preview unsubscribe: %w", err)
+	}
+	if plan == nil {
+		// Some pages unsubscribe as soon as they are opened; that still counts
+		if result != nil && result.Success {
+			u.recordAttempt(ctx, email, result, startedAt)
+		}
+		return nil, result, nil
+	}
+
+	plan.AccountID = email.AccountID
+	plan.EmailID = email.ID
+	plan.ExpiresAt = time.Now().Add(unsubscribePlanTTL)
+
+	err = u.planRepo.Create(ctx, plan)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return plan, nil, nil
+}
+
+func (u *SubscriptionUsecase) GetPlan(ctx context.Context, planID int64) (*repositories.UnsubscribePlan, error) {
+	return u.planRepo.GetByID(ctx, planID)
+}
+
+// GetPlanScreenshot opens the highlighted screenshot of a plan's index-th action
+func (u *SubscriptionUsecase) GetPlanScreenshot(ctx context.Context, planID int64, index int) (string, io.ReadCloser, error) {
+	plan, err := u.planRepo.GetByID(ctx, planID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return u.openArtifact(ctx, plan.Screenshots, index)
+}
+
+// ExecutePlan carries out an approved plan, and records it as an unsubscribe attempt.
+// A plan runs until it succeeds once; a failed run can be approved again until the plan expires.
+func (u *SubscriptionUsecase) ExecutePlan(ctx context.Context, planID int64) (*repositories.UnsubscribeResult, error) {
+	plan, err := u.planRepo.GetByID(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.ExecutedAt != nil {
+		return nil, apperrors.NewInvalidInputError(fmt.Sprintf("unsubscribe plan %d was already executed", planID))
+	}
+	if time.Now().After(plan.ExpiresAt) {
+		return nil, apperrors.NewInvalidInputError(fmt.Sprintf("unsubscribe plan %d has expired, preview the unsubscribe again", planID))
+	}
+
+	email, err := u.emailRepo.GetByID(ctx, plan.EmailID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	unlock := u.senderLocks.Lock(senderLockKey(email))
+	defer unlock()
+
+	// Claim the plan first so two approvals can't both run it
+	err = u.planRepo.Claim(ctx, plan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	startedAt := time.Now()
+	result, err := u.unsubscribeService.ExecuteUnsubscribePlan(ctx, email, plan)
+
+	// Release the claim whatever happened, or the plan could never run again
+	if finishErr := u.planRepo.Finish(context.WithoutCancel(ctx), plan.ID, err == nil && result.Success); finishErr != nil {
+		fmt.Printf("Warning: failed to finish unsubscribe plan %d: %v\n", plan.ID, finishErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+
+	u.recordAttempt(ctx, email, result, startedAt)
+
+	return result, nil
+}
+
+// PurgeExpiredPlans deletes plans that expired longer ago than the retention period
+func (u *SubscriptionUsecase) PurgeExpiredPlans(ctx context.Context) (int64, error) {
+	return u.planRepo.DeleteExpiredBefore(ctx, time.Now().Add(-unsubscribePlanRetention))
+}
+
+// CheckNewEmails flags newly synced emails from senders that were unsubscribed more
+// than the grace period before the email arrived. The unsubscribe attempt is marked
+// ineffective, the configured action is applied and, if enabled, another strategy
+// is tried. It returns the emails that are still in the mailbox.
+func (u *SubscriptionUsecase) CheckNewEmails(ctx context.Context, accountID int64, emails []entities.Email) ([]entities.Email, error) {
+	emailsBySender := make(map[string][]entities.Email)
+	for _, email := range emails {
+		if email.ID == 0 {
+			continue
+		}
+		sender := entities.SenderAddress(email.Sender)
+		emailsBySender[sender] = append(emailsBySender[sender], email)
+	}
+
+	removed := make(map[int64]bool)
+	for sender, senderEmails := range emailsBySender {
+		attempt, err := u.subscriptionRepo.GetEffectiveAttempt(ctx, accountID, sender)
+		if err != nil {
+			return emails, err
+		}
+		if attempt == nil {
+			continue
+		}
+
+		graceEnd := attempt.CompletedAt.Add(u.gracePeriod)
+		var violations []entities.Email
+		for _, email := range senderEmails {
+			if email.ReceivedAt.After(graceEnd) {
+				violations = append(violations, email)
+			}
+		}
+		if len(violations) == 0 {
+			continue
+		}
+
+		fmt.Printf("Sender %s kept mailing after unsubscribing (%d new emails)\n", sender, len(violations))
+
+		emailIDs := make([]int64, len(violations))
+		for i, email := range violations {
+			emailIDs[i] = email.ID
+		}
+		if err := u.emailRepo.MarkMailedAfterUnsubscribe(ctx, emailIDs); err != nil {
+			return emails, err
+		}
+		if err := u.subscriptionRepo.MarkAttemptIneffective(ctx, attempt.ID); err != nil {
+			return emails, err
+		}
+
+		for _, email := range violations {
+			if u.applyViolationAction(ctx, &email) {
+				removed[email.ID] = true
+			}
+		}
+
+		if u.retryEnabled {
+			// A trashed email is retried without its ID, so the attempt isn't linked to it
+			retry := violations[len(violations)-1]
+			if removed[retry.ID] {
+				retry.ID = 0
+			}
+
+			select {
+			case u.retryQueue <- retry:
+			default:
+				fmt.Printf("Warning: unsubscribe retry queue is full, skipping retry for %s\n", sender)
+			}
+		}
+	}
+
+	if len(removed) == 0 {
+		return emails, nil
+	}
+
+	var remaining []entities.Email
+	for _, email := range emails {
+		if !removed[email.ID] {
+			remaining = append(remaining, email)
+		}
+	}
+	return remaining, nil
+}
+
+// applyViolationAction archives or trashes an email from a sender that ignored an
+// unsubscribe. It reports whether the email was removed from the database.
+func (u *SubscriptionUsecase) applyViolationAction(ctx context.Context, email *entities.Email) bool {
+	if u.violationAction != entities.UnsubscribeViolationActionArchive && u.violationAction != entities.UnsubscribeViolationActionTrash {
+		return false
+	}
+
+	account, err := u.accountRepo.GetByID(ctx, email.AccountID)
+	if err != nil {
+		fmt.Printf("Warning: failed to get account for email %d: %v\n", email.ID, err)
+		return false
+	}
+	token := account.ToOAuth2Token()
+
+	switch u.violationAction {
+	case entities.UnsubscribeViolationActionArchive:
+		if err := u.gmailService.ArchiveMessage(ctx, token, email.GmailMessageID); err != nil {
+			fmt.Printf("Warning: failed to archive email %d: %v\n", email.ID, err)
+			return false
+		}
+		if err := u.emailRepo.UpdateArchivedInGmail(ctx, email.ID, true); err != nil {
+			fmt.Printf("Warning: failed to update archived state of email %d: %v\n", email.ID, err)
+		}
+		return false
+
+	case entities.UnsubscribeViolationActionTrash:
+		if err := u.gmailService.TrashMessage(ctx, token, email.GmailMessageID); err != nil {
+			fmt.Printf("Warning: failed to trash email %d: %v\n", email.ID, err)
+			return false
+		}
+		if err := u.emailRepo.Delete(ctx, email.ID); err != nil {
+			fmt.Printf("Warning: failed to delete trashed email %d: %v\n", email.ID, err)
+			return false
+		}
+		return true
+	}
+
+	return false
+}
+
+// unsubscribe runs the unsubscribe flow for an email unless its sender is already
+// unsubscribed, and records the attempt. Strategies that proved ineffective for the
+// sender are skipped. It reports whether an attempt was made.
+//
+// Unsubscribes from the same sender run one at a time, so a second one sees the
+// outcome of the first instead of unsubscribing again.
+func (u *SubscriptionUsecase) unsubscribe(ctx context.Context, email *entities.Email) (*repositories.UnsubscribeResult, bool, error) {
+	unlock := u.senderLocks.Lock(senderLockKey(email))
+	defer unlock()
+
+	subscription, excludedStrategies, err := u.subscriptionState(ctx, email)
+	if err != nil {
+		return nil, false, err
+	}
+	if result := alreadyUnsubscribedResult(subscription); result != nil {
+		return result, false, nil
+	}
+
+	startedAt := time.Now()
+	result, err := u.unsubscribeService.UnsubscribeExcluding(ctx, email, excludedStrategies)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+
+	u.recordAttempt(ctx, email, result, startedAt)
+
+	return result, true, nil
+}
+
+// subscriptionState returns the subscription of the email's sender, nil if unknown,
+// and the strategies that proved ineffective for it
+func (u *SubscriptionUsecase) subscriptionState(ctx context.Context, email *entities.Email) (*entities.Subscription, []string, error) {
+	senderAddress := entities.SenderAddress(email.Sender)
+
+	subscription, err := u.subscriptionRepo.GetBySender(ctx, email.AccountID, senderAddress)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	var excludedStrategies []string
+	if subscription != nil && subscription.Status == entities.SubscriptionStatusIneffective {
+		attempts, err := u.subscriptionRepo.GetAttemptsBySender(ctx, email.AccountID, senderAddress)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get unsubscribe attempts: %w", err)
+		}
+		for _, attempt := range attempts {
+			if attempt.IneffectiveAt != nil && attempt.Strategy != "" {
+				excludedStrategies = append(excludedStrategies, attempt.Strategy)
+			}
+		}
+	}
+
+	return subscription, excludedStrategies, nil
+}
+
+// alreadyUnsubscribedResult returns a successful result if the sender is already
+// unsubscribed, or nil if an unsubscribe should go ahead
+func alreadyUnsubscribedResult(subscription *entities.Subscription) *repositories.UnsubscribeResult {
+	if subscription == nil || subscription.Status != entities.SubscriptionStatusUnsubscribed {
+		return nil
+	}
+
+	result := &repositories.UnsubscribeResult{
+		Success: true,
+		Message: fmt.Sprintf("Already unsubscribed from %s", subscription.SenderAddress),
+	}
+	if subscription.LastStrategy != nil {
+		result.Strategy = *subscription.LastStrategy
+	}
+	return result
+}
+
+func (u *SubscriptionUsecase) recordAttempt(ctx context.Context, email *entities.Email, result *repositories.UnsubscribeResult, startedAt time.Time) {
+	senderAddress := entities.SenderAddress(email.Sender)
+
+	attempt := &entities.UnsubscribeAttempt{
+		AccountID:     email.AccountID,
+		EmailID:       email.ID,
+		SenderAddress: senderAddress,
+		SenderDomain:  entities.SenderDomain(senderAddress),
+		Strategy:      result.Strategy,
+		Success:       result.Success,
+		Message:       result.Message,
+		ErrorType:     result.ErrorType,
+		RequiresAuth:  result.RequiresAuth,
+		SentMessageID: result.SentMessageID,
+		Artifacts:     result.Artifacts,
+		StartedAt:     startedAt,
+		CompletedAt:   time.Now(),
+	}
+
+	// The unsubscribe already happened, so a bookkeeping failure must not hide its result
+	if err := u.subscriptionRepo.CreateAttempt(ctx, attempt); err != nil {
+		fmt.Printf("Warning: failed to record unsubscribe attempt for email %d: %v\n", email.ID, err)
+	}
+}
+
+func senderLockKey(email *entities.Email) string {
+	return fmt.Sprintf("%d/%s", email.AccountID, entities.SenderAddress(email.Sender))
+}
+
+// keyedMutex is a set of mutexes created on demand for each key
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	mu      sync.Mutex
+	waiters int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedLock)}
+}
+
+// Lock blocks until the key is free and returns the function that releases it
+func (m *keyedMutex) Lock(key string) func() {
+	m.mu.Lock()
+	lock, exists := m.locks[key]
+	if !exists {
+		lock = &keyedLock{}
+		m.locks[key] = lock
+	}
+	lock.waiters++
+	m.mu.Unlock()
+
+	lock.mu.Lock()
+
+	return func() {
+		lock.mu.Unlock()
+
+		m.mu.Lock()
+		lock.waiters--
+		if lock.waiters == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
+}
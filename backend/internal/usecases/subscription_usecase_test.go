@@ -0,0 +1,542 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+	apperrors "github.com/email-sorting-app/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+type fakeSubscriptionRepository struct {
+	repositories.SubscriptionRepository
+	subscriptions map[string]*entities.Subscription
+	history       []entities.UnsubscribeAttempt
+	effective     *entities.UnsubscribeAttempt
+	ineffective   []int64
+	attempts      []*entities.UnsubscribeAttempt
+}
+
+func (r *fakeSubscriptionRepository) GetAttemptByID(ctx context.Context, id int64) (*entities.UnsubscribeAttempt, error) {
+	for i := range r.history {
+		if r.history[i].ID == id {
+			return &r.history[i], nil
+		}
+	}
+	return nil, apperrors.NewNotFoundError(fmt.Sprintf("attempt %d not found", id))
+}
+
+func (r *fakeSubscriptionRepository) GetAttemptsBySender(ctx context.Context, accountID int64, senderAddress string) ([]entities.UnsubscribeAttempt, error) {
+	return r.history, nil
+}
+
+func (r *fakeSubscriptionRepository) GetEffectiveAttempt(ctx context.Context, accountID int64, senderAddress string) (*entities.UnsubscribeAttempt, error) {
+	if r.effective != nil && r.effective.SenderAddress == senderAddress {
+		return r.effective, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeSubscriptionRepository) MarkAttemptIneffective(ctx context.Context, attemptID int64) error {
+	r.ineffective = append(r.ineffective, attemptID)
+	return nil
+}
+
+func (r *fakeSubscriptionRepository) GetBySender(ctx context.Context, accountID int64, senderAddress string) (*entities.Subscription, error) {
+	return r.subscriptions[senderAddress], nil
+}
+
+func (r *fakeSubscriptionRepository) GetByAccountID(ctx context.Context, accountID int64, status string) ([]entities.Subscription, error) {
+	subscriptions := []entities.Subscription{}
+	for _, subscription := range r.subscriptions {
+		if status == "" || subscription.Status == status {
+			subscriptions = append(subscriptions, *subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *fakeSubscriptionRepository) CreateAttempt(ctx context.Context, attempt *entities.UnsubscribeAttempt) error {
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+type fakeUnsubscribeService struct {
+	repositories.UnsubscribeService
+	calls    int
+	excluded []string
+	// previewResult is returned by PreviewUnsubscribe instead of a plan when set
+	previewResult *repositories.UnsubscribeResult
+	failPlans     bool
+}
+
+func (s *fakeUnsubscribeService) UnsubscribeExcluding(ctx context.Context, email *entities.Email, excludedStrategies []string) (*repositories.UnsubscribeResult, error) {
+	s.calls++
+	s.excluded = excludedStrategies
+	return &repositories.UnsubscribeResult{Success: true, Message: "done", Strategy: repositories.UnsubscribeStrategyOneClick}, nil
+}
+
+func (s *fakeUnsubscribeService) PreviewUnsubscribe(ctx context.Context, email *entities.Email, excludedStrategies []string) (*repositories.UnsubscribePlan, *repositories.UnsubscribeResult, error) {
+	s.excluded = excludedStrategies
+	if s.previewResult != nil {
+		return nil, s.previewResult, nil
+	}
+	return &repositories.UnsubscribePlan{
+		Strategy: repositories.UnsubscribeStrategyBrowser,
+		PageURL:  "https://shop.com/unsubscribe",
+		Actions:  []repositories.UnsubscribeAction{{Action: "click", Selector: "#unsubscribe"}},
+	}, nil, nil
+}
+
+func (s *fakeUnsubscribeService) ExecuteUnsubscribePlan(ctx context.Context, email *entities.Email, plan *repositories.UnsubscribePlan) (*repositories.UnsubscribeResult, error) {
+	s.calls++
+	if s.failPlans {
+		return &repositories.UnsubscribeResult{Success: false, Message: "button not found", Strategy: plan.Strategy}, nil
+	}
+	return &repositories.UnsubscribeResult{Success: true, Message: "done", Strategy: plan.Strategy}, nil
+}
+
+type fakePlanRepository struct {
+	repositories.UnsubscribePlanRepository
+	plans map[int64]*repositories.UnsubscribePlan
+}
+
+func (r *fakePlanRepository) Create(ctx context.Context, plan *repositories.UnsubscribePlan) error {
+	plan.ID = int64(len(r.plans) + 1)
+	r.plans[plan.ID] = plan
+	return nil
+}
+
+func (r *fakePlanRepository) GetByID(ctx context.Context, id int64) (*repositories.UnsubscribePlan, error) {
+	plan, ok := r.plans[id]
+	if !ok {
+		return nil, fmt.Errorf("plan %d not found", id)
+	}
+	copied := *plan
+	return &copied, nil
+}
+
+func (r *fakePlanRepository) Claim(ctx context.Context, id int64) error {
+	if r.plans[id].StartedAt != nil || r.plans[id].ExecutedAt != nil {
+		return fmt.Errorf("plan %d is running or was already executed", id)
+	}
+	now := time.Now()
+	r.plans[id].StartedAt = &now
+	return nil
+}
+
+func (r *fakePlanRepository) Finish(ctx context.Context, id int64, success bool) error {
+	now := time.Now()
+	if success {
+		r.plans[id].ExecutedAt = &now
+	} else {
+		r.plans[id].StartedAt = nil
+		r.plans[id].FailedAt = &now
+	}
+	return nil
+}
+
+func (r *fakePlanRepository) DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	for id, plan := range r.plans {
+		if plan.ExpiresAt.Before(before) {
+			delete(r.plans, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func TestSubscriptionUsecase_PreviewAndExecutePlan(t *testing.T) {
+	repo := &fakeSubscriptionRepository{}
+	planRepo := &fakePlanRepository{plans: make(map[int64]*repositories.UnsubscribePlan)}
+	emailRepo := &fakeEmailRepository{emails: map[int64]*entities.Email{
+		5: {ID: 5, AccountID: 1, Sender: "news@shop.com"},
+	}}
+	service := &fakeUnsubscribeService{}
+	usecase := NewSubscriptionUsecase(repo, planRepo, emailRepo, nil, nil, service, nil, time.Hour, entities.UnsubscribeViolationActionNone, false)
+
+	plan, result, err := usecase.PreviewUnsubscribe(context.Background(), 5)
+	if err != nil || result != nil {
+		t.Fatalf("Expected a plan, got result=%+v err=%v", result, err)
+	}
+	if plan.ID == 0 || plan.EmailID != 5 || plan.AccountID != 1 || plan.ExpiresAt.Before(time.Now()) {
+		t.Errorf("Expected the plan to be stored for the email, got %+v", plan)
+	}
+	if service.calls != 0 || len(repo.attempts) != 0 {
+		t.Error("Expected a dry run not to unsubscribe")
+	}
+
+	result, err = usecase.ExecutePlan(context.Background(), plan.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.Success || service.calls != 1 || len(repo.attempts) != 1 {
+		t.Errorf("Expected the plan to run and be recorded, got %+v", result)
+	}
+
+	if _, err := usecase.ExecutePlan(context.Background(), plan.ID); err == nil {
+		t.Error("Expected a plan to run only once")
+	}
+}
+
+func TestSubscriptionUsecase_ExecutePlanRejectsExpiredPlan(t *testing.T) {
+	planRepo := &fakePlanRepository{plans: map[int64]*repositories.UnsubscribePlan{
+		1: {ID: 1, EmailID: 5, ExpiresAt: time.Now().Add(-time.Minute)},
+	}}
+	service := &fakeUnsubscribeService{}
+	usecase := NewSubscriptionUsecase(&fakeSubscriptionRepository{}, planRepo, &fakeEmailRepository{}, nil, nil, service, nil, time.Hour, entities.UnsubscribeViolationActionNone, false)
+
+	if _, err := usecase.ExecutePlan(context.Background(), 1); err == nil {
+		t.Error("Expected an expired plan to be rejected")
+	}
+	if service.calls != 0 {
+		t.Error("Expected an expired plan not to run")
+	}
+}
+
+func TestSubscriptionUsecase_ExecutePlanCanBeRetriedAfterFailure(t *testing.T) {
+	repo := &fakeSubscriptionRepository{}
+	planRepo := &fakePlanRepository{plans: map[int64]*repositories.UnsubscribePlan{
+		1: {ID: 1, EmailID: 5, Strategy: repositories.UnsubscribeStrategyBrowser, ExpiresAt: time.Now().Add(time.Minute)},
+	}}
+	emailRepo := &fakeEmailRepository{emails: map[int64]*entities.Email{
+		5: {ID: 5, AccountID: 1, Sender: "news@shop.com"},
+	}}
+	service := &fakeUnsubscribeService{failPlans: true}
+	usecase := NewSubscriptionUsecase(repo, planRepo, emailRepo, nil, nil, service, nil, time.Hour, entities.UnsubscribeViolationActionNone, false)
+
+	result, err := usecase.ExecutePlan(context.Background(), 1)
+	if err != nil || result.Success {
+		t.Fatalf("Expected a failed run, got %+v, %v", result, err)
+	}
+	if planRepo.plans[1].ExecutedAt != nil || planRepo.plans[1].FailedAt == nil {
+		t.Errorf("Expected the plan to be marked failed, got %+v", planRepo.plans[1])
+	}
+
+	service.failPlans = false
+	result, err = usecase.ExecutePlan(context.Background(), 1)
+	if err != nil || !result.Success {
+		t.Fatalf("Expected the approved plan to run again, got %+v, %v", result, err)
+	}
+	if planRepo.plans[1].ExecutedAt == nil || service.calls != 2 || len(repo.attempts) != 2 {
+		t.Errorf("Expected both runs to be recorded and the plan executed, got %+v", planRepo.plans[1])
+	}
+}
+
+func TestSubscriptionUsecase_PreviewThatUnsubscribesIsRecorded(t *testing.T) {
+	repo := &fakeSubscriptionRepository{}
+	planRepo := &fakePlanRepository{plans: make(map[int64]*repositories.UnsubscribePlan)}
+	emailRepo := &fakeEmailRepository{emails: map[int64]*entities.Email{
+		5: {ID: 5, AccountID: 1, Sender: "news@shop.com"},
+	}}
+	service := &fakeUnsubscribeService{previewResult: &repositories.UnsubscribeResult{
+		Success: true, Message: "already unsubscribed", Strategy: repositories.UnsubscribeStrategyBrowser,
+	}}
+	usecase := NewSubscriptionUsecase(repo, planRepo, emailRepo, nil, nil, service, nil, time.Hour, entities.UnsubscribeViolationActionNone, false)
+
+	plan, result, err := usecase.PreviewUnsubscribe(context.Background(), 5)
+	if err != nil || plan != nil || !result.Success {
+		t.Fatalf("Expected the preview to report the unsubscribe, got %+v, %+v, %v", plan, result, err)
+	}
+	if len(repo.attempts) != 1 || len(planRepo.plans) != 0 {
+		t.Errorf("Expected the unsubscribe to be recorded without a plan, got %d attempts and %d plans", len(repo.attempts), len(planRepo.plans))
+	}
+}
+
+func TestSubscriptionUsecase_PurgeExpiredPlans(t *testing.T) {
+	planRepo := &fakePlanRepository{plans: map[int64]*repositories.UnsubscribePlan{
+		1: {ID: 1, ExpiresAt: time.Now().Add(-2 * unsubscribePlanRetention)},
+		2: {ID: 2, ExpiresAt: time.Now().Add(-time.Minute)},
+		3: {ID: 3, ExpiresAt: time.Now().Add(time.Minute)},
+	}}
+	usecase := NewSubscriptionUsecase(&fakeSubscriptionRepository{}, planRepo, nil, nil, nil, nil, nil, time.Hour, entities.UnsubscribeViolationActionNone, false)
+
+	deleted, err := usecase.PurgeExpiredPlans(context.Background())
+	if err != nil || deleted != 1 {
+		t.Fatalf("Expected one plan to be purged, got %d, %v", deleted, err)
+	}
+	if _, exists := planRepo.plans[1]; exists || len(planRepo.plans) != 2 {
+		t.Errorf("Expected only the plan past the retention period to be purged, got %v", planRepo.plans)
+	}
+}
+
+func TestSubscriptionUsecase_RecordsAttempt(t *testing.T) {
+	repo := &fakeSubscriptionRepository{}
+	service := &fakeUnsubscribeService{}
+	usecase := NewSubscriptionUsecase(repo, nil, nil, nil, nil, service, nil, time.Hour, entities.UnsubscribeViolationActionNone, false)
+
+	email := &entities.Email{ID: 3, AccountID: 1, Sender: "Shop <News@Shop.com>"}
+	result, attempted, err := usecase.unsubscribe(context.Background(), email)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !attempted || !result.Success || service.calls != 1 {
+		t.Errorf("Expected one successful attempt, got attempted=%v result=%+v calls=%d", attempted, result, service.calls)
+	}
+
+	if len(repo.attempts) != 1 {
+		t.Fatalf("Expected one recorded attempt, got %d", len(repo.attempts))
+	}
+
+	attempt := repo.attempts[0]
+	if attempt.SenderAddress != "news@shop.com" || attempt.SenderDomain != "shop.com" {
+		t.Errorf("Expected sender news@shop.com at shop.com, got %s at %s", attempt.SenderAddress, attempt.SenderDomain)
+	}
+	if attempt.Strategy != repositories.UnsubscribeStrategyOneClick || attempt.EmailID != 3 {
+		t.Errorf("Unexpected attempt: %+v", attempt)
+	}
+}
+
+func TestSubscriptionUsecase_SkipsUnsubscribedSender(t *testing.T) {
+	strategy := repositories.UnsubscribeStrategyMailto
+	repo := &fakeSubscriptionRepository{
+		subscriptions: map[string]*entities.Subscription{
+			"news@shop.com": {SenderAddress: "news@shop.com", Status: entities.SubscriptionStatusUnsubscribed, LastStrategy: &strategy},
+		},
+	}
+	service := &fakeUnsubscribeService{}
+	usecase := NewSubscriptionUsecase(repo, nil, nil, nil, nil, service, nil, time.Hour, entities.UnsubscribeViolationActionNone, false)
+
+	result, attempted, err := usecase.unsubscribe(context.Background(), &entities.Email{AccountID: 1, Sender: "news@shop.com"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if attempted || service.calls != 0 || len(repo.attempts) != 0 {
+		t.Errorf("Expected already unsubscribed sender to be skipped")
+	}
+	if !result.Success || result.Strategy != strategy {
+		t.Errorf("Expected success via the earlier strategy, got %+v", result)
+	}
+}
+
+func TestSubscriptionUsecase_RetrySkipsIneffectiveStrategies(t *testing.T) {
+	ineffectiveAt := time.Now()
+	repo := &fakeSubscriptionRepository{
+		subscriptions: map[string]*entities.Subscription{
+			"news@shop.com": {SenderAddress: "news@shop.com", Status: entities.SubscriptionStatusIneffective},
+		},
+		history: []entities.UnsubscribeAttempt{
+			{ID: 2, Strategy: repositories.UnsubscribeStrategyBrowser, Success: false},
+			{ID: 1, Strategy: repositories.UnsubscribeStrategyOneClick, Success: true, IneffectiveAt: &ineffectiveAt},
+		},
+	}
+	service := &fakeUnsubscribeService{}
+	usecase := NewSubscriptionUsecase(repo, nil, nil, nil, nil, service, nil, time.Hour, entities.UnsubscribeViolationActionNone, false)
+
+	_, attempted, err := usecase.unsubscribe(context.Background(), &entities.Email{AccountID: 1, Sender: "news@shop.com"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !attempted || !slices.Equal(service.excluded, []string{repositories.UnsubscribeStrategyOneClick}) {
+		t.Errorf("Expected one-click to be excluded, got %v", service.excluded)
+	}
+}
+
+type fakeEmailRepository struct {
+	repositories.EmailRepository
+	emails  map[int64]*entities.Email
+	flagged []int64
+	deleted []int64
+}
+
+func (r *fakeEmailRepository) GetByID(ctx context.Context, id int64) (*entities.Email, error) {
+	email, ok := r.emails[id]
+	if !ok {
+		return nil, fmt.Errorf("email %d not found", id)
+	}
+	return email, nil
+}
+
+func (r *fakeEmailRepository) MarkMailedAfterUnsubscribe(ctx context.Context, emailIDs []int64) error {
+	r.flagged = append(r.flagged, emailIDs...)
+	return nil
+}
+
+func (r *fakeEmailRepository) Delete(ctx context.Context, id int64) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+type fakeAccountRepository struct {
+	repositories.AccountRepository
+}
+
+func (r *fakeAccountRepository) GetByID(ctx context.Context, id int64) (*entities.Account, error) {
+	return &entities.Account{ID: id}, nil
+}
+
+type fakeGmailService struct {
+	repositories.GmailService
+	trashed []string
+}
+
+func (s *fakeGmailService) TrashMessage(ctx context.Context, token *oauth2.Token, messageID string) error {
+	s.trashed = append(s.trashed, messageID)
+	return nil
+}
+
+func TestSubscriptionUsecase_CheckNewEmails(t *testing.T) {
+	unsubscribedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &fakeSubscriptionRepository{
+		effective: &entities.UnsubscribeAttempt{ID: 9, SenderAddress: "news@shop.com", Success: true, CompletedAt: unsubscribedAt},
+	}
+	emailRepo := &fakeEmailRepository{}
+	gmailService := &fakeGmailService{}
+	service := &fakeUnsubscribeService{}
+	usecase := NewSubscriptionUsecase(repo, nil, emailRepo, &fakeAccountRepository{}, gmailService, service, nil,
+		48*time.Hour, entities.UnsubscribeViolationActionTrash, true)
+
+	emails := []entities.Email{
+		// Within the grace period: senders may still have mail in flight
+		{ID: 1, AccountID: 1, GmailMessageID: "m1", Sender: "Shop <news@shop.com>", ReceivedAt: unsubscribedAt.Add(24 * time.Hour)},
+		{ID: 2, AccountID: 1, GmailMessageID: "m2", Sender: "Shop <news@shop.com>", ReceivedAt: unsubscribedAt.Add(72 * time.Hour)},
+		{ID: 3, AccountID: 1, GmailMessageID: "m3", Sender: "friend@example.com", ReceivedAt: unsubscribedAt.Add(72 * time.Hour)},
+	}
+
+	remaining, err := usecase.CheckNewEmails(context.Background(), 1, emails)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !slices.Equal(emailRepo.flagged, []int64{2}) {
+		t.Errorf("Expected only email 2 to be flagged, got %v", emailRepo.flagged)
+	}
+	if !slices.Equal(repo.ineffective, []int64{9}) {
+		t.Errorf("Expected attempt 9 to be marked ineffective, got %v", repo.ineffective)
+	}
+	if !slices.Equal(gmailService.trashed, []string{"m2"}) || !slices.Equal(emailRepo.deleted, []int64{2}) {
+		t.Errorf("Expected email 2 to be trashed, got trashed=%v deleted=%v", gmailService.trashed, emailRepo.deleted)
+	}
+	if len(remaining) != 2 || remaining[0].ID != 1 || remaining[1].ID != 3 {
+		t.Errorf("Expected emails 1 and 3 to remain, got %v", remaining)
+	}
+
+	var retry entities.Email
+	select {
+	case retry = <-usecase.retryQueue:
+	default:
+		t.Fatal("Expected a retry to be queued")
+	}
+
+	// The retry still runs although email 2 was deleted, and records an attempt without it
+	usecase.retryUnsubscribe(context.Background(), retry)
+	if service.calls != 1 || len(repo.attempts) != 1 {
+		t.Fatalf("Expected the retry to unsubscribe again, got %d calls and %d attempts", service.calls, len(repo.attempts))
+	}
+	if attempt := repo.attempts[0]; attempt.EmailID != 0 || attempt.SenderAddress != "news@shop.com" {
+		t.Errorf("Expected an attempt for news@shop.com without the trashed email, got %+v", attempt)
+	}
+}
+
+type fakeArtifactStore struct {
+	repositories.ArtifactStore
+	artifacts map[string]string
+}
+
+func (s *fakeArtifactStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	content, ok := s.artifacts[key]
+	if !ok {
+		return nil, apperrors.NewNotFoundError(fmt.Sprintf("artifact %s not found", key))
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func TestSubscriptionUsecase_GetAttemptArtifact(t *testing.T) {
+	repo := &fakeSubscriptionRepository{
+		history: []entities.UnsubscribeAttempt{
+			{ID: 4, Artifacts: []string{"unsubscribe/email-1/1/before.png", "unsubscribe/email-1/1/page.html"}},
+		},
+	}
+	store := &fakeArtifactStore{artifacts: map[string]string{"unsubscribe/email-1/1/page.html": "<html></html>"}}
+	usecase := NewSubscriptionUsecase(repo, nil, nil, nil, nil, nil, store, time.Hour, entities.UnsubscribeViolationActionNone, false)
+
+	key, reader, err := usecase.GetAttemptArtifact(context.Background(), 4, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer reader.Close()
+
+	content, _ := io.ReadAll(reader)
+	if key != "unsubscribe/email-1/1/page.html" || string(content) != "<html></html>" {
+		t.Errorf("Unexpected artifact %s: %q", key, content)
+	}
+
+	// Only artifacts linked from the attempt can be read
+	if _, _, err := usecase.GetAttemptArtifact(context.Background(), 4, 2); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected a not found error for an out of range index, got %v", err)
+	}
+
+	if _, _, err := usecase.GetAttemptArtifact(context.Background(), 5, 0); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected a not found error for a missing attempt, got %v", err)
+	}
+}
+
+func TestSubscriptionUsecase_QueryErrors(t *testing.T) {
+	repo := &fakeSubscriptionRepository{subscriptions: map[string]*entities.Subscription{
+		"news@shop.com":   {SenderAddress: "news@shop.com", Status: entities.SubscriptionStatusSubscribed},
+		"digest@blog.org": {SenderAddress: "digest@blog.org", Status: entities.SubscriptionStatusUnverified},
+	}}
+	usecase := NewSubscriptionUsecase(repo, nil, nil, &fakeAccountRepository{}, nil, nil, nil, time.Hour, entities.UnsubscribeViolationActionNone, false)
+
+	if _, err := usecase.GetSubscriptions(context.Background(), 1, "paused"); !errors.Is(err, apperrors.ErrInvalidInput) {
+		t.Errorf("Expected an invalid input error for an unknown status, got %v", err)
+	}
+
+	unverified, err := usecase.GetSubscriptions(context.Background(), 1, entities.SubscriptionStatusUnverified)
+	if err != nil || len(unverified) != 1 || unverified[0].SenderAddress != "digest@blog.org" {
+		t.Errorf("Expected the unverified subscription to be listed, got %v, %v", unverified, err)
+	}
+
+	if _, err := usecase.GetSenderAttempts(context.Background(), 1, "other@shop.com"); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected a not found error for a sender of another account, got %v", err)
+	}
+
+	if _, err := usecase.GetSenderAttempts(context.Background(), 1, "Shop <News@Shop.com>"); err != nil {
+		t.Errorf("Expected the attempts of a known sender, got %v", err)
+	}
+}
+
+func TestKeyedMutex(t *testing.T) {
+	locks := newKeyedMutex()
+	unlock := locks.Lock("1/news@shop.com")
+
+	// Another sender isn't held up
+	locks.Lock("1/digest@blog.org")()
+
+	acquired := make(chan struct{})
+	go func() {
+		locks.Lock("1/news@shop.com")()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Expected the second unsubscribe from the sender to wait")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the second unsubscribe to run once the first is done")
+	}
+
+	locks.mu.Lock()
+	defer locks.mu.Unlock()
+	if len(locks.locks) != 0 {
+		t.Errorf("Expected released locks to be dropped, got %v", locks.locks)
+	}
+}
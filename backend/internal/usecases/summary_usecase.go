@@ -0,0 +1,268 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+const (
+	summaryQueueSize    = 1000
+	summaryBacklogBatch = 50
+	// Emails whose summary failed this many times are left without one
+	maxSummaryAttempts = 3
+)
+
+// SummaryUsecase generates AI summaries in the background: newly synced emails
+// are queued as they arrive, and a backlog processor fills in historical emails.
+// Both share the daily AI budget.
+type SummaryUsecase struct {
+	emailRepo       repositories.EmailRepository
+	accountRepo     repositories.AccountRepository
+	categoryRepo    repositories.CategoryRepository
+	aiService       repositories.AIService
+	budget          *AIBudget
+	backlogInterval time.Duration
+	queue           chan int64
+}
+
+func NewSummaryUsecase(
+	emailRepo repositories.EmailRepository,
+	accountRepo repositories.AccountRepository,
+	categoryRepo repositories.CategoryRepository,
+	aiService repositories.AIService,
+	budget *AIBudget,
+	backlogInterval time.Duration,
+) *SummaryUsecase {
+	return &SummaryUsecase{
+		emailRepo:       emailRepo,
+		accountRepo:     accountRepo,
+		categoryRepo:    categoryRepo,
+		aiService:       aiService,
+		budget:          budget,
+		backlogInterval: backlogInterval,
+		queue:           make(chan int64, summaryQueueSize),
+	}
+}
+
+// Start launches the summary worker and the backlog processor. Both stop when ctx is cancelled.
+func (u *SummaryUsecase) Start(ctx context.Context) {
+	go u.runWorker(ctx)
+	go u.runBacklog(ctx)
+}
+
+// EnqueueEmails schedules newly created emails for summarization without blocking the sync.
+// Emails that don't fit in the queue are picked up later by the backlog processor.
+func (u *SummaryUsecase) EnqueueEmails(emails []entities.Email) {
+	for _, email := range emails {
+		if email.ID == 0 {
+			continue
+		}
+
+		select {
+		case u.queue <- email.ID:
+		default:
+			fmt.Printf("Warning: summary queue is full, leaving remaining emails to the backlog processor\n")
+			return
+		}
+	}
+}
+
+// ProcessBacklog summarizes historical emails until today's budget is spent.
+// It returns the number of emails summarized.
+func (u *SummaryUsecase) ProcessBacklog(ctx context.Context) (int, error) {
+	remaining, err := u.budget.Remaining(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if remaining == 0 {
+		return 0, nil
+	}
+
+	emails, err := u.emailRepo.GetPendingSummaries(ctx, min(remaining, summaryBacklogBatch), maxSummaryAttempts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get emails pending summary: %w", err)
+	}
+
+	processed := 0
+	for i := range emails {
+		if ctx.Err() != nil {
+			break
+		}
+
+		// New emails may have spent the budget since it was checked
+		reserved, err := u.budget.Reserve(ctx)
+		if err != nil {
+			return processed, err
+		}
+		if !reserved {
+			break
+		}
+
+		if summarizeErr := u.summarize(ctx, &emails[i]); summarizeErr != nil {
+			fmt.Printf("Warning: failed to summarize email %d: %v\n", emails[i].ID, summarizeErr)
+			continue
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+func (u *SummaryUsecase) UpdateAccountSettings(ctx context.Context, accountID int64, autoSummarize bool) error {
+	_, err := u.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("account not found: %w", err)
+	}
+
+	err = u.accountRepo.UpdateAutoSummarize(ctx, accountID, autoSummarize)
+	if err != nil {
+		return fmt.Errorf("failed to update account summary settings: %w", err)
+	}
+
+	return nil
+}
+
+func (u *SummaryUsecase) UpdateCategorySettings(ctx context.Context, accountID, categoryID int64, autoSummarize bool) error {
+	// Check if category belongs to the account
+	categories, err := u.categoryRepo.GetByAccountID(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	found := false
+	for _, cat := range categories {
+		if cat.ID == categoryID {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("category not found or does not belong to account")
+	}
+
+	err = u.categoryRepo.UpdateAutoSummarize(ctx, categoryID, autoSummarize)
+	if err != nil {
+		return fmt.Errorf("failed to update category summary settings: %w", err)
+	}
+
+	return nil
+}
+
+func (u *SummaryUsecase) runWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case emailID := <-u.queue:
+			if err := u.summarizeNewEmail(ctx, emailID); err != nil {
+				fmt.Printf("Warning: failed to summarize email %d: %v\n", emailID, err)
+			}
+		}
+	}
+}
+
+func (u *SummaryUsecase) runBacklog(ctx context.Context) {
+	ticker := time.NewTicker(u.backlogInterval)
+	defer ticker.Stop()
+
+	for {
+		processed, err := u.ProcessBacklog(ctx)
+		if err != nil {
+			fmt.Printf("Warning: summary backlog processing failed: %v\n", err)
+		} else if processed > 0 {
+			fmt.Printf("Summary backlog processed %d emails\n", processed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *SummaryUsecase) summarizeNewEmail(ctx context.Context, emailID int64) error {
+	email, err := u.emailRepo.GetByID(ctx, emailID)
+	if err != nil {
+		return fmt.Errorf("failed to get email: %w", err)
+	}
+
+	if email.AISummary != nil && *email.AISummary != "" {
+		return nil // Summary already exists
+	}
+
+	enabled, err := u.isSummaryEnabled(ctx, email)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+
+	// Over budget, the email waits for the backlog processor on a later day
+	reserved, err := u.budget.Reserve(ctx)
+	if err != nil || !reserved {
+		return err
+	}
+
+	return u.summarize(ctx, email)
+}
+
+// isSummaryEnabled checks the account setting and skips emails in any category
+// that has auto summarization turned off
+func (u *SummaryUsecase) isSummaryEnabled(ctx context.Context, email *entities.Email) (bool, error) {
+	account, err := u.accountRepo.GetByID(ctx, email.AccountID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	if !account.AutoSummarize {
+		return false, nil
+	}
+
+	if len(email.CategoryIDs) == 0 {
+		return true, nil
+	}
+
+	categories, err := u.categoryRepo.GetByAccountID(ctx, email.AccountID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	disabledCategories := make(map[int64]bool)
+	for _, cat := range categories {
+		if !cat.AutoSummarize {
+			disabledCategories[cat.ID] = true
+		}
+	}
+
+	for _, categoryID := range email.CategoryIDs {
+		if disabledCategories[categoryID] {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (u *SummaryUsecase) summarize(ctx context.Context, email *entities.Email) error {
+	summary, err := u.aiService.SummarizeEmail(ctx, email)
+	if err != nil {
+		if recordErr := u.emailRepo.RecordSummaryFailure(ctx, email.ID); recordErr != nil {
+			fmt.Printf("Warning: failed to record summary failure for email %d: %v\n", email.ID, recordErr)
+		}
+		return fmt.Errorf("failed to generate AI summary: %w", err)
+	}
+
+	err = u.emailRepo.UpdateAISummary(ctx, email.ID, summary)
+	if err != nil {
+		return fmt.Errorf("failed to update AI summary: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+type fakeAIUsageRepository struct {
+	repositories.AIUsageRepository
+	usage map[string]int
+}
+
+func (r *fakeAIUsageRepository) GetDailyUsage(ctx context.Context, day time.Time, feature string) (int, error) {
+	return r.usage[feature], nil
+}
+
+func (r *fakeAIUsageRepository) ReserveDailyUsage(ctx context.Context, day time.Time, feature string, limit int) (bool, error) {
+	if r.usage[feature] >= limit {
+		return false, nil
+	}
+	r.usage[feature]++
+	return true, nil
+}
+
+type fakeSummaryEmailRepository struct {
+	repositories.EmailRepository
+	emails    []entities.Email
+	summaries map[int64]string
+	failures  map[int64]int
+}
+
+func (r *fakeSummaryEmailRepository) GetByID(ctx context.Context, id int64) (*entities.Email, error) {
+	for i := range r.emails {
+		if r.emails[i].ID == id {
+			return &r.emails[i], nil
+		}
+	}
+	return nil, fmt.Errorf("email %d not found", id)
+}
+
+func (r *fakeSummaryEmailRepository) GetPendingSummaries(ctx context.Context, limit, maxAttempts int) ([]entities.Email, error) {
+	var pending []entities.Email
+	for _, email := range r.emails {
+		if _, done := r.summaries[email.ID]; done || r.failures[email.ID] >= maxAttempts {
+			continue
+		}
+		if len(pending) < limit {
+			pending = append(pending, email)
+		}
+	}
+	return pending, nil
+}
+
+func (r *fakeSummaryEmailRepository) UpdateAISummary(ctx context.Context, emailID int64, summary string) error {
+	r.summaries[emailID] = summary
+	return nil
+}
+
+func (r *fakeSummaryEmailRepository) RecordSummaryFailure(ctx context.Context, emailID int64) error {
+	r.failures[emailID]++
+	return nil
+}
+
+type fakeSummaryAccountRepository struct {
+	repositories.AccountRepository
+}
+
+func (r *fakeSummaryAccountRepository) GetByID(ctx context.Context, id int64) (*entities.Account, error) {
+	return &entities.Account{ID: id, AutoSummarize: true}, nil
+}
+
+type fakeSummaryAIService struct {
+	repositories.AIService
+	failing map[int64]bool
+	calls   []int64
+}
+
+func (s *fakeSummaryAIService) SummarizeEmail(ctx context.Context, email *entities.Email) (string, error) {
+	s.calls = append(s.calls, email.ID)
+	if s.failing[email.ID] {
+		return "", fmt.Errorf("model overloaded")
+	}
+	return fmt.Sprintf("Summary of %d", email.ID), nil
+}
+
+func newTestSummaryUsecase(emails []entities.Email, budget int, categories []entities.Category) (*SummaryUsecase, *fakeSummaryEmailRepository, *fakeSummaryAIService, *fakeAIUsageRepository) {
+	emailRepo := &fakeSummaryEmailRepository{emails: emails, summaries: map[int64]string{}, failures: map[int64]int{}}
+	aiService := &fakeSummaryAIService{failing: map[int64]bool{}}
+	usageRepo := &fakeAIUsageRepository{usage: map[string]int{}}
+	usecase := NewSummaryUsecase(emailRepo, &fakeSummaryAccountRepository{}, &fakeCategoryRepository{categories: categories},
+		aiService, NewAIBudget(usageRepo, budget), time.Minute)
+	return usecase, emailRepo, aiService, usageRepo
+}
+
+func TestSummaryUsecase_ProcessBacklogStopsAtBudget(t *testing.T) {
+	emails := []entities.Email{{ID: 1, AccountID: 1}, {ID: 2, AccountID: 1}, {ID: 3, AccountID: 1}}
+	usecase, emailRepo, _, _ := newTestSummaryUsecase(emails, 2, nil)
+
+	processed, err := usecase.ProcessBacklog(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if processed != 2 || len(emailRepo.summaries) != 2 {
+		t.Errorf("Expected 2 emails summarized within the budget, got %d and %v", processed, emailRepo.summaries)
+	}
+
+	processed, err = usecase.ProcessBacklog(context.Background())
+	if err != nil || processed != 0 {
+		t.Errorf("Expected nothing to be processed once the budget is spent, got %d, %v", processed, err)
+	}
+}
+
+func TestSummaryUsecase_ProcessBacklogGivesUpOnFailingEmails(t *testing.T) {
+	emails := []entities.Email{{ID: 1, AccountID: 1}, {ID: 2, AccountID: 1}}
+	usecase, emailRepo, aiService, _ := newTestSummaryUsecase(emails, 100, nil)
+	aiService.failing[1] = true
+
+	for range maxSummaryAttempts + 2 {
+		if _, err := usecase.ProcessBacklog(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	// The failing email is tried until it runs out of attempts, and isn't picked again
+	attempts := 0
+	for _, id := range aiService.calls {
+		if id == 1 {
+			attempts++
+		}
+	}
+	if attempts != maxSummaryAttempts || emailRepo.failures[1] != maxSummaryAttempts {
+		t.Errorf("Expected %d attempts at the failing email, got %d calls and %d failures", maxSummaryAttempts, attempts, emailRepo.failures[1])
+	}
+	if emailRepo.summaries[2] != "Summary of 2" {
+		t.Errorf("Expected the other email to be summarized, got %v", emailRepo.summaries)
+	}
+}
+
+func TestSummaryUsecase_NewEmailsShareTheBudget(t *testing.T) {
+	emails := []entities.Email{{ID: 1, AccountID: 1}, {ID: 2, AccountID: 1}}
+	usecase, emailRepo, _, usageRepo := newTestSummaryUsecase(emails, 1, nil)
+
+	for _, id := range []int64{1, 2} {
+		if err := usecase.summarizeNewEmail(context.Background(), id); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if len(emailRepo.summaries) != 1 || usageRepo.usage[aiBudgetFeature] != 1 {
+		t.Errorf("Expected new emails to stop at the budget, got %v and usage %v", emailRepo.summaries, usageRepo.usage)
+	}
+}
+
+func TestSummaryUsecase_SkipsDisabledCategories(t *testing.T) {
+	categories := []entities.Category{
+		{ID: 1, Name: "CATEGORY_PROMOTIONS", AutoSummarize: false},
+		{ID: 2, Name: "Inbox", AutoSummarize: true},
+	}
+	emails := []entities.Email{{ID: 1, AccountID: 1, CategoryIDs: []int64{1, 2}}, {ID: 2, AccountID: 1, CategoryIDs: []int64{2}}}
+	usecase, emailRepo, aiService, _ := newTestSummaryUsecase(emails, 10, categories)
+
+	for _, id := range []int64{1, 2} {
+		if err := usecase.summarizeNewEmail(context.Background(), id); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if !slices.Equal(aiService.calls, []int64{2}) || len(emailRepo.summaries) != 1 {
+		t.Errorf("Expected only the email outside Promotions to be summarized, got calls %v", aiService.calls)
+	}
+}
+
+type fakeLabelCategoryRepository struct {
+	repositories.CategoryRepository
+	names map[string]int64
+}
+
+func (r *fakeLabelCategoryRepository) GetOrCreate(ctx context.Context, accountID int64, name string) (*entities.Category, error) {
+	if _, exists := r.names[name]; !exists {
+		r.names[name] = int64(len(r.names) + 1)
+	}
+	return &entities.Category{ID: r.names[name], AccountID: accountID, Name: name}, nil
+}
+
+func TestGetCategoriesFromLabels_GmailTabs(t *testing.T) {
+	categoryRepo := &fakeLabelCategoryRepository{names: map[string]int64{}}
+	usecase := &EmailUsecase{categoryRepo: categoryRepo}
+
+	_, err := usecase.getCategoriesFromLabels(context.Background(), 1, []string{"INBOX", "CATEGORY_PROMOTIONS", "UNREAD"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The Promotions tab needs a category for its summary setting to apply to
+	if _, exists := categoryRepo.names["CATEGORY_PROMOTIONS"]; !exists || len(categoryRepo.names) != 2 {
+		t.Errorf("Expected Inbox and CATEGORY_PROMOTIONS categories, got %v", categoryRepo.names)
+	}
+}
@@ -0,0 +1,195 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+const threadQueueSize = 1000
+
+// ThreadUsecase groups emails into Gmail conversations and keeps an AI summary
+// of each conversation up to date as new replies arrive. Summaries updated in the
+// background count against the daily AI budget.
+type ThreadUsecase struct {
+	threadRepo repositories.ThreadRepository
+	emailRepo  repositories.EmailRepository
+	aiService  repositories.AIService
+	budget     *AIBudget
+	queue      chan int64
+}
+
+func NewThreadUsecase(
+	threadRepo repositories.ThreadRepository,
+	emailRepo repositories.EmailRepository,
+	aiService repositories.AIService,
+	budget *AIBudget,
+) *ThreadUsecase {
+	return &ThreadUsecase{
+		threadRepo: threadRepo,
+		emailRepo:  emailRepo,
+		aiService:  aiService,
+		budget:     budget,
+		queue:      make(chan int64, threadQueueSize),
+	}
+}
+
+// Start launches the thread summary worker. It stops when ctx is cancelled.
+func (u *ThreadUsecase) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case threadID := <-u.queue:
+				if err := u.refreshThreadSummary(ctx, threadID); err != nil {
+					fmt.Printf("Warning: failed to update summary for thread %d: %v\n", threadID, err)
+				}
+			}
+		}
+	}()
+}
+
+// AssignThreads links newly created emails to their threads and schedules the
+// affected thread summaries for an update
+func (u *ThreadUsecase) AssignThreads(ctx context.Context, accountID int64, emails []entities.Email) error {
+	emailIDsByThread := make(map[string][]int64)
+	subjects := make(map[string]string)
+	for _, email := range emails {
+		if email.ID == 0 || email.GmailThreadID == "" {
+			continue
+		}
+		emailIDsByThread[email.GmailThreadID] = append(emailIDsByThread[email.GmailThreadID], email.ID)
+		if _, exists := subjects[email.GmailThreadID]; !exists {
+			subjects[email.GmailThreadID] = email.Subject
+		}
+	}
+
+	for gmailThreadID, emailIDs := range emailIDsByThread {
+		thread, err := u.threadRepo.GetOrCreate(ctx, accountID, gmailThreadID, subjects[gmailThreadID])
+		if err != nil {
+			return fmt.Errorf("failed to get thread %s: %w", gmailThreadID, err)
+		}
+
+		err = u.threadRepo.AttachEmails(ctx, thread.ID, emailIDs)
+		if err != nil {
+			return fmt.Errorf("failed to attach emails to thread %s: %w", gmailThreadID, err)
+		}
+
+		select {
+		case u.queue <- thread.ID:
+		default:
+			fmt.Printf("Warning: thread queue is full, skipping summary update for thread %d\n", thread.ID)
+		}
+	}
+
+	return nil
+}
+
+// ResetAccount removes all threads of an account, used before a full resync
+func (u *ThreadUsecase) ResetAccount(ctx context.Context, accountID int64) error {
+	return u.threadRepo.DeleteByAccountID(ctx, accountID)
+}
+
+func (u *ThreadUsecase) GetThread(ctx context.Context, threadID int64) (*entities.Thread, error) {
+	thread, err := u.loadThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	sanitizeBodies(thread.Emails)
+
+	return thread, nil
+}
+
+// loadThread returns a thread with its messages as stored, for the AI to summarize
+func (u *ThreadUsecase) loadThread(ctx context.Context, threadID int64) (*entities.Thread, error) {
+	thread, err := u.threadRepo.GetByID(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	emails, err := u.emailRepo.GetByThreadID(ctx, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread emails: %w", err)
+	}
+	thread.Emails = emails
+
+	return thread, nil
+}
+
+// SummarizeThread brings the thread summary up to date and returns the thread with its messages
+func (u *ThreadUsecase) SummarizeThread(ctx context.Context, threadID int64) (*entities.Thread, error) {
+	thread, err := u.loadThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.updateSummary(ctx, thread)
+	if err != nil {
+		return nil, err
+	}
+	sanitizeBodies(thread.Emails)
+
+	return thread, nil
+}
+
+func (u *ThreadUsecase) refreshThreadSummary(ctx context.Context, threadID int64) error {
+	thread, err := u.loadThread(ctx, threadID)
+	if err != nil {
+		return err
+	}
+
+	// Single messages are covered by their own email summary
+	if len(thread.Emails) < 2 {
+		return nil
+	}
+
+	if !slices.ContainsFunc(thread.Emails, func(email entities.Email) bool { return !email.ThreadSummarized }) {
+		return nil // Summary is up to date
+	}
+
+	// Over budget, the summary is brought up to date by the next reply or on request
+	reserved, err := u.budget.Reserve(ctx)
+	if err != nil || !reserved {
+		return err
+	}
+
+	return u.updateSummary(ctx, thread)
+}
+
+// updateSummary feeds only the messages not yet covered by the summary to the AI,
+// so long conversations are not re-summarized from scratch on every reply
+func (u *ThreadUsecase) updateSummary(ctx context.Context, thread *entities.Thread) error {
+	var newMessages []entities.Email
+	var newMessageIDs []int64
+	for _, email := range thread.Emails {
+		if !email.ThreadSummarized {
+			newMessages = append(newMessages, email)
+			newMessageIDs = append(newMessageIDs, email.ID)
+		}
+	}
+
+	if len(newMessages) == 0 {
+		return nil // Summary is up to date
+	}
+
+	summary, err := u.aiService.SummarizeThread(ctx, thread, newMessages)
+	if err != nil {
+		return fmt.Errorf("failed to generate thread summary: %w", err)
+	}
+
+	err = u.threadRepo.UpdateSummary(ctx, thread.ID, summary, newMessageIDs)
+	if err != nil {
+		return fmt.Errorf("failed to update thread summary: %w", err)
+	}
+
+	thread.AISummary = &summary
+	for i := range thread.Emails {
+		thread.Emails[i].ThreadSummarized = true
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package usecases
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/email-sorting-app/internal/domain/entities"
+	"github.com/email-sorting-app/internal/domain/repositories"
+)
+
+type fakeThreadRepository struct {
+	repositories.ThreadRepository
+	thread    entities.Thread
+	emailRepo *fakeThreadEmailRepository
+}
+
+func (r *fakeThreadRepository) GetByID(ctx context.Context, id int64) (*entities.Thread, error) {
+	thread := r.thread
+	return &thread, nil
+}
+
+func (r *fakeThreadRepository) UpdateSummary(ctx context.Context, threadID int64, summary string, emailIDs []int64) error {
+	r.thread.AISummary = &summary
+	for i := range r.emailRepo.emails {
+		if slices.Contains(emailIDs, r.emailRepo.emails[i].ID) {
+			r.emailRepo.emails[i].ThreadSummarized = true
+		}
+	}
+	return nil
+}
+
+type fakeThreadEmailRepository struct {
+	repositories.EmailRepository
+	emails []entities.Email
+}
+
+func (r *fakeThreadEmailRepository) GetByThreadID(ctx context.Context, threadID int64) ([]entities.Email, error) {
+	return slices.Clone(r.emails), nil
+}
+
+type fakeThreadAIService struct {
+	repositories.AIService
+	calls [][]int64
+}
+
+func (s *fakeThreadAIService) SummarizeThread(ctx context.Context, thread *entities.Thread, newMessages []entities.Email) (string, error) {
+	var ids []int64
+	for _, email := range newMessages {
+		ids = append(ids, email.ID)
+	}
+	s.calls = append(s.calls, ids)
+	return "Thread summary", nil
+}
+
+func TestThreadUsecase_SummarizeThreadCoversEveryNewMessage(t *testing.T) {
+	receivedAt := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	emailRepo := &fakeThreadEmailRepository{emails: []entities.Email{
+		// A late-synced reply older than the summarized message
+		{ID: 3, ReceivedAt: receivedAt.Add(-time.Hour)},
+		{ID: 1, ReceivedAt: receivedAt, ThreadSummarized: true},
+		// A reply received in the same second as the summarized message
+		{ID: 2, ReceivedAt: receivedAt},
+	}}
+	threadRepo := &fakeThreadRepository{thread: entities.Thread{ID: 1}, emailRepo: emailRepo}
+	aiService := &fakeThreadAIService{}
+	usecase := NewThreadUsecase(threadRepo, emailRepo, aiService, newTestAIBudget(10))
+
+	thread, err := usecase.SummarizeThread(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(aiService.calls) != 1 || !slices.Equal(aiService.calls[0], []int64{3, 2}) {
+		t.Errorf("Expected the messages not yet summarized to be sent, got %v", aiService.calls)
+	}
+	if thread.AISummary == nil || *thread.AISummary != "Thread summary" {
+		t.Errorf("Expected the thread to carry the new summary, got %v", thread.AISummary)
+	}
+
+	// Nothing is left to summarize until another reply arrives
+	if _, err := usecase.SummarizeThread(context.Background(), 1); err != nil || len(aiService.calls) != 1 {
+		t.Errorf("Expected the summary to be up to date, got %v and calls %v", err, aiService.calls)
+	}
+
+	emailRepo.emails = append(emailRepo.emails, entities.Email{ID: 4, ReceivedAt: receivedAt})
+	if _, err := usecase.SummarizeThread(context.Background(), 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(aiService.calls) != 2 || !slices.Equal(aiService.calls[1], []int64{4}) {
+		t.Errorf("Expected only the new reply to be sent, got %v", aiService.calls)
+	}
+}
+
+func TestThreadUsecase_RefreshSkipsSingleMessages(t *testing.T) {
+	emailRepo := &fakeThreadEmailRepository{emails: []entities.Email{{ID: 1}}}
+	aiService := &fakeThreadAIService{}
+	usecase := NewThreadUsecase(&fakeThreadRepository{thread: entities.Thread{ID: 1}, emailRepo: emailRepo}, emailRepo, aiService, newTestAIBudget(10))
+
+	if err := usecase.refreshThreadSummary(context.Background(), 1); err != nil || len(aiService.calls) != 0 {
+		t.Errorf("Expected a single message not to be summarized as a thread, got %v and calls %v", err, aiService.calls)
+	}
+}
+
+func TestThreadUsecase_RefreshRespectsBudget(t *testing.T) {
+	emailRepo := &fakeThreadEmailRepository{emails: []entities.Email{{ID: 1}, {ID: 2}}}
+	aiService := &fakeThreadAIService{}
+	threadRepo := &fakeThreadRepository{thread: entities.Thread{ID: 1}, emailRepo: emailRepo}
+	usecase := NewThreadUsecase(threadRepo, emailRepo, aiService, newTestAIBudget(0))
+
+	if err := usecase.refreshThreadSummary(context.Background(), 1); err != nil || len(aiService.calls) != 0 {
+		t.Errorf("Expected no summary once the budget is spent, got %v and calls %v", err, aiService.calls)
+	}
+
+	// Summaries the user asks for aren't counted
+	if _, err := usecase.SummarizeThread(context.Background(), 1); err != nil || len(aiService.calls) != 1 {
+		t.Errorf("Expected a requested summary to be made, got %v and calls %v", err, aiService.calls)
+	}
+}
+
+func newTestAIBudget(limit int) *AIBudget {
+	return NewAIBudget(&fakeAIUsageRepository{usage: map[string]int{}}, limit)
+}
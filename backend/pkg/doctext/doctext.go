@@ -0,0 +1,94 @@
+// Package doctext pulls the text out of attached documents so it can be searched
+// and categorized. Extraction is best effort: it reads what plain parsing can
+// recover and gives up on anything else.
+package doctext
+
+import (
+	"errors"
+	"mime"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/email-sorting-app/pkg/mimeparse"
+	"golang.org/x/net/html/charset"
+)
+
+var ErrUnsupported = errors.New("unsupported document type")
+
+// Media types read as text besides text/*
+var textTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/x-yaml":     true,
+	"application/javascript": true,
+}
+
+// Extensions of text files that mime.TypeByExtension only knows from system tables
+var textExtensions = map[string]bool{
+	".txt": true, ".csv": true, ".tsv": true, ".md": true, ".log": true, ".ics": true, ".vcf": true,
+}
+
+// Supported reports whether text can be extracted from a document. Mail clients
+// often send documents as application/octet-stream, so the file name counts too.
+func Supported(mimeType, filename string) bool {
+	return kind(mimeType, filename) != ""
+}
+
+// Extract returns the text of a document
+func Extract(mimeType, filename string, data []byte) (string, error) {
+	var text string
+	switch kind(mimeType, filename) {
+	case "text":
+		text = decodeText(data)
+	case "html":
+		text = mimeparse.HTMLToText(decodeText(data))
+	case "pdf":
+		var err error
+		text, err = extractPDF(data)
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", ErrUnsupported
+	}
+
+	return strings.TrimSpace(text), nil
+}
+
+func kind(mimeType, filename string) string {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil || mediaType == "application/octet-stream" {
+		ext := strings.ToLower(path.Ext(filename))
+		if textExtensions[ext] {
+			return "text"
+		}
+		mediaType, _, _ = mime.ParseMediaType(mime.TypeByExtension(ext))
+	}
+
+	switch {
+	case mediaType == "application/pdf":
+		return "pdf"
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return "html"
+	case strings.HasPrefix(mediaType, "text/") || textTypes[mediaType]:
+		return "text"
+	}
+	return ""
+}
+
+// decodeText reads UTF-8 when the content is valid UTF-8 or has a BOM, and Windows-1252 otherwise
+func decodeText(data []byte) string {
+	encoding, _, _ := charset.DetermineEncoding(data, "text/plain")
+	decoded, err := encoding.NewDecoder().Bytes(data)
+	if err != nil {
+		decoded = data
+	}
+
+	text := string(decoded)
+	if !utf8.Valid(decoded) {
+		text = strings.ToValidUTF8(text, "\uFFFD")
+	}
+	text = strings.TrimPrefix(text, "\uFEFF")
+	return strings.ReplaceAll(text, "\r\n", "\n")
+}
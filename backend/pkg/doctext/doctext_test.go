@@ -0,0 +1,199 @@
+package doctext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF lays out a minimal PDF with one page per content stream
+func buildPDF(t *testing.T, compress bool, contents ...string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	buf.WriteString("1 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>\nendobj\n")
+	for i, content := range contents {
+		data := []byte(content)
+		filter := ""
+		if compress {
+			var zbuf bytes.Buffer
+			w := zlib.NewWriter(&zbuf)
+			w.Write(data)
+			w.Close()
+			data = zbuf.Bytes()
+			filter = " /Filter /FlateDecode"
+		}
+		fmt.Fprintf(&buf, "%d 0 obj\n<< /Length %d%s >>\nstream\n", i+2, len(data), filter)
+		buf.Write(data)
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+	buf.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+func TestExtract_PDF(t *testing.T) {
+	pdf := buildPDF(t, true,
+		"BT /F1 12 Tf 72 720 Td (Invoice #1042) Tj 0 -14 Td (Total due: \\20042.50) Tj ET",
+		"BT /F1 12 Tf [(Pay)-20(ment)-300(terms)] TJ T* (Net \\(30\\) days) Tj ET",
+	)
+
+	text, err := Extract("application/pdf", "invoice.pdf", pdf)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := "Invoice #1042\nTotal due: €42.50\nPayment terms\nNet (30) days"
+	if text != expected {
+		t.Errorf("Expected %q, got %q", expected, text)
+	}
+}
+
+func TestExtract_PDFStrings(t *testing.T) {
+	pdf := buildPDF(t, false,
+		// UTF-16 with a byte order mark, as used for text outside WinAnsiEncoding
+		"BT <FEFF00430061006600E900A9> Tj ET",
+		// Two-byte glyph IDs of an embedded font can't be read and are skipped
+		"BT <0012003400560078> Tj (readable) Tj ET",
+		// Strings outside text objects aren't shown
+		"(hidden) Tj",
+	)
+
+	text, err := Extract("application/octet-stream", "scan.PDF", pdf)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "Café©\nreadable" {
+		t.Errorf("Expected %q, got %q", "Café©\nreadable", text)
+	}
+}
+
+func TestExtract_PDFSkipsImages(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<< /Type /XObject /Subtype /Image /Length 22 >>\nstream\nBT (not text) Tj ET\nendstream\nendobj\n")
+
+	text, err := Extract("application/pdf", "", pdf)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "" {
+		t.Errorf("Expected no text from an image stream, got %q", text)
+	}
+}
+
+func TestExtract_PDFEncrypted(t *testing.T) {
+	pdf := []byte("%PDF-1.4\ntrailer\n<< /Encrypt 5 0 R >>\n")
+
+	if _, err := Extract("application/pdf", "", pdf); err == nil {
+		t.Error("Expected an error for an encrypted PDF")
+	}
+}
+
+func TestExtract_PDFMalformed(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n" +
+		"2 0 obj\n<< /Length 10 /Filter /FlateDecode >>\nstream\nnot zlib data\nendstream\nendobj\n" +
+		"3 0 obj\n<< /Length 99 >>\nstream\nBT (Kept) Tj ET\nendstream\nendobj\n" +
+		// A stream cut off in the middle of an unterminated string
+		"4 0 obj\n<< /Length 99 >>\nstream\nBT (Cut off) Tj (never clo")
+
+	text, err := Extract("application/pdf", "", pdf)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "Kept\nCut off" {
+		t.Errorf("Expected %q, got %q", "Kept\nCut off", text)
+	}
+}
+
+func TestExtract_PDFLarge(t *testing.T) {
+	// Many pages are read in one pass over the document
+	pages := make([]string, 20000)
+	for i := range pages {
+		pages[i] = fmt.Sprintf("BT (Page %d) Tj ET", i+1)
+	}
+	text, err := Extract("application/pdf", "", buildPDF(t, false, pages...))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasSuffix(text, "Page 20000") {
+		t.Errorf("Expected the text of every page, got %d bytes", len(text))
+	}
+
+	// Streams that inflate to the per-stream limit use up the budget for the
+	// document, and the streams after them aren't read
+	bomb := strings.Repeat(" ", maxStreamSize)
+	contents := []string{}
+	for range maxInflatedSize/maxStreamSize + 1 {
+		contents = append(contents, bomb)
+	}
+	contents = append(contents, "BT (After the bombs) Tj ET")
+	text, err = Extract("application/pdf", "", buildPDF(t, true, contents...))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "" {
+		t.Errorf("Expected reading to stop at the inflate budget, got %q", text)
+	}
+
+	// Collected text is capped too
+	long := "BT (" + strings.Repeat("x", 100000) + ") Tj ET"
+	contents = []string{}
+	for range 2 * maxPDFTextSize / 100000 {
+		contents = append(contents, long)
+	}
+	text, err = Extract("application/pdf", "", buildPDF(t, true, contents...))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(text) > maxPDFTextSize+100001 {
+		t.Errorf("Expected the text to stop near %d bytes, got %d", maxPDFTextSize, len(text))
+	}
+}
+
+func TestExtract_Text(t *testing.T) {
+	tests := []struct {
+		name     string
+		mimeType string
+		filename string
+		data     []byte
+		expected string
+	}{
+		{"utf-8", "text/plain; charset=utf-8", "notes.txt", []byte("Caf\xc3\xa9\r\nmenu\r\n"), "Café\nmenu"},
+		{"windows-1252", "text/csv", "prices.csv", []byte("item,price\ncaf\xe9,\x803\n"), "item,price\ncafé,€3"},
+		{"byte order mark", "text/plain", "", []byte("\xef\xbb\xbfhello"), "hello"},
+		{"by extension", "application/octet-stream", "README.md", []byte("# Title"), "# Title"},
+		{"json", "application/json", "data.json", []byte(`{"a": 1}`), `{"a": 1}`},
+		{"html", "text/html", "page.html", []byte("<p>Hello</p><script>x()</script><p>world</p>"), "Hello\n\nworld"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := Extract(tt.mimeType, tt.filename, tt.data)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if text != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, text)
+			}
+		})
+	}
+}
+
+func TestExtract_Unsupported(t *testing.T) {
+	if Supported("image/png", "photo.png") {
+		t.Error("Expected images not to be supported")
+	}
+	if !Supported("application/octet-stream", "contract.pdf") {
+		t.Error("Expected a PDF sent as octet-stream to be supported")
+	}
+
+	if _, err := Extract("image/png", "photo.png", []byte{0x89, 'P', 'N', 'G'}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+	// A file named .pdf that isn't one
+	if _, err := Extract("application/pdf", "fake.pdf", []byte("hello")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+}
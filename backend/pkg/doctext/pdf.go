@@ -0,0 +1,398 @@
+package doctext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Decompressed content is capped per stream and per document, and text stops being
+// collected past a limit, so a small hostile PDF can't expand without bound
+const (
+	maxStreamSize   = 16 << 20
+	maxInflatedSize = 64 << 20
+	maxPDFTextSize  = 1 << 20
+)
+
+var (
+	errEncryptedPDF = errors.New("encrypted PDF")
+
+	streamKeywordRegex = regexp.MustCompile(`stream\r?\n`)
+	objKeywordRegex    = regexp.MustCompile(`\d+\s+\d+\s+obj\b`)
+	// Streams that hold images, fonts or other objects rather than page content
+	skippedStreamRegex = regexp.MustCompile(`/Subtype\s*/Image|/Type\s*/(ObjStm|XRef|Metadata|EmbeddedFile)|/FontFile|/Length[123]\b`)
+	// Filters this reader can't undo; FlateDecode is the only one it handles
+	otherFilterRegex = regexp.MustCompile(`/(DCTDecode|JPXDecode|CCITTFaxDecode|JBIG2Decode|LZWDecode|RunLengthDecode|ASCII85Decode|ASCIIHexDecode)\b`)
+)
+
+// extractPDF reads the text shown by the content streams of a PDF. Text drawn
+// with fonts that use custom glyph encodings can't be recovered and is skipped.
+func extractPDF(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF")) {
+		return "", ErrUnsupported
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return "", errEncryptedPDF
+	}
+
+	var out strings.Builder
+	inflateBudget := int64(maxInflatedSize)
+	// The document is scanned once: objStart is where the object that the current
+	// stream belongs to begins, and scanned is how far objects have been looked for
+	objStart, scanned := 0, 0
+	for _, loc := range streamKeywordRegex.FindAllIndex(data, -1) {
+		// The keyword ends the stream's dictionary; "endstream" matches too
+		if loc[0] >= 3 && string(data[loc[0]-3:loc[0]]) == "end" {
+			continue
+		}
+		if loc[0] < scanned {
+			// The keyword is inside the previous stream's content
+			continue
+		}
+		if locs := objKeywordRegex.FindAllIndex(data[scanned:loc[0]], -1); len(locs) > 0 {
+			objStart = scanned + locs[len(locs)-1][1]
+		}
+
+		content := data[loc[1]:]
+		if end := bytes.Index(content, []byte("endstream")); end >= 0 {
+			content = content[:end]
+		}
+		scanned = loc[1] + len(content)
+
+		dict := string(data[objStart:loc[0]])
+		if skippedStreamRegex.MatchString(dict) || otherFilterRegex.MatchString(dict) {
+			continue
+		}
+		if strings.Contains(dict, "/FlateDecode") {
+			if inflateBudget <= 0 {
+				break
+			}
+			content = inflate(content, min(inflateBudget, maxStreamSize))
+			inflateBudget -= int64(len(content))
+		}
+
+		if text := contentText(content); text != "" {
+			out.WriteString(text)
+			out.WriteString("\n")
+		}
+		if out.Len() >= maxPDFTextSize {
+			break
+		}
+	}
+
+	return out.String(), nil
+}
+
+// inflate decompresses up to limit bytes of a FlateDecode stream. A damaged stream
+// still yields its beginning.
+func inflate(data []byte, limit int64) []byte {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+
+	decoded, _ := io.ReadAll(io.LimitReader(reader, limit))
+	return decoded
+}
+
+// contentText interprets the text operators of a content stream (PDF 32000-1, 9.4)
+func contentText(content []byte) string {
+	s := &scanner{data: content}
+	var out strings.Builder
+	var operands []token
+	inText := false
+	lastY := 0.0
+
+	newline := func() {
+		if out.Len() > 0 && !strings.HasSuffix(out.String(), "\n") {
+			out.WriteString("\n")
+		}
+	}
+
+	for {
+		tok, ok := s.next()
+		if !ok {
+			break
+		}
+		if tok.kind != tokenOperator {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch tok.value {
+		case "BT":
+			inText = true
+		case "ET":
+			inText = false
+			newline()
+		case "Tj":
+			if inText && len(operands) > 0 {
+				out.WriteString(operands[len(operands)-1].text())
+			}
+		case "'", "\"":
+			if inText && len(operands) > 0 {
+				newline()
+				out.WriteString(operands[len(operands)-1].text())
+			}
+		case "TJ":
+			if inText {
+				for _, item := range operands {
+					switch item.kind {
+					case tokenString:
+						out.WriteString(item.text())
+					case tokenNumber:
+						// A wide negative adjustment is how many generators draw a space
+						if n, err := strconv.ParseFloat(item.value, 64); err == nil && n < -200 {
+							out.WriteString(" ")
+						}
+					}
+				}
+			}
+		case "Td", "TD":
+			if inText && len(operands) >= 2 {
+				if y, err := strconv.ParseFloat(operands[len(operands)-1].value, 64); err == nil && y != 0 {
+					newline()
+				} else {
+					out.WriteString(" ")
+				}
+			}
+		case "T*":
+			newline()
+		case "Tm":
+			if inText && len(operands) >= 6 {
+				y, err := strconv.ParseFloat(operands[len(operands)-1].value, 64)
+				if err == nil && y != lastY {
+					newline()
+					lastY = y
+				} else {
+					out.WriteString(" ")
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+
+	return collapseSpaces(out.String())
+}
+
+var spacesRegex = regexp.MustCompile(`[ \t\f\x{00a0}]+`)
+
+func collapseSpaces(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(spacesRegex.ReplaceAllString(line, " ")); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+type tokenKind int
+
+const (
+	tokenNumber tokenKind = iota
+	tokenString
+	tokenName
+	tokenOperator
+	tokenOther
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	// raw holds the bytes of a string
+	raw []byte
+}
+
+// text decodes a string operand. Strings with a byte order mark are UTF-16, others
+// are read as WinAnsiEncoding, the encoding of the standard fonts. Strings that are
+// mostly control characters are glyph IDs of a custom-encoded font and are dropped.
+func (t token) text() string {
+	if t.kind != tokenString {
+		return ""
+	}
+
+	raw := t.raw
+	var text string
+	if len(raw) >= 2 && raw[0] == 0xfe && raw[1] == 0xff {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		text = string(utf16.Decode(units))
+	} else {
+		decoded, err := charmap.Windows1252.NewDecoder().Bytes(raw)
+		if err != nil {
+			return ""
+		}
+		text = string(decoded)
+	}
+
+	var controls, total int
+	for _, r := range text {
+		total++
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			controls++
+		}
+	}
+	if total > 0 && controls*3 > total {
+		return ""
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, text)
+}
+
+// scanner splits a content stream into tokens
+type scanner struct {
+	data []byte
+	pos  int
+}
+
+func isDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func (s *scanner) next() (token, bool) {
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		switch {
+		case isSpace(c):
+			s.pos++
+		case c == '%':
+			for s.pos < len(s.data) && s.data[s.pos] != '\n' && s.data[s.pos] != '\r' {
+				s.pos++
+			}
+		case c == '(':
+			s.pos++
+			return token{kind: tokenString, raw: s.literalString()}, true
+		case c == '<' && s.pos+1 < len(s.data) && s.data[s.pos+1] == '<',
+			c == '>' && s.pos+1 < len(s.data) && s.data[s.pos+1] == '>':
+			s.pos += 2
+			return token{kind: tokenOther}, true
+		case c == '<':
+			s.pos++
+			return token{kind: tokenString, raw: s.hexString()}, true
+		case c == '[' || c == ']' || c == '{' || c == '}' || c == ')' || c == '>':
+			s.pos++
+			return token{kind: tokenOther}, true
+		case c == '/':
+			s.pos++
+			return token{kind: tokenName, value: s.word()}, true
+		default:
+			word := s.word()
+			if word == "" {
+				s.pos++
+				continue
+			}
+			if _, err := strconv.ParseFloat(word, 64); err == nil {
+				return token{kind: tokenNumber, value: word}, true
+			}
+			return token{kind: tokenOperator, value: word}, true
+		}
+	}
+	return token{}, false
+}
+
+func (s *scanner) word() string {
+	start := s.pos
+	for s.pos < len(s.data) && !isSpace(s.data[s.pos]) && !isDelimiter(s.data[s.pos]) {
+		s.pos++
+	}
+	return string(s.data[start:s.pos])
+}
+
+// literalString reads a string in parentheses, which nest unless escaped
+func (s *scanner) literalString() []byte {
+	var out []byte
+	depth := 1
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		s.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if s.pos >= len(s.data) {
+				return out
+			}
+			e := s.data[s.pos]
+			s.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// A line continuation
+				if e == '\r' && s.pos < len(s.data) && s.data[s.pos] == '\n' {
+					s.pos++
+				}
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					value := int(e - '0')
+					for i := 0; i < 2 && s.pos < len(s.data) && s.data[s.pos] >= '0' && s.data[s.pos] <= '7'; i++ {
+						value = value*8 + int(s.data[s.pos]-'0')
+						s.pos++
+					}
+					c = byte(value)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// hexString reads a string in angle brackets; a missing final digit is zero
+func (s *scanner) hexString() []byte {
+	var digits []byte
+	for s.pos < len(s.data) && s.data[s.pos] != '>' {
+		if c := s.data[s.pos]; c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' {
+			digits = append(digits, c)
+		}
+		s.pos++
+	}
+	s.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		value, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(value)
+	}
+	return out
+}
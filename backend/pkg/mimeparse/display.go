@@ -0,0 +1,168 @@
+package mimeparse
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DisplayOptions decide where the images of a body are loaded from when it is shown
+type DisplayOptions struct {
+	// RemoteImage returns the URL to load a remote image through, e.g. a proxy, or
+	// "" to block it. Without it, remote images are blocked.
+	RemoteImage func(src string) string
+	// InlineImage returns the URL of the part a cid: reference points to, or "" to
+	// drop the image
+	InlineImage func(contentID string) string
+}
+
+// DisplayHTML is a body prepared to be rendered by a client
+type DisplayHTML struct {
+	HTML string
+	// Trackers counts the tracking pixels that were removed
+	Trackers int
+	// BlockedImages counts the remote images that were removed
+	BlockedImages int
+}
+
+// URLs of well-known open trackers, for pixels that don't give themselves away by their size
+var trackerURLRegex = regexp.MustCompile(`(?i)` +
+	`/track/open|/wf/open|/open\.(gif|png|php|aspx)|/pixel(\.gif|\.png|/|\?|$)|/beacon(\.gif|/|\?|$)|/e/o/|` +
+	`//[^/]*(mailtrack\.io|mailfoogae\.appspot\.com|t\.yesware\.com|pixel\.|tracking\.)`)
+
+var (
+	hiddenStyleRegex = regexp.MustCompile(`(?i)display\s*:\s*none|visibility\s*:\s*hidden|opacity\s*:\s*0(\.0+)?\s*(;|$)`)
+)
+
+// PrepareHTML sanitizes an HTML body for display: tracking pixels are removed and
+// images are only loaded from where the options allow. Trackers are found in the
+// markup as sent, before sanitizing drops what gives them away.
+func PrepareHTML(body string, opts DisplayOptions) DisplayHTML {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return DisplayHTML{HTML: html.EscapeString(body)}
+	}
+
+	var result DisplayHTML
+	root := findBody(doc)
+	prepareImages(root, opts, &result)
+
+	var buf bytes.Buffer
+	for node := root.FirstChild; node != nil; node = node.NextSibling {
+		html.Render(&buf, node)
+	}
+	result.HTML = Sanitize(buf.String())
+	return result
+}
+
+// CountTrackers returns the number of tracking pixels in an HTML body
+func CountTrackers(body string) int {
+	return PrepareHTML(body, DisplayOptions{}).Trackers
+}
+
+func prepareImages(node *html.Node, opts DisplayOptions, result *DisplayHTML) {
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.ElementNode && child.DataAtom == atom.Img {
+			prepareImage(child, opts, result)
+		} else {
+			prepareImages(child, opts, result)
+		}
+		child = next
+	}
+}
+
+func prepareImage(img *html.Node, opts DisplayOptions, result *DisplayHTML) {
+	src := strings.TrimSpace(attribute(img, "src"))
+	if isTrackingPixel(img, src) {
+		img.Parent.RemoveChild(img)
+		result.Trackers++
+		return
+	}
+
+	var url string
+	lower := strings.ToLower(src)
+	switch {
+	case strings.HasPrefix(lower, "cid:"):
+		if opts.InlineImage != nil {
+			url = opts.InlineImage(strings.Trim(src[len("cid:"):], "<>"))
+		}
+	case strings.HasPrefix(lower, "http:") || strings.HasPrefix(lower, "https:"):
+		if opts.RemoteImage != nil {
+			url = opts.RemoteImage(src)
+		}
+		if url == "" {
+			result.BlockedImages++
+		}
+	}
+
+	if url == "" {
+		img.Parent.RemoveChild(img)
+		return
+	}
+	setAttribute(img, "src", url)
+}
+
+// isTrackingPixel reports whether an image only exists to report that the email
+// was opened: an invisible or tiny image, or one served by a known tracker
+func isTrackingPixel(img *html.Node, src string) bool {
+	lower := strings.ToLower(src)
+	if !strings.HasPrefix(lower, "http:") && !strings.HasPrefix(lower, "https:") {
+		return false
+	}
+	if trackerURLRegex.MatchString(src) {
+		return true
+	}
+
+	style := attribute(img, "style")
+	if hiddenStyleRegex.MatchString(style) {
+		return true
+	}
+
+	width, hasWidth := pixelSize(attribute(img, "width"))
+	height, hasHeight := pixelSize(attribute(img, "height"))
+	for _, declaration := range strings.Split(style, ";") {
+		property, value, _ := strings.Cut(declaration, ":")
+		switch strings.ToLower(strings.TrimSpace(property)) {
+		case "width":
+			if size, ok := pixelSize(value); ok {
+				width, hasWidth = size, true
+			}
+		case "height":
+			if size, ok := pixelSize(value); ok {
+				height, hasHeight = size, true
+			}
+		}
+	}
+
+	// Spacer images are 1px in one direction only
+	return hasWidth && hasHeight && width <= 1 && height <= 1 ||
+		hasWidth && width == 0 || hasHeight && height == 0
+}
+
+// pixelSize parses a width or height such as "1" or "1px"
+func pixelSize(value string) (float64, bool) {
+	value = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), "px")
+	if value == "" {
+		return 0, false
+	}
+	size, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return size, true
+}
+
+func setAttribute(node *html.Node, key, value string) {
+	for i := range node.Attr {
+		if node.Attr[i].Key == key {
+			node.Attr[i].Val = value
+			return
+		}
+	}
+	node.Attr = append(node.Attr, html.Attribute{Key: key, Val: value})
+}
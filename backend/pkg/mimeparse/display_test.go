@@ -0,0 +1,94 @@
+package mimeparse
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestPrepareHTML(t *testing.T) {
+	proxy := func(src string) string { return "https://app.example/image-proxy?url=" + url.QueryEscape(src) }
+	inline := func(contentID string) string {
+		if contentID == "logo@shop" {
+			return "https://app.example/emails/1/attachments/3"
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		opts     DisplayOptions
+		expected DisplayHTML
+	}{
+		{
+			name: "blocks remote images by default",
+			body: `<p>Hi</p><img src="https://cdn.shop.example/banner.png" width="600" alt="Sale">`,
+			expected: DisplayHTML{
+				HTML:          `<p>Hi</p>`,
+				BlockedImages: 1,
+			},
+		},
+		{
+			name: "proxies remote images",
+			body: `<img src="https://cdn.shop.example/banner.png?a=1&b=2" width="600">`,
+			opts: DisplayOptions{RemoteImage: proxy},
+			expected: DisplayHTML{
+				HTML: `<img src="https://app.example/image-proxy?url=https%3A%2F%2Fcdn.shop.example%2Fbanner.png%3Fa%3D1%26b%3D2" width="600"/>`,
+			},
+		},
+		{
+			name: "strips tracking pixels even when images are proxied",
+			body: `<p>Hi</p>` +
+				`<img src="https://mail.shop.example/o/abc" width="1" height="1">` +
+				`<img src="https://mail.shop.example/o/def" style="width: 1px; height: 1px">` +
+				`<img src="https://mail.shop.example/o/ghi" style="display:none">` +
+				`<img src="https://mail.shop.example/o/jkl" height="0">` +
+				`<img src="https://u123.ct.sendgrid.net/wf/open?upn=xyz">` +
+				`<img src="https://mailtrack.io/trace/mail/abc.png">`,
+			opts: DisplayOptions{RemoteImage: proxy},
+			expected: DisplayHTML{
+				HTML:     `<p>Hi</p>`,
+				Trackers: 6,
+			},
+		},
+		{
+			name: "keeps spacers and sized images",
+			body: `<img src="https://cdn.shop.example/spacer.gif" width="1" height="40"><img src="https://cdn.shop.example/p.png" width="100%">`,
+			opts: DisplayOptions{RemoteImage: func(src string) string { return src }},
+			expected: DisplayHTML{
+				HTML: `<img src="https://cdn.shop.example/spacer.gif" width="1" height="40"/><img src="https://cdn.shop.example/p.png" width="100%"/>`,
+			},
+		},
+		{
+			name: "points inline images at their parts",
+			body: `<img src="cid:logo@shop" alt="Logo"><img src="cid:missing">`,
+			opts: DisplayOptions{InlineImage: inline},
+			expected: DisplayHTML{
+				HTML: `<img src="https://app.example/emails/1/attachments/3" alt="Logo"/>`,
+			},
+		},
+		{
+			name: "sanitizes raw HTML stored before sanitization",
+			body: `<html><head><style>p{}</style></head><body onload="x()"><script>x()</script><a href="https://shop.example">Shop</a></body></html>`,
+			expected: DisplayHTML{
+				HTML: `<a href="https://shop.example" target="_blank" rel="noopener noreferrer">Shop</a>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PrepareHTML(tt.body, tt.opts)
+			if got != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCountTrackers(t *testing.T) {
+	body := `<img src="https://cdn.shop.example/banner.png"><img src="https://pixel.wp.com/g.gif?blog=1" width="1" height="1">`
+	if got := CountTrackers(body); got != 1 {
+		t.Errorf("Expected 1 tracker, got %d", got)
+	}
+}
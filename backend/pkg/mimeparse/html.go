@@ -0,0 +1,302 @@
+package mimeparse
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Elements dropped with their content: they run code, load other documents or
+// only matter to the document head
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Frame: true, atom.Frameset: true,
+	atom.Object: true, atom.Embed: true, atom.Applet: true, atom.Noscript: true, atom.Template: true,
+	atom.Head: true, atom.Title: true, atom.Meta: true, atom.Link: true, atom.Base: true,
+	atom.Form: true, atom.Input: true, atom.Button: true, atom.Select: true, atom.Textarea: true,
+	atom.Svg: true, atom.Math: true,
+}
+
+// Elements kept as they are; any other element is replaced by its content
+var allowedElements = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.Address: true, atom.B: true, atom.Big: true, atom.Blockquote: true,
+	atom.Br: true, atom.Caption: true, atom.Center: true, atom.Cite: true, atom.Code: true, atom.Col: true,
+	atom.Colgroup: true, atom.Dd: true, atom.Del: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Em: true, atom.Font: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true,
+	atom.H5: true, atom.H6: true, atom.Hr: true, atom.I: true, atom.Img: true, atom.Ins: true,
+	atom.Li: true, atom.Ol: true, atom.P: true, atom.Pre: true, atom.Q: true, atom.S: true,
+	atom.Small: true, atom.Span: true, atom.Strike: true, atom.Strong: true, atom.Sub: true, atom.Sup: true,
+	atom.Table: true, atom.Tbody: true, atom.Td: true, atom.Tfoot: true, atom.Th: true, atom.Thead: true,
+	atom.Tr: true, atom.U: true, atom.Ul: true,
+}
+
+var allowedAttributes = map[string]bool{
+	"href": true, "src": true, "alt": true, "title": true, "width": true, "height": true,
+	"align": true, "valign": true, "bgcolor": true, "color": true, "border": true, "cellpadding": true,
+	"cellspacing": true, "colspan": true, "rowspan": true, "dir": true, "lang": true, "style": true,
+	"face": true, "size": true,
+}
+
+// URL schemes allowed in href and src; relative URLs are meaningless in an email
+var allowedSchemes = []string{"http:", "https:", "mailto:", "tel:", "cid:"}
+
+// Inline styles can load resources or, in old browsers, run script
+var unsafeStyleRegex = regexp.MustCompile(`(?i)url\s*\(|expression\s*\(|javascript:|@import|behavior\s*:|-moz-binding`)
+
+// Sanitize keeps the markup of an HTML body that is safe to render: allowlisted
+// elements and attributes, links and images with allowlisted schemes, and inline
+// styles that load nothing. Links open in a new tab without a referrer.
+func Sanitize(body string) string {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return html.EscapeString(body)
+	}
+
+	var buf bytes.Buffer
+	for _, node := range sanitizeChildren(findBody(doc)) {
+		html.Render(&buf, node)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// findBody returns the body element html.Parse always creates
+func findBody(node *html.Node) *html.Node {
+	if node.Type == html.ElementNode && node.DataAtom == atom.Body {
+		return node
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if body := findBody(child); body != nil {
+			return body
+		}
+	}
+	return nil
+}
+
+// sanitizeChildren returns sanitized, detached copies of the children of node
+func sanitizeChildren(node *html.Node) []*html.Node {
+	if node == nil {
+		return nil
+	}
+
+	var result []*html.Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case html.TextNode:
+			result = append(result, &html.Node{Type: html.TextNode, Data: child.Data})
+
+		case html.ElementNode:
+			if droppedElements[child.DataAtom] {
+				continue
+			}
+			children := sanitizeChildren(child)
+			if !allowedElements[child.DataAtom] {
+				result = append(result, children...)
+				continue
+			}
+
+			clean := &html.Node{Type: html.ElementNode, Data: child.Data, DataAtom: child.DataAtom, Attr: sanitizeAttributes(child)}
+			if child.DataAtom == atom.Img && !hasAttribute(clean, "src") {
+				continue
+			}
+			for _, grandchild := range children {
+				clean.AppendChild(grandchild)
+			}
+			result = append(result, clean)
+		}
+	}
+	return result
+}
+
+func sanitizeAttributes(node *html.Node) []html.Attribute {
+	var attrs []html.Attribute
+	for _, attr := range node.Attr {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || !allowedAttributes[key] {
+			continue
+		}
+
+		switch key {
+		case "href", "src":
+			if !allowedURL(attr.Val) {
+				continue
+			}
+		case "style":
+			if unsafeStyleRegex.MatchString(attr.Val) {
+				continue
+			}
+		}
+		attrs = append(attrs, html.Attribute{Key: key, Val: attr.Val})
+	}
+
+	if node.DataAtom == atom.A && hasAttributeIn(attrs, "href") {
+		attrs = append(attrs,
+			html.Attribute{Key: "target", Val: "_blank"},
+			html.Attribute{Key: "rel", Val: "noopener noreferrer"},
+		)
+	}
+	return attrs
+}
+
+func allowedURL(value string) bool {
+	// Browsers ignore whitespace and control characters in schemes, e.g. "java\tscript:"
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(value))
+
+	for _, scheme := range allowedSchemes {
+		if strings.HasPrefix(cleaned, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAttribute(node *html.Node, key string) bool {
+	return hasAttributeIn(node.Attr, key)
+}
+
+func hasAttributeIn(attrs []html.Attribute, key string) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Elements that start on a new line in the text version
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true, atom.Center: true,
+	atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Footer: true, atom.H1: true,
+	atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true,
+	atom.Hr: true, atom.Li: true, atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true,
+	atom.Table: true, atom.Tr: true, atom.Ul: true,
+}
+
+var (
+	spaceRegex      = regexp.MustCompile(`[ \t\r\n\f\x{00a0}]+`)
+	blankLinesRegex = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText renders an HTML body as plain text: one line per block, list items
+// marked with "- ", and link targets after their text when it doesn't show them
+func HTMLToText(body string) string {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return body
+	}
+
+	var w textWriter
+	w.node(findBody(doc))
+
+	lines := strings.Split(w.buf.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text := blankLinesRegex.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
+
+type textWriter struct {
+	buf bytes.Buffer
+	pre int
+}
+
+func (w *textWriter) newline() {
+	if w.buf.Len() > 0 && !bytes.HasSuffix(w.buf.Bytes(), []byte("\n")) {
+		w.buf.WriteByte('\n')
+	}
+}
+
+func (w *textWriter) text(data string) {
+	if w.pre > 0 {
+		w.buf.WriteString(data)
+		return
+	}
+	data = spaceRegex.ReplaceAllString(data, " ")
+	if data == " " && (w.buf.Len() == 0 || bytes.HasSuffix(w.buf.Bytes(), []byte(" ")) || bytes.HasSuffix(w.buf.Bytes(), []byte("\n"))) {
+		return
+	}
+	w.buf.WriteString(data)
+}
+
+func (w *textWriter) node(node *html.Node) {
+	if node == nil {
+		return
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case html.TextNode:
+			w.text(child.Data)
+
+		case html.ElementNode:
+			if droppedElements[child.DataAtom] {
+				continue
+			}
+			w.element(child)
+		}
+	}
+}
+
+func (w *textWriter) element(node *html.Node) {
+	switch node.DataAtom {
+	case atom.Br:
+		w.buf.WriteByte('\n')
+		return
+	case atom.Img:
+		return
+	case atom.Td, atom.Th:
+		if node.PrevSibling != nil {
+			w.text(" ")
+		}
+	}
+
+	block := blockElements[node.DataAtom]
+	if block {
+		w.newline()
+	}
+	if node.DataAtom == atom.P || node.DataAtom == atom.Table || node.DataAtom == atom.Blockquote {
+		w.buf.WriteByte('\n')
+	}
+	if node.DataAtom == atom.Li {
+		w.buf.WriteString("- ")
+	}
+	if node.DataAtom == atom.Pre {
+		w.pre++
+	}
+
+	start := w.buf.Len()
+	w.node(node)
+
+	if node.DataAtom == atom.Pre {
+		w.pre--
+	}
+	if node.DataAtom == atom.A {
+		href := strings.TrimSpace(attribute(node, "href"))
+		shown := strings.TrimSpace(w.buf.String()[start:])
+		if allowedURL(href) && !strings.HasPrefix(strings.ToLower(href), "cid:") && !strings.Contains(shown, strings.TrimPrefix(href, "mailto:")) {
+			w.buf.WriteString(" (" + href + ")")
+		}
+	}
+	if block {
+		w.newline()
+	}
+	if node.DataAtom == atom.P || node.DataAtom == atom.Table || node.DataAtom == atom.Blockquote {
+		w.buf.WriteByte('\n')
+	}
+}
+
+func attribute(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
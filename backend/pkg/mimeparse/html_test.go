@@ -0,0 +1,87 @@
+package mimeparse
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "drops scripts and event handlers",
+			body:     `<p onclick="steal()">Hi<script>steal()</script></p>`,
+			expected: `<p>Hi</p>`,
+		},
+		{
+			name:     "drops links with unsafe schemes",
+			body:     `<a href=" JaVa&#x09;script:alert(1)">x</a><img src="data:image/png;base64,AAAA">`,
+			expected: `<a>x</a>`,
+		},
+		{
+			name:     "keeps unknown elements' content",
+			body:     `<custom-tag><b>bold</b></custom-tag>`,
+			expected: `<b>bold</b>`,
+		},
+		{
+			name:     "drops styles that load resources",
+			body:     `<div style="color: red">a</div><div style="background:URL(https://t.example/p.gif)">b</div>`,
+			expected: `<div style="color: red">a</div><div>b</div>`,
+		},
+		{
+			name:     "escapes text",
+			body:     `1 &lt; 2 &amp;&amp; "quotes"`,
+			expected: `1 &lt; 2 &amp;&amp; &#34;quotes&#34;`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.body); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "blocks and line breaks",
+			body:     `<h1>Title</h1><p>First   line<br>second line</p><div>Last</div>`,
+			expected: "Title\n\nFirst line\nsecond line\n\nLast",
+		},
+		{
+			name:     "links show their target unless the text does",
+			body:     `<a href="https://a.example/x">Read more</a> <a href="https://b.example">https://b.example</a>`,
+			expected: "Read more (https://a.example/x) https://b.example",
+		},
+		{
+			name:     "lists and table cells",
+			body:     `<ul><li>One</li><li>Two</li></ul><table><tr><td>a</td><td>b</td></tr></table>`,
+			expected: "- One\n- Two\n\na b",
+		},
+		{
+			name:     "preformatted text keeps its spacing",
+			body:     "<pre>col1  col2\nval1  val2</pre>",
+			expected: "col1  col2\nval1  val2",
+		},
+		{
+			name:     "hidden content is skipped",
+			body:     `<style>p { color: red }</style><script>x()</script><p>Visible</p>`,
+			expected: "Visible",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTMLToText(tt.body); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
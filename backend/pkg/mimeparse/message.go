@@ -0,0 +1,206 @@
+package mimeparse
+
+import (
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Message is the content of a MIME message
+type Message struct {
+	// HTML is the HTML body as sent, or "" for plain text messages. It is not safe
+	// to render; Sanitize or PrepareHTML it for display.
+	HTML string `json:"html"`
+	// Text is the plain text body, converted from the HTML body when the message has no text part
+	Text        string       `json:"text"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// Attachment describes a part that isn't part of the body
+type Attachment struct {
+	Filename string `json:"filename"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	// ContentID is how the HTML body refers to inline parts, e.g. <img src="cid:logo">
+	ContentID    string `json:"content_id,omitempty"`
+	Inline       bool   `json:"inline"`
+	AttachmentID string `json:"attachment_id,omitempty"`
+	PartID       string `json:"part_id,omitempty"`
+	// Data is the decoded content, when the part carries it
+	Data []byte `json:"-"`
+}
+
+// Parse reads a raw RFC 5322 message and extracts its content
+func Parse(r io.Reader) (*Message, error) {
+	root, err := Read(r)
+	if err != nil {
+		return nil, err
+	}
+	return Extract(root), nil
+}
+
+// Extract walks a MIME tree for the message body and attachments
+func Extract(root *Part) *Message {
+	var e extractor
+	e.walk(root)
+
+	message := &Message{
+		Text:        strings.TrimSpace(strings.Join(e.text, "\n\n")),
+		Attachments: e.attachments,
+	}
+	if len(e.html) > 0 {
+		message.HTML = strings.Join(e.html, "\n")
+		if message.Text == "" {
+			message.Text = HTMLToText(message.HTML)
+		}
+	}
+	if message.Attachments == nil {
+		message.Attachments = []Attachment{}
+	}
+
+	return message
+}
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// DecodeHeader decodes RFC 2047 encoded words such as "=?ISO-8859-1?Q?Caf=E9?=".
+// Values that fail to decode are returned unchanged.
+func DecodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+type extractor struct {
+	html        []string
+	text        []string
+	attachments []Attachment
+}
+
+func (e *extractor) walk(part *Part) {
+	mediaType, params := part.MediaType()
+	switch {
+	case mediaType == "multipart/alternative" && len(part.Parts) > 0:
+		e.alternative(part)
+
+	case strings.HasPrefix(mediaType, "multipart/") && len(part.Parts) > 0:
+		for _, child := range part.Parts {
+			e.walk(child)
+		}
+
+	case isBody(part, mediaType):
+		content := decodeCharset(part.Body, mediaType, params["charset"])
+		if mediaType == "text/html" {
+			e.html = append(e.html, content)
+		} else {
+			e.text = append(e.text, content)
+		}
+
+	default:
+		e.attachments = append(e.attachments, newAttachment(part, mediaType, params))
+	}
+}
+
+// alternative keeps the richest version of each kind: senders list alternatives in
+// increasing order of preference (RFC 2046). Parts of other kinds, such as the
+// text/calendar of an invitation, are kept as attachments.
+func (e *extractor) alternative(part *Part) {
+	var html, text *extractor
+	for _, child := range part.Parts {
+		var sub extractor
+		sub.walk(child)
+
+		switch {
+		case len(sub.html) > 0:
+			html = &sub
+		case len(sub.text) > 0:
+			text = &sub
+		default:
+			e.attachments = append(e.attachments, sub.attachments...)
+		}
+	}
+
+	// Inline images come with the version that shows them
+	if html != nil {
+		e.html = append(e.html, html.html...)
+		e.attachments = append(e.attachments, html.attachments...)
+	}
+	if text != nil {
+		e.text = append(e.text, text.text...)
+		if html == nil {
+			e.attachments = append(e.attachments, text.attachments...)
+		}
+	}
+}
+
+// isBody reports whether a leaf is body text rather than an attached file
+func isBody(part *Part, mediaType string) bool {
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return false
+	}
+	if part.AttachmentID != "" {
+		return false
+	}
+	disposition, params := part.disposition()
+	if disposition == "attachment" {
+		return false
+	}
+	_, typeParams := part.MediaType()
+	return params["filename"] == "" && typeParams["name"] == ""
+}
+
+func newAttachment(part *Part, mediaType string, params map[string]string) Attachment {
+	disposition, dispositionParams := part.disposition()
+
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	// Some clients encode filenames as RFC 2047 words instead of RFC 2231
+	filename = DecodeHeader(filename)
+	if filename == "" && mediaType == "message/rfc822" {
+		filename = "message.eml"
+	}
+
+	contentID := strings.Trim(strings.TrimSpace(part.Header.Get("Content-Id")), "<>")
+
+	return Attachment{
+		Filename:     filename,
+		MimeType:     mediaType,
+		Size:         part.Size,
+		ContentID:    contentID,
+		Inline:       disposition == "inline" || disposition == "" && contentID != "",
+		AttachmentID: part.AttachmentID,
+		PartID:       part.PartID,
+		Data:         part.Body,
+	}
+}
+
+// decodeCharset converts text to UTF-8 with \n line endings. A declared charset
+// wins over one declared in HTML; without either, UTF-8 is assumed when the text
+// is valid UTF-8 and Windows-1252 otherwise. US-ASCII labels are often wrong, so
+// they count as none.
+func decodeCharset(body []byte, mediaType, label string) string {
+	contentType := mediaType
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "", "us-ascii", "ascii":
+	default:
+		contentType = mime.FormatMediaType(mediaType, map[string]string{"charset": label})
+	}
+
+	encoding, _, _ := charset.DetermineEncoding(body, contentType)
+	decoded, err := encoding.NewDecoder().Bytes(body)
+	if err != nil {
+		decoded = body
+	}
+	text := string(decoded)
+	if !utf8.Valid(decoded) {
+		text = strings.ToValidUTF8(text, "\uFFFD")
+	}
+	return strings.ReplaceAll(text, "\r\n", "\n")
+}
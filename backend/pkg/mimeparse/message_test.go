@@ -0,0 +1,168 @@
+package mimeparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Regenerate the golden files after an intended change with
+// go test ./pkg/mimeparse -run TestExtract_Golden -update
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden is what a fixture is expected to parse into
+type golden struct {
+	Subject     string             `json:"subject"`
+	From        string             `json:"from"`
+	HTML        string             `json:"html"`
+	Sanitized   string             `json:"sanitized"`
+	Trackers    int                `json:"trackers"`
+	Text        string             `json:"text"`
+	Attachments []goldenAttachment `json:"attachments"`
+}
+
+type goldenAttachment struct {
+	Attachment
+	// Content of text attachments, so decoding is covered too
+	Content string `json:"content,omitempty"`
+}
+
+func TestExtract_Golden(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "*.eml"))
+	if err != nil {
+		t.Fatalf("Failed to list fixtures: %v", err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("Expected fixtures in testdata")
+	}
+
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".eml")
+		t.Run(name, func(t *testing.T) {
+			raw, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("Failed to read fixture: %v", err)
+			}
+
+			root, err := Read(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			message := Extract(root)
+
+			got := golden{
+				Subject:     DecodeHeader(root.Header.Get("Subject")),
+				From:        DecodeHeader(root.Header.Get("From")),
+				HTML:        message.HTML,
+				Sanitized:   Sanitize(message.HTML),
+				Trackers:    CountTrackers(message.HTML),
+				Text:        message.Text,
+				Attachments: []goldenAttachment{},
+			}
+			for _, attachment := range message.Attachments {
+				entry := goldenAttachment{Attachment: attachment}
+				if strings.HasPrefix(attachment.MimeType, "text/") {
+					entry.Content = string(attachment.Data)
+				}
+				got.Attachments = append(got.Attachments, entry)
+			}
+
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(got); err != nil {
+				t.Fatalf("Failed to encode result: %v", err)
+			}
+			actual := buf.Bytes()
+
+			goldenPath := filepath.Join("testdata", name+".golden.json")
+			if *update {
+				if err := os.WriteFile(goldenPath, actual, 0644); err != nil {
+					t.Fatalf("Failed to write golden file: %v", err)
+				}
+				return
+			}
+
+			expected, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(actual, expected) {
+				t.Errorf("Result differs from %s\ngot:\n%s\nwant:\n%s", goldenPath, actual, expected)
+			}
+		})
+	}
+}
+
+func TestDecodeHeader(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"=?iso-8859-1?Q?Caf=E9?= <cafe@example.com>", "Café <cafe@example.com>"},
+		{"=?UTF-8?B?8J+OiQ==?= Party", "🎉 Party"},
+		{"Plain subject", "Plain subject"},
+		// Unknown charsets are left alone rather than dropped
+		{"=?x-unknown?Q?abc?=", "=?x-unknown?Q?abc?="},
+	}
+
+	for _, tt := range tests {
+		if got := DecodeHeader(tt.value); got != tt.expected {
+			t.Errorf("DecodeHeader(%q): expected %q, got %q", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestExtract_PartsLoadedElsewhere(t *testing.T) {
+	// Gmail leaves attachment content out of the message and gives an ID instead
+	root := &Part{
+		Header: map[string][]string{"Content-Type": {"multipart/mixed; boundary=x"}},
+		Parts: []*Part{
+			{Header: map[string][]string{"Content-Type": {"text/plain; charset=utf-8"}}, Body: []byte("See attached"), Size: 12},
+			{
+				Header:       map[string][]string{"Content-Type": {"text/plain; name=notes.txt"}},
+				AttachmentID: "ANGjdJ8",
+				Size:         2048,
+			},
+		},
+	}
+
+	message := Extract(root)
+	if message.Text != "See attached" {
+		t.Errorf("Expected the text body, got %q", message.Text)
+	}
+	if len(message.Attachments) != 1 {
+		t.Fatalf("Expected 1 attachment, got %d", len(message.Attachments))
+	}
+	attachment := message.Attachments[0]
+	if attachment.Filename != "notes.txt" || attachment.AttachmentID != "ANGjdJ8" || attachment.Size != 2048 {
+		t.Errorf("Unexpected attachment %+v", attachment)
+	}
+}
+
+func TestExtract_KeepsHTMLAsSent(t *testing.T) {
+	body := `<p>Verify your account</p><form action="https://login.bank.example.net/"><input type="password"></form>` +
+		`<img src="https://mail.bank.example.net/o/1" style="display:none">`
+	root := &Part{
+		Header: map[string][]string{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:   []byte(body),
+		Size:   int64(len(body)),
+	}
+
+	// Risk scoring and tracker counting look at the markup the sender wrote
+	message := Extract(root)
+	if message.HTML != body {
+		t.Errorf("Expected the HTML as sent, got %q", message.HTML)
+	}
+	if got := CountTrackers(message.HTML); got != 1 {
+		t.Errorf("Expected 1 tracker, got %d", got)
+	}
+	if message.Text != "Verify your account" {
+		t.Errorf("Expected the text of the body, got %q", message.Text)
+	}
+}
@@ -0,0 +1,160 @@
+// Package mimeparse turns MIME messages into what the app stores and shows: a
+// sanitized HTML body, a plain text body and the list of attachments.
+package mimeparse
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+)
+
+// Deeper nesting than this is treated as a leaf, so a hostile message can't recurse forever
+const maxDepth = 32
+
+// Part is a node of a MIME tree. Multipart nodes have Parts; leaves have Body.
+type Part struct {
+	Header textproto.MIMEHeader
+	// Body is the content after transfer decoding, still in its original charset
+	Body  []byte
+	Parts []*Part
+	// AttachmentID identifies content kept out of the message, as Gmail does for
+	// attachments; Body is empty for such parts
+	AttachmentID string
+	// PartID locates the part in a message fetched from Gmail, e.g. "1.2"
+	PartID string
+	// Size of the decoded content, also known when Body isn't loaded
+	Size int64
+}
+
+// Read parses a raw RFC 5322 message into its MIME tree. Broken multiparts are
+// read up to where they break rather than rejected.
+func Read(r io.Reader) (*Part, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+
+	return readPart(textproto.MIMEHeader(msg.Header), msg.Body, 0), nil
+}
+
+func readPart(header textproto.MIMEHeader, body io.Reader, depth int) *Part {
+	part := &Part{Header: header}
+
+	mediaType, params := part.MediaType()
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" && depth < maxDepth {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			// Raw parts, so quoted-printable is decoded below like every other encoding
+			child, err := reader.NextRawPart()
+			if err != nil {
+				break
+			}
+			part.Parts = append(part.Parts, readPart(child.Header, child, depth+1))
+		}
+		return part
+	}
+
+	// A truncated body still has its beginning
+	data, _ := io.ReadAll(body)
+	part.Body = decodeTransfer(header.Get("Content-Transfer-Encoding"), data)
+	part.Size = int64(len(part.Body))
+	return part
+}
+
+// decodeTransfer undoes a Content-Transfer-Encoding. Undecodable content is kept as is.
+func decodeTransfer(encoding string, data []byte) []byte {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return decodeBase64(data)
+	case "quoted-printable":
+		decoded, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			return data
+		}
+		return decoded
+	}
+	return data
+}
+
+// decodeBase64 decodes leniently: line breaks, stray characters and missing padding
+// are common in the wild
+func decodeBase64(data []byte) []byte {
+	clean := make([]byte, 0, len(data))
+	for _, c := range data {
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '+' || c == '/' {
+			clean = append(clean, c)
+		}
+	}
+	// A lone trailing character can't encode a byte
+	if len(clean)%4 == 1 {
+		clean = clean[:len(clean)-1]
+	}
+
+	decoded := make([]byte, base64.RawStdEncoding.DecodedLen(len(clean)))
+	n, err := base64.RawStdEncoding.Decode(decoded, clean)
+	if err != nil {
+		return data
+	}
+	return decoded[:n]
+}
+
+// MediaType returns the lower-cased media type and parameters of the part. Parts
+// without a usable Content-Type are plain text, as RFC 2045 prescribes.
+func (p *Part) MediaType() (string, map[string]string) {
+	value := p.Header.Get("Content-Type")
+	if value == "" {
+		return "text/plain", map[string]string{}
+	}
+
+	mediaType, params, err := mime.ParseMediaType(value)
+	if mediaType == "" {
+		return "text/plain", map[string]string{}
+	}
+	if err != nil {
+		// Keep the parameters that can be recovered from a malformed header
+		params = looseParams(value)
+	}
+	return strings.ToLower(mediaType), params
+}
+
+// disposition returns the lower-cased Content-Disposition type and parameters
+func (p *Part) disposition() (string, map[string]string) {
+	value := p.Header.Get("Content-Disposition")
+	if value == "" {
+		return "", map[string]string{}
+	}
+
+	disposition, params, err := mime.ParseMediaType(value)
+	if err != nil {
+		params = looseParams(value)
+		disposition, _, _ = strings.Cut(value, ";")
+	}
+	return strings.ToLower(strings.TrimSpace(disposition)), params
+}
+
+var looseParamRegex = regexp.MustCompile(`(?i)([a-z0-9*-]+)\s*=\s*("[^"]*"|[^;]*)`)
+
+// looseParams reads parameters from a header mime.ParseMediaType rejects, such as
+// an unquoted filename with spaces
+func looseParams(value string) map[string]string {
+	params := map[string]string{}
+	_, rest, found := strings.Cut(value, ";")
+	if !found {
+		return params
+	}
+	for _, match := range looseParamRegex.FindAllStringSubmatch(rest, -1) {
+		name := strings.ToLower(match[1])
+		if _, exists := params[name]; !exists {
+			params[name] = strings.Trim(strings.TrimSpace(match[2]), `"`)
+		}
+	}
+	return params
+}
@@ -692,23 +692,41 @@ export default function DashboardPage() {
       });
 
       if (response.ok) {
-        const data = await response.json();
-        const results = data.results;
-        
+        let job = await response.json();
+
+        // The unsubscribe runs as a background job; poll until it finishes, giving up
+        // after ten minutes while the job carries on in the background
+        const maxPolls = 300;
+        let polls = 0;
+        while (job.status !== 'completed' && job.status !== 'cancelled') {
+          if (polls++ >= maxPolls) {
+            alert('The unsubscribe is taking longer than expected and continues in the background. Check back later for the results.');
+            return;
+          }
+          await new Promise(resolve => setTimeout(resolve, 2000));
+          const jobResponse = await fetch(`http://localhost:8080/jobs/${job.id}`, {
+            credentials: 'include',
+          });
+          if (!jobResponse.ok) {
+            throw new Error('Failed to fetch unsubscribe job status');
+          }
+          job = await jobResponse.json();
+        }
+
         // Count successful and failed unsubscribes and collect error details
         let successCount = 0;
         let failureCount = 0;
         const failureMessages: string[] = [];
-        
-        Object.entries(results as Record<string, {success: boolean, message?: string, error_type?: string}>).forEach(([emailId, result]) => {
-          if (result.success) {
+
+        (job.items as {email_id: number, status: string, message?: string}[] || []).forEach((item) => {
+          if (item.status === 'succeeded') {
             successCount++;
-          } else {
+          } else if (item.status === 'failed') {
             failureCount++;
-            if (result.message) {
-              failureMessages.push(`Email ${emailId}: ${result.message}`);
+            if (item.message) {
+              failureMessages.push(`Email ${item.email_id}: ${item.message}`);
             } else {
-              failureMessages.push(`Email ${emailId}: Unknown error`);
+              failureMessages.push(`Email ${item.email_id}: Unknown error`);
             }
           }
         });